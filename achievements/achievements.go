@@ -0,0 +1,186 @@
+// Package achievements evaluates RetroAchievements style achievements. The
+// achievement sets are fetched from a server through a Client, their rcheevos
+// condition strings are parsed into triggers, and the triggers are evaluated
+// every frame against the emulated memory. Unlocks raise a notification and are
+// sent back to the server.
+package achievements
+
+import (
+	"log"
+	"sync"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+)
+
+// Achievement states
+const (
+	// Waiting achievements must be false once before they can unlock, so that
+	// they don't unlock on a state that was already true when loading
+	Waiting = iota
+	// Active achievements unlock as soon as their trigger is true
+	Active
+	// Unlocked achievements are not evaluated anymore
+	Unlocked
+)
+
+// Achievement is an achievement with its parsed trigger
+type Achievement struct {
+	Definition
+	State   int
+	trigger *Trigger
+}
+
+// Set is the list of achievements of a game
+type Set struct {
+	Game         *Game
+	Achievements []*Achievement
+}
+
+// NewSet parses the triggers of the achievements of a game. Achievements with
+// invalid triggers are skipped.
+func NewSet(game *Game) *Set {
+	s := &Set{Game: game}
+	for _, d := range game.Achievements {
+		t, err := ParseTrigger(d.MemAddr)
+		if err != nil {
+			log.Printf("[Achievements]: %s: %v", d.Title, err)
+			continue
+		}
+		s.Achievements = append(s.Achievements, &Achievement{Definition: d, trigger: t})
+	}
+	return s
+}
+
+// Process evaluates the achievements for a new frame and returns the ones
+// that were unlocked
+func (s *Set) Process(mem Memory) []*Achievement {
+	unlocked := []*Achievement{}
+	for _, a := range s.Achievements {
+		if a.State == Unlocked {
+			continue
+		}
+		ok := a.trigger.Test(mem)
+		switch {
+		case a.State == Waiting && !ok:
+			a.State = Active
+		case a.State == Active && ok:
+			a.State = Unlocked
+			unlocked = append(unlocked, a)
+		}
+	}
+	return unlocked
+}
+
+// Suspend puts the achievements that are not unlocked back to waiting, so
+// that they have to be false again before unlocking. It is used when the game
+// isn't played normally, like while rewinding or after loading a state.
+func (s *Set) Suspend() {
+	for _, a := range s.Achievements {
+		if a.State != Unlocked {
+			a.State = Waiting
+			a.trigger.reset()
+		}
+	}
+}
+
+var (
+	mutex      sync.Mutex
+	current    *Set
+	client     Client
+	generation int  // incremented on unload, to discard sets of previous games
+	announce   bool // the set was loaded in the background and not announced yet
+)
+
+// NewClient returns the client used to reach the achievements service
+var NewClient = func() Client {
+	return &HTTPClient{
+		Host:  settings.Current.AchievementsHost,
+		User:  settings.Current.AchievementsUser,
+		Token: settings.Current.AchievementsToken,
+	}
+}
+
+// Load fetches the achievement set of a game in the background. path is the
+// content given to the core and data its bytes, or nil if the core was given
+// the path, see Hash.
+func Load(path string, data []byte) {
+	Unload()
+	mutex.Lock()
+	gen := generation
+	mutex.Unlock()
+
+	c := NewClient()
+	go func() {
+		hash, err := Hash(path, data)
+		if err != nil {
+			log.Println("[Achievements]:", err)
+			return
+		}
+		game, err := c.Game(hash)
+		if err != nil {
+			log.Println("[Achievements]:", err)
+			return
+		}
+		set := NewSet(game)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if gen != generation {
+			return
+		}
+		current = set
+		client = c
+		announce = true
+	}()
+}
+
+// Unload drops the achievement set of the current game
+func Unload() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = nil
+	client = nil
+	announce = false
+	generation++
+}
+
+// Reset suspends the achievements of the current game, see Set.Suspend. It is
+// meant to be called after loading a state.
+func Reset() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if current != nil {
+		current.Suspend()
+	}
+}
+
+// Process evaluates the achievements of the current game. It is meant to be
+// called once per frame, after running the core. The addresses of the
+// achievements are mapped to the memory of the core like rcheevos does for the
+// console of the game. No achievement unlocks while suspended, see
+// Set.Suspend.
+func Process(ram RAM, suspended bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if current == nil {
+		return
+	}
+	if announce {
+		ntf.DisplayAndLogf(ntf.Info, "Achievements", "%d achievements for %s.", len(current.Achievements), current.Game.Title)
+		announce = false
+	}
+	if suspended {
+		current.Suspend()
+		return
+	}
+	mem := newConsoleMemory(current.Game.ConsoleID, ram)
+	for _, a := range current.Process(mem) {
+		ntf.DisplayAndLogf(ntf.Success, "Achievements", "Achievement unlocked: %s (%d points)", a.Title, a.Points)
+		go func(c Client, id int) {
+			if err := c.Award(id); err != nil {
+				log.Println("[Achievements]:", err)
+			}
+		}(client, a.ID)
+	}
+}
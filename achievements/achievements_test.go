@@ -0,0 +1,102 @@
+package achievements
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ids(achievements []*Achievement) []int {
+	ids := []int{}
+	for _, a := range achievements {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func Test_Set_Process(t *testing.T) {
+	client := &FileClient{Path: "testdata/game.json"}
+	game, err := client.Game("")
+	if err != nil {
+		t.Fatalf("Game() error = %v", err)
+	}
+	set := NewSet(game)
+
+	t.Run("Skips achievements with invalid triggers", func(t *testing.T) {
+		if got := ids(set.Achievements); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+			t.Errorf("NewSet() = %v, want %v", got, []int{1, 2, 3})
+		}
+	})
+
+	t.Run("Unlocks achievements as the game is played", func(t *testing.T) {
+		frames := []struct {
+			mem  fakeMemory
+			want []int
+		}{
+			{fakeMemory{0, 0, 0xe8, 0x03, 1, 0}, []int{}},  // already rich when loading
+			{fakeMemory{1, 0, 0x00, 0x00, 1, 0}, []int{1}}, // level 1 reached
+			{fakeMemory{1, 0, 0x00, 0x00, 1, 1}, []int{}},  // got hit
+			{fakeMemory{1, 0, 0x10, 0x00, 1, 0}, []int{}},
+			{fakeMemory{1, 0, 0xe8, 0x03, 1, 0}, []int{2}},
+			{fakeMemory{1, 0, 0xe8, 0x03, 1, 0}, []int{3}},
+			{fakeMemory{1, 0, 0xe8, 0x03, 1, 0}, []int{}},
+		}
+		for i, f := range frames {
+			if got := ids(set.Process(f.mem)); !reflect.DeepEqual(got, f.want) {
+				t.Errorf("frame %d: Process() = %v, want %v", i, got, f.want)
+			}
+		}
+	})
+
+	t.Run("Has to be false again after being suspended", func(t *testing.T) {
+		set := NewSet(game)
+		set.Process(fakeMemory{0, 0, 0, 0, 0, 0})
+		set.Suspend()
+		if got := ids(set.Process(fakeMemory{1, 0, 0, 0, 0, 0})); len(got) != 0 {
+			t.Errorf("Process() = %v, want %v", got, []int{})
+		}
+		set.Process(fakeMemory{0, 0, 0, 0, 0, 0})
+		if got := ids(set.Process(fakeMemory{1, 0, 0, 0, 0, 0})); !reflect.DeepEqual(got, []int{1}) {
+			t.Errorf("Process() = %v, want %v", got, []int{1})
+		}
+	})
+}
+
+func Test_Process(t *testing.T) {
+	client := &FileClient{Path: "testdata/game.json"}
+	NewClient = func() Client { return client }
+
+	t.Run("Loads the set in the background and awards unlocks", func(t *testing.T) {
+		Load("testdata/game.json", nil)
+		defer Unload()
+
+		for i := 0; i < 100; i++ {
+			mutex.Lock()
+			loaded := current != nil
+			mutex.Unlock()
+			if loaded {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		Process(RAM{System: []byte{0, 0, 0, 0, 0, 0}}, false)
+		Process(RAM{System: []byte{1, 0, 0, 0, 0, 0}}, true) // rewinding
+		Process(RAM{System: []byte{0, 0, 0, 0, 0, 0}}, false)
+		Process(RAM{System: []byte{1, 0, 0, 0, 0, 0}}, false)
+
+		for i := 0; i < 100; i++ {
+			client.mutex.Lock()
+			awarded := append([]int{}, client.Awarded...)
+			client.mutex.Unlock()
+			if len(awarded) > 0 {
+				if !reflect.DeepEqual(awarded, []int{1}) {
+					t.Errorf("Awarded = %v, want %v", awarded, []int{1})
+				}
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Errorf("Awarded = %v, want %v", client.Awarded, []int{1})
+	})
+}
@@ -0,0 +1,147 @@
+package achievements
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Definition describes an achievement as served by the achievements service
+type Definition struct {
+	ID          int    `json:"ID"`
+	MemAddr     string `json:"MemAddr"`
+	Title       string `json:"Title"`
+	Description string `json:"Description"`
+	Points      int    `json:"Points"`
+}
+
+// Game is the achievement set of a game
+type Game struct {
+	ID           int          `json:"ID"`
+	Title        string       `json:"Title"`
+	ConsoleID    int          `json:"ConsoleID"`
+	Achievements []Definition `json:"Achievements"`
+}
+
+// Client talks to the achievements service
+type Client interface {
+	// Game returns the achievement set of the game with the given hash
+	Game(hash string) (*Game, error)
+	// Award records that the user unlocked an achievement
+	Award(id int) error
+}
+
+// patchResponse is the payload of the patch request of the service
+type patchResponse struct {
+	Success   bool   `json:"Success"`
+	Error     string `json:"Error"`
+	PatchData Game   `json:"PatchData"`
+}
+
+// FileClient reads the achievement set from a local JSON file using the same
+// format as the patch request of the service. It is used for testing and to
+// develop achievement sets offline. Awards are only recorded in memory.
+type FileClient struct {
+	Path    string
+	Awarded []int
+
+	mutex sync.Mutex
+}
+
+// Game reads the achievement set from the file, regardless of the hash
+func (c *FileClient) Game(hash string) (*Game, error) {
+	b, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	var r patchResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	if !r.Success {
+		return nil, errors.New(r.Error)
+	}
+	return &r.PatchData, nil
+}
+
+// Award records the achievement as unlocked
+func (c *FileClient) Award(id int) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.Awarded = append(c.Awarded, id)
+	return nil
+}
+
+// HTTPClient queries a RetroAchievements compatible server
+type HTTPClient struct {
+	Host     string
+	User     string
+	Token    string
+	Hardcore bool
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func (c *HTTPClient) request(params url.Values, v interface{}) error {
+	params.Set("u", c.User)
+	params.Set("t", c.Token)
+	resp, err := httpClient.PostForm(c.Host+"/dorequest.php", params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Game resolves the game ID from its hash, then fetches its achievement set
+func (c *HTTPClient) Game(hash string) (*Game, error) {
+	var id struct {
+		Success bool `json:"Success"`
+		GameID  int  `json:"GameID"`
+	}
+	err := c.request(url.Values{"r": {"gameid"}, "m": {hash}}, &id)
+	if err != nil {
+		return nil, err
+	}
+	if !id.Success || id.GameID == 0 {
+		return nil, errors.New("unknown game")
+	}
+
+	var r patchResponse
+	err = c.request(url.Values{"r": {"patch"}, "g": {fmt.Sprint(id.GameID)}}, &r)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Success {
+		return nil, errors.New(r.Error)
+	}
+	return &r.PatchData, nil
+}
+
+// Award sends the unlock to the server
+func (c *HTTPClient) Award(id int) error {
+	hardcore := "0"
+	if c.Hardcore {
+		hardcore = "1"
+	}
+	var r struct {
+		Success bool   `json:"Success"`
+		Error   string `json:"Error"`
+	}
+	err := c.request(url.Values{"r": {"awardachievement"}, "a": {fmt.Sprint(id)}, "h": {hardcore}}, &r)
+	if err != nil {
+		return err
+	}
+	if !r.Success {
+		return errors.New(r.Error)
+	}
+	return nil
+}
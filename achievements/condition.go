@@ -0,0 +1,431 @@
+package achievements
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Memory is the emulated memory the conditions are evaluated against
+type Memory interface {
+	Peek(address uint32) (byte, bool)
+}
+
+// operand sizes, by their rcheevos prefix
+var sizes = map[byte]struct {
+	bytes int
+	shift uint
+	mask  uint32
+}{
+	'M': {1, 0, 0x1}, 'N': {1, 1, 0x1}, 'O': {1, 2, 0x1}, 'P': {1, 3, 0x1},
+	'Q': {1, 4, 0x1}, 'R': {1, 5, 0x1}, 'S': {1, 6, 0x1}, 'T': {1, 7, 0x1},
+	'L': {1, 0, 0xf}, 'U': {1, 4, 0xf},
+	'H': {1, 0, 0xff},
+	' ': {2, 0, 0xffff},
+	'W': {3, 0, 0xffffff},
+	'X': {4, 0, 0xffffffff},
+}
+
+const (
+	operandConst = iota
+	operandValue
+	operandDelta
+	operandPrior
+	operandBCD
+)
+
+// operand is a constant or a value read from the memory
+type operand struct {
+	kind    int
+	address uint32
+	bytes   int
+	shift   uint
+	mask    uint32
+
+	constant uint32
+	current  uint32
+	previous uint32
+	prior    uint32
+}
+
+// read returns the value of the operand in the memory
+func (o *operand) read(mem Memory) uint32 {
+	var v uint32
+	for i := 0; i < o.bytes; i++ {
+		b, _ := mem.Peek(o.address + uint32(i))
+		v |= uint32(b) << (8 * i)
+	}
+	return (v >> o.shift) & o.mask
+}
+
+// update reads the memory for the new frame, keeping track of the value from
+// the previous frame and of the last different value
+func (o *operand) update(mem Memory) {
+	if o.kind == operandConst {
+		return
+	}
+	v := o.read(mem)
+	o.previous = o.current
+	if v != o.current {
+		o.prior = o.current
+	}
+	o.current = v
+}
+
+func (o *operand) value() uint32 {
+	switch o.kind {
+	case operandValue:
+		return o.current
+	case operandDelta:
+		return o.previous
+	case operandPrior:
+		return o.prior
+	case operandBCD:
+		var v, m uint32 = 0, 1
+		for n := o.current; n > 0; n >>= 4 {
+			v += (n & 0xf) * m
+			m *= 10
+		}
+		return v
+	}
+	return o.constant
+}
+
+// parseOperand parses an operand at the beginning of s and returns the rest
+func parseOperand(s string) (*operand, string, error) {
+	o := &operand{kind: operandValue}
+	switch {
+	case strings.HasPrefix(s, "d0x"):
+		o.kind, s = operandDelta, s[1:]
+	case strings.HasPrefix(s, "p0x"):
+		o.kind, s = operandPrior, s[1:]
+	case strings.HasPrefix(s, "b0x"):
+		o.kind, s = operandBCD, s[1:]
+	}
+
+	if !strings.HasPrefix(s, "0x") {
+		// Constant, decimal or hexadecimal with a h prefix
+		o.kind = operandConst
+		base := 10
+		if strings.HasPrefix(s, "h") {
+			base, s = 16, s[1:]
+		}
+		n := strings.IndexFunc(s, func(r rune) bool { return !isDigit(r, base) })
+		if n < 0 {
+			n = len(s)
+		}
+		c, err := strconv.ParseUint(s[:n], base, 32)
+		if err != nil {
+			return nil, "", errors.New("invalid constant: " + s)
+		}
+		o.constant = uint32(c)
+		return o, s[n:], nil
+	}
+
+	s = s[2:]
+	size := byte(' ')
+	if len(s) > 0 {
+		if _, ok := sizes[s[0]]; ok && s[0] != ' ' {
+			size, s = s[0], s[1:]
+		} else if s[0] == ' ' {
+			s = s[1:]
+		}
+	}
+	n := strings.IndexFunc(s, func(r rune) bool { return !isDigit(r, 16) })
+	if n < 0 {
+		n = len(s)
+	}
+	a, err := strconv.ParseUint(s[:n], 16, 32)
+	if err != nil {
+		return nil, "", errors.New("invalid address: " + s)
+	}
+	o.address = uint32(a)
+	o.bytes, o.shift, o.mask = sizes[size].bytes, sizes[size].shift, sizes[size].mask
+	return o, s[n:], nil
+}
+
+func isDigit(r rune, base int) bool {
+	if r >= '0' && r <= '9' {
+		return true
+	}
+	return base == 16 && ((r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F'))
+}
+
+// condition flags
+const (
+	flagNone = iota
+	flagResetIf
+	flagPauseIf
+	flagAddSource
+	flagSubSource
+	flagAddHits
+)
+
+var flags = map[byte]int{
+	'R': flagResetIf,
+	'P': flagPauseIf,
+	'A': flagAddSource,
+	'B': flagSubSource,
+	'C': flagAddHits,
+}
+
+// comparators, longest first so that <= is not parsed as <
+var comparators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// condition compares two operands, and may have to be true for a number of
+// frames before counting as true
+type condition struct {
+	flag       int
+	left       *operand
+	comparator string
+	right      *operand
+	target     uint32
+	hits       uint32
+}
+
+func parseCondition(s string) (*condition, error) {
+	c := &condition{}
+	if len(s) > 1 && s[1] == ':' {
+		f, ok := flags[s[0]]
+		if !ok {
+			return nil, errors.New("unsupported condition flag: " + s[:1])
+		}
+		c.flag, s = f, s[2:]
+	}
+
+	var err error
+	c.left, s, err = parseOperand(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cmp := range comparators {
+		if strings.HasPrefix(s, cmp) {
+			c.comparator = cmp
+			c.right, s, err = parseOperand(s[len(cmp):])
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if c.comparator == "" && c.flag != flagAddSource && c.flag != flagSubSource {
+		return nil, errors.New("missing comparator")
+	}
+
+	// Hit target, written .N. or (N)
+	if len(s) > 2 && (s[0] == '.' && s[len(s)-1] == '.' || s[0] == '(' && s[len(s)-1] == ')') {
+		t, err := strconv.ParseUint(s[1:len(s)-1], 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid hit target: " + s)
+		}
+		c.target, s = uint32(t), ""
+	}
+	if s != "" {
+		return nil, errors.New("unexpected characters: " + s)
+	}
+	return c, nil
+}
+
+// compare tests the condition, add being accumulated by the previous AddSource
+// and SubSource conditions
+func (c *condition) compare(add int64) bool {
+	left := add + int64(c.left.value())
+	var right int64
+	if c.right != nil {
+		right = int64(c.right.value())
+	}
+	switch c.comparator {
+	case "=":
+		return left == right
+	case "!=":
+		return left != right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	}
+	return false
+}
+
+// hit counts a frame where the condition is true, up to its target
+func (c *condition) hit() {
+	if c.target == 0 || c.hits < c.target {
+		c.hits++
+	}
+}
+
+// chain is a list of AddSource, SubSource and AddHits conditions ending with
+// the condition they modify
+type chain []*condition
+
+func (ch chain) last() *condition {
+	return ch[len(ch)-1]
+}
+
+func (ch chain) test() bool {
+	var add int64
+	var hits uint32
+	for _, c := range ch[:len(ch)-1] {
+		switch c.flag {
+		case flagAddSource:
+			add += int64(c.left.value())
+		case flagSubSource:
+			add -= int64(c.left.value())
+		case flagAddHits:
+			if c.compare(0) {
+				c.hit()
+			}
+			hits += c.hits
+		}
+	}
+
+	last := ch.last()
+	ok := last.compare(add)
+	if last.target == 0 {
+		return ok
+	}
+	if ok {
+		last.hit()
+	}
+	return last.hits+hits >= last.target
+}
+
+// group is a list of conditions that must all be true at the same time
+type group []chain
+
+func parseGroup(s string) (group, error) {
+	g := group{}
+	if s == "" {
+		return g, nil
+	}
+	ch := chain{}
+	for _, cs := range strings.Split(s, "_") {
+		c, err := parseCondition(cs)
+		if err != nil {
+			return nil, err
+		}
+		ch = append(ch, c)
+		if c.flag != flagAddSource && c.flag != flagSubSource && c.flag != flagAddHits {
+			g = append(g, ch)
+			ch = chain{}
+		}
+	}
+	if len(ch) > 0 {
+		return nil, errors.New("dangling modifier condition")
+	}
+	return g, nil
+}
+
+// test evaluates the group. A group is false while one of its PauseIf
+// conditions is true, and reset is true if one of its ResetIf conditions is.
+func (g group) test() (ok bool, reset bool) {
+	for _, ch := range g {
+		if ch.last().flag == flagPauseIf && ch.test() {
+			return false, false
+		}
+	}
+
+	ok = true
+	for _, ch := range g {
+		switch ch.last().flag {
+		case flagPauseIf:
+		case flagResetIf:
+			if ch.test() {
+				reset = true
+			}
+		default:
+			if !ch.test() {
+				ok = false
+			}
+		}
+	}
+	return ok, reset
+}
+
+// Trigger is a parsed rcheevos condition string. It has a core group of
+// conditions and optional alternative groups separated by S, at least one of
+// which must be true with the core group.
+type Trigger struct {
+	core     group
+	alts     []group
+	operands []*operand
+}
+
+// splitGroups splits a condition string on the S separating the groups, which
+// is not to be confused with the S size prefix in 0xS addresses
+func splitGroups(s string) []string {
+	groups := []string{}
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == 'S' && !strings.HasSuffix(s[:i], "0x") {
+			groups = append(groups, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(groups, s[start:])
+}
+
+// ParseTrigger parses a rcheevos condition string like 0xH0010=5_d0xH0011<0xH0011
+func ParseTrigger(s string) (*Trigger, error) {
+	t := &Trigger{}
+	for i, gs := range splitGroups(s) {
+		g, err := parseGroup(gs)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			t.core = g
+		} else {
+			t.alts = append(t.alts, g)
+		}
+		for _, ch := range g {
+			for _, c := range ch {
+				t.operands = append(t.operands, c.left)
+				if c.right != nil {
+					t.operands = append(t.operands, c.right)
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// reset clears the hit counts of every condition
+func (t *Trigger) reset() {
+	for _, g := range append([]group{t.core}, t.alts...) {
+		for _, ch := range g {
+			for _, c := range ch {
+				c.hits = 0
+			}
+		}
+	}
+}
+
+// Test reads the memory for a new frame and evaluates the trigger. It is meant
+// to be called exactly once per frame.
+func (t *Trigger) Test(mem Memory) bool {
+	for _, o := range t.operands {
+		o.update(mem)
+	}
+
+	ok, reset := t.core.test()
+	if len(t.alts) > 0 {
+		anyAlt := false
+		for _, alt := range t.alts {
+			altOk, altReset := alt.test()
+			anyAlt = anyAlt || altOk
+			reset = reset || altReset
+		}
+		ok = ok && anyAlt
+	}
+	if reset {
+		t.reset()
+		return false
+	}
+	return ok
+}
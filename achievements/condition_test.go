@@ -0,0 +1,154 @@
+package achievements
+
+import (
+	"testing"
+)
+
+// fakeMemory is a flat memory buffer
+type fakeMemory []byte
+
+func (m fakeMemory) Peek(address uint32) (byte, bool) {
+	if int(address) >= len(m) {
+		return 0, false
+	}
+	return m[address], true
+}
+
+// run evaluates the trigger for one frame per memory state
+func run(t *testing.T, trigger string, frames ...fakeMemory) []bool {
+	tr, err := ParseTrigger(trigger)
+	if err != nil {
+		t.Fatalf("ParseTrigger() error = %v", err)
+	}
+	results := []bool{}
+	for _, mem := range frames {
+		results = append(results, tr.Test(mem))
+	}
+	return results
+}
+
+func equal(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_ParseTrigger(t *testing.T) {
+	valid := []string{
+		"0xH0010=5",
+		"0x1234!=h1F_0xX0000>d0xX0000",
+		"R:0xM0001=1_P:0xT0001=0_0xL0002<=0xU0002.10.",
+		"A:0xH0000_B:0xH0001_0xH0002=3",
+		"0xH0000=1S0xS0001=1S0xH0002=2",
+		"b0xH0000=99(2)",
+	}
+	for _, s := range valid {
+		if _, err := ParseTrigger(s); err != nil {
+			t.Errorf("ParseTrigger(%q) error = %v", s, err)
+		}
+	}
+
+	invalid := []string{
+		"0xH0010",
+		"0xH0010=",
+		"0xHZZZZ=1",
+		"Z:0xH0000=1",
+		"A:0xH0000",
+		"0xH0000=1.x.",
+	}
+	for _, s := range invalid {
+		if _, err := ParseTrigger(s); err == nil {
+			t.Errorf("ParseTrigger(%q) error = %v, want an error", s, err)
+		}
+	}
+}
+
+func Test_Trigger_Test(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger string
+		frames  []fakeMemory
+		want    []bool
+	}{
+		{
+			name:    "Compares memory to constants",
+			trigger: "0xH0001=5_0x 0002>h0100",
+			frames:  []fakeMemory{{0, 5, 0x00, 0x01}, {0, 5, 0x01, 0x01}, {0, 4, 0x01, 0x01}},
+			want:    []bool{false, true, false},
+		},
+		{
+			name:    "Reads bits and nibbles",
+			trigger: "0xN0000=1_0xU0000=10",
+			frames:  []fakeMemory{{0xA2}, {0xA0}, {0xB2}},
+			want:    []bool{true, false, false},
+		},
+		{
+			name:    "Compares with the previous frame",
+			trigger: "0xH0000>d0xH0000",
+			frames:  []fakeMemory{{1}, {2}, {2}, {1}, {3}},
+			want:    []bool{true, true, false, false, true},
+		},
+		{
+			name:    "Compares with the prior value",
+			trigger: "0xH0000=3_p0xH0000=1",
+			frames:  []fakeMemory{{1}, {3}, {3}, {2}, {3}},
+			want:    []bool{false, true, true, false, false},
+		},
+		{
+			name:    "Decodes BCD",
+			trigger: "b0xH0000=42",
+			frames:  []fakeMemory{{0x42}, {42}},
+			want:    []bool{true, false},
+		},
+		{
+			name:    "Counts hits",
+			trigger: "0xH0000=1.3.",
+			frames:  []fakeMemory{{1}, {0}, {1}, {1}, {0}},
+			want:    []bool{false, false, false, true, true},
+		},
+		{
+			name:    "Resets hits",
+			trigger: "0xH0000=1.3._R:0xH0001=1",
+			frames:  []fakeMemory{{1, 0}, {1, 0}, {1, 1}, {1, 0}, {1, 0}, {1, 0}},
+			want:    []bool{false, false, false, false, false, true},
+		},
+		{
+			name:    "Pauses hit counting",
+			trigger: "0xH0000=1.2._P:0xH0001=1",
+			frames:  []fakeMemory{{1, 0}, {1, 1}, {1, 0}},
+			want:    []bool{false, false, true},
+		},
+		{
+			name:    "Adds and subtracts sources",
+			trigger: "A:0xH0000_B:0xH0001_0xH0002=10",
+			frames:  []fakeMemory{{5, 1, 6}, {5, 1, 5}, {20, 12, 2}},
+			want:    []bool{true, false, true},
+		},
+		{
+			name:    "Adds hits",
+			trigger: "C:0xH0000=1_0xH0001=1.3.",
+			frames:  []fakeMemory{{1, 0}, {0, 1}, {0, 0}, {1, 0}},
+			want:    []bool{false, false, false, true},
+		},
+		{
+			name:    "Requires one alternative group",
+			trigger: "0xH0000=1S0xH0001=1S0xS0002=1",
+			frames:  []fakeMemory{{1, 0, 0}, {1, 1, 0}, {1, 0, 0x40}, {0, 1, 0x40}},
+			want:    []bool{false, true, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := run(t, tt.trigger, tt.frames...)
+			if !equal(got, tt.want) {
+				t.Errorf("Test() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
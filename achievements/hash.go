@@ -0,0 +1,223 @@
+package achievements
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/libretro/ludo/disc"
+	"github.com/libretro/ludo/utils"
+)
+
+// Hash returns the hash identifying a game on the service. It is computed
+// like rcheevos does for each kind of content: the headers added by dumpers
+// are skipped, Nintendo 64 images are hashed in their native byte order,
+// discs are identified by their boot files and arcade games by the name of
+// their romset. path is the content given to the core, data its bytes if the
+// core was given them, or nil to read the file.
+func Hash(path string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".cue":
+		return hashCue(path)
+	case ".m3u":
+		return hashM3U(path)
+	case ".zip", ".7z":
+		return hashString(utils.FileName(path)), nil
+	case ".chd", ".pbp", ".iso", ".gdi", ".cdi", ".nds", ".3ds":
+		return "", fmt.Errorf("can't hash %s content", ext)
+	}
+
+	if data == nil {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return "", err
+		}
+	}
+
+	switch ext {
+	case ".nes", ".fds":
+		if bytes.HasPrefix(data, []byte("NES\x1a")) || bytes.HasPrefix(data, []byte("FDS\x1a")) {
+			data = data[16:]
+		}
+	case ".sfc", ".smc", ".swc", ".fig":
+		data = skipCopierHeader(data, 0x2000)
+	case ".pce", ".sgx":
+		data = skipCopierHeader(data, 0x20000)
+	case ".lnx":
+		if bytes.HasPrefix(data, []byte("LYNX\x00")) && len(data) >= 64 {
+			data = data[64:]
+		}
+	case ".a78":
+		if len(data) >= 128 && string(data[1:10]) == "ATARI7800" {
+			data = data[128:]
+		}
+	case ".n64", ".v64", ".z64":
+		data = n64BigEndian(data)
+	}
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func hashString(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// skipCopierHeader removes the 512 bytes header added by copiers to ROMs
+// whose size is a multiple of unit
+func skipCopierHeader(data []byte, unit int) []byte {
+	if len(data)%unit == 512 {
+		return data[512:]
+	}
+	return data
+}
+
+// n64BigEndian converts the byte swapped .v64 and little endian .n64 images
+// to the big endian order of the .z64 images
+func n64BigEndian(data []byte) []byte {
+	if len(data) < 4 {
+		return data
+	}
+	out := make([]byte, len(data)&^3)
+	switch data[0] {
+	case 0x37:
+		for i := 0; i+1 < len(out); i += 2 {
+			out[i], out[i+1] = data[i+1], data[i]
+		}
+	case 0x40:
+		for i := 0; i+3 < len(out); i += 4 {
+			binary.BigEndian.PutUint32(out[i:], binary.LittleEndian.Uint32(data[i:]))
+		}
+	default:
+		return data
+	}
+	return out
+}
+
+// hashM3U hashes the first disc of a playlist
+func hashM3U(path string) (string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(path), line)
+		}
+		return Hash(line, nil)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New(filepath.Base(path) + ": no disc")
+}
+
+// hashCue hashes a disc by looking for the boot files of the supported
+// systems in its first data track
+func hashCue(path string) (string, error) {
+	tracks, err := disc.ReadCue(path)
+	if err != nil {
+		return "", err
+	}
+	t, ok := disc.FirstDataTrack(tracks)
+	if !ok {
+		return "", errors.New(filepath.Base(path) + ": no data track")
+	}
+	r, err := disc.Open(t)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	for _, hash := range []func(*disc.Reader) (string, bool){hashSegaCD, hashPlayStation} {
+		if h, ok := hash(r); ok {
+			return h, nil
+		}
+	}
+	return "", errors.New(filepath.Base(path) + ": unsupported disc")
+}
+
+// hashSegaCD hashes the volume and ROM headers at the start of Sega CD and
+// Saturn discs
+func hashSegaCD(r *disc.Reader) (string, bool) {
+	b, err := r.ReadSector(0)
+	if err != nil {
+		return "", false
+	}
+	magic := string(b[:16])
+	if magic != "SEGADISCSYSTEM  " && magic != "SEGA SEGASATURN " {
+		return "", false
+	}
+	sum := md5.Sum(b[:512])
+	return hex.EncodeToString(sum[:]), true
+}
+
+// hashPlayStation hashes the name and the content of the executable booted by
+// PlayStation discs
+func hashPlayStation(r *disc.Reader) (string, bool) {
+	name := "PSX.EXE"
+	if sector, size, err := r.FindFile("SYSTEM.CNF"); err == nil {
+		cnf, err := r.ReadFile(sector, size)
+		if err != nil {
+			return "", false
+		}
+		if name = bootExecutable(string(cnf)); name == "" {
+			return "", false
+		}
+	}
+	sector, size, err := r.FindFile(name)
+	if err != nil {
+		return "", false
+	}
+	// The header of the executable gives its size, without the header itself
+	if header, err := r.ReadSector(sector); err == nil && string(header[:7]) == "PS-X EXE" {
+		size = int(binary.LittleEndian.Uint32(header[28:])) + disc.SectorSize
+	}
+	exe, err := r.ReadFile(sector, size)
+	if err != nil {
+		return "", false
+	}
+	h := md5.New()
+	h.Write([]byte(name))
+	h.Write(exe)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// bootExecutable returns the path of the executable in a SYSTEM.CNF file, from
+// a line like BOOT = cdrom:\SLUS_012.34;1
+func bootExecutable(cnf string) string {
+	for _, line := range strings.Split(cnf, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "BOOT")
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "=") {
+			continue
+		}
+		rest = strings.TrimSpace(rest[1:])
+		rest = strings.TrimPrefix(rest, "cdrom:")
+		rest = strings.TrimLeft(rest, "\\")
+		end := strings.IndexFunc(rest, func(c rune) bool { return c == ';' || unicode.IsSpace(c) })
+		if end >= 0 {
+			rest = rest[:end]
+		}
+		return rest
+	}
+	return ""
+}
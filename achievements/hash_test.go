@@ -0,0 +1,123 @@
+package achievements
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func md5String(b []byte) string {
+	sum := md5.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_Hash(t *testing.T) {
+	rom := bytes.Repeat([]byte{0x12, 0x34, 0x56, 0x78}, 0x800)
+	// A Nintendo 64 ROM starts with 80 37 12 40 in big endian
+	z64 := append([]byte{0x80, 0x37, 0x12, 0x40}, rom...)
+	v64 := make([]byte, len(z64))
+	n64 := make([]byte, len(z64))
+	for i := 0; i < len(z64); i += 4 {
+		v64[i], v64[i+1], v64[i+2], v64[i+3] = z64[i+1], z64[i], z64[i+3], z64[i+2]
+		n64[i], n64[i+1], n64[i+2], n64[i+3] = z64[i+3], z64[i+2], z64[i+1], z64[i]
+	}
+
+	tests := []struct {
+		name string
+		path string
+		data []byte
+		want string
+	}{
+		{
+			name: "Skips the iNES header",
+			path: "game.nes",
+			data: append(append([]byte("NES\x1a"), make([]byte, 12)...), rom...),
+			want: md5String(rom),
+		},
+		{
+			name: "Skips the SNES copier header",
+			path: "game.smc",
+			data: append(make([]byte, 512), rom...),
+			want: md5String(rom),
+		},
+		{
+			name: "Keeps SNES ROMs without copier header",
+			path: "game.sfc",
+			data: rom,
+			want: md5String(rom),
+		},
+		{
+			name: "Hashes the Nintendo 64 ROMs in big endian",
+			path: "game.v64",
+			data: v64,
+			want: md5String(z64),
+		},
+		{
+			name: "Converts little endian Nintendo 64 ROMs",
+			path: "game.n64",
+			data: n64,
+			want: md5String(z64),
+		},
+		{
+			name: "Hashes the name of arcade romsets",
+			path: "/roms/sf2.zip",
+			want: md5String([]byte("sf2")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Hash(tt.path, tt.data)
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Hash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Hashes the header of Sega CD discs", func(t *testing.T) {
+		dir := t.TempDir()
+		track := make([]byte, 2048*4)
+		copy(track, "SEGADISCSYSTEM  ")
+		os.WriteFile(filepath.Join(dir, "game.bin"), track, 0644)
+		os.WriteFile(filepath.Join(dir, "game.cue"), []byte("FILE \"game.bin\" BINARY\n  TRACK 01 MODE1/2048\n    INDEX 01 00:00:00\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "game.m3u"), []byte("# disc 1\ngame.cue\n"), 0644)
+
+		want := md5String(track[:512])
+		for _, name := range []string{"game.cue", "game.m3u"} {
+			got, err := Hash(filepath.Join(dir, name), nil)
+			if err != nil {
+				t.Fatalf("Hash(%s) error = %v", name, err)
+			}
+			if got != want {
+				t.Errorf("Hash(%s) = %v, want %v", name, got, want)
+			}
+		}
+	})
+
+	t.Run("Refuses the formats it can't hash", func(t *testing.T) {
+		if _, err := Hash("game.chd", nil); err == nil {
+			t.Errorf("Hash() error = nil, want an error")
+		}
+	})
+}
+
+func Test_bootExecutable(t *testing.T) {
+	tests := []struct {
+		cnf  string
+		want string
+	}{
+		{"BOOT = cdrom:\\SLUS_012.34;1\r\nTCB = 4\r\n", "SLUS_012.34"},
+		{"BOOT=cdrom:\\GAME\\MAIN.EXE;1", "GAME\\MAIN.EXE"},
+		{"TCB = 4\n", ""},
+	}
+	for _, tt := range tests {
+		if got := bootExecutable(tt.cnf); got != tt.want {
+			t.Errorf("bootExecutable(%q) = %v, want %v", tt.cnf, got, tt.want)
+		}
+	}
+}
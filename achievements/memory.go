@@ -0,0 +1,183 @@
+package achievements
+
+import (
+	"unsafe"
+
+	"github.com/libretro/ludo/cheats"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/state"
+)
+
+// Kinds of memory regions
+const (
+	SystemRAM = iota
+	SaveRAM
+	// Virtual regions mirror other regions and are only reachable through the
+	// memory map of the core
+	Virtual
+)
+
+// region maps a range of achievement addresses to the address of the
+// emulated system, like the console memory maps of rcheevos
+type region struct {
+	start, end uint32 // achievement addresses, inclusive
+	real       uint32 // address on the bus of the emulated system
+	kind       int
+}
+
+// consoles are the memory maps of the consoles, by RetroAchievements console
+// ID. Achievement addresses of other consoles are offsets in the system RAM
+// followed by the save RAM.
+var consoles = map[int][]region{
+	// Mega Drive
+	1: {
+		{0x000000, 0x00ffff, 0xff0000, SystemRAM},
+		{0x010000, 0x01ffff, 0x000000, SaveRAM},
+	},
+	// Nintendo 64
+	2: {
+		{0x000000, 0x1fffff, 0x80000000, SystemRAM},
+		{0x200000, 0x3fffff, 0x80200000, SystemRAM},
+	},
+	// Super Nintendo
+	3: {
+		{0x000000, 0x01ffff, 0x7e0000, SystemRAM},
+		{0x020000, 0x09ffff, 0x700000, SaveRAM},
+	},
+	// Game Boy
+	4: gameBoy,
+	// Game Boy Advance
+	5: {
+		{0x000000, 0x007fff, 0x3000000, SystemRAM},
+		{0x008000, 0x047fff, 0x2000000, SystemRAM},
+		{0x048000, 0x057fff, 0xe000000, SaveRAM},
+	},
+	// Game Boy Color, with the banks 2 to 7 of its work RAM
+	6: append(gameBoy, region{0x010000, 0x015fff, 0x010000, SystemRAM}),
+	// NES
+	7: {
+		{0x0000, 0x07ff, 0x0000, SystemRAM},
+		{0x0800, 0x1fff, 0x0800, Virtual},
+		{0x6000, 0x7fff, 0x6000, SaveRAM},
+	},
+	// PC Engine
+	8: {
+		{0x000000, 0x001fff, 0x1f0000, SystemRAM},
+		{0x002000, 0x011fff, 0x100000, SystemRAM},
+		{0x012000, 0x041fff, 0x0d0000, SystemRAM},
+		{0x042000, 0x0427ff, 0x1ee000, SaveRAM},
+	},
+	// Master System
+	11: {
+		{0x0000, 0x1fff, 0xc000, SystemRAM},
+	},
+	// PlayStation
+	12: {
+		{0x000000, 0x1fffff, 0x000000, SystemRAM},
+		{0x200000, 0x2003ff, 0x1f800000, SystemRAM},
+	},
+	// Game Gear
+	15: {
+		{0x0000, 0x1fff, 0xc000, SystemRAM},
+	},
+	// Atari 2600
+	25: {
+		{0x00, 0x7f, 0x80, SystemRAM},
+	},
+}
+
+var gameBoy = []region{
+	{0x8000, 0x9fff, 0x8000, Virtual},
+	{0xa000, 0xbfff, 0xa000, SaveRAM},
+	{0xc000, 0xdfff, 0xc000, SystemRAM},
+	{0xe000, 0xfdff, 0xc000, Virtual},
+	{0xfe00, 0xffff, 0xfe00, Virtual},
+}
+
+// RAM is the memory exposed by a core
+type RAM struct {
+	// Bus reads the addresses of the emulated system through the memory map
+	// of the core, it is nil if the core doesn't provide one
+	Bus    Memory
+	System []byte
+	Save   []byte
+}
+
+// CoreRAM returns the memory of the running core
+func CoreRAM() RAM {
+	if state.Core == nil {
+		return RAM{}
+	}
+	var ram RAM
+	if m := cheats.CoreMemory(); len(m.Descriptors) > 0 {
+		ram.Bus = m
+	}
+	ram.System = coreMemory(libretro.MemorySystemRAM)
+	ram.Save = coreMemory(libretro.MemorySaveRAM)
+	return ram
+}
+
+func coreMemory(id uint32) []byte {
+	size := state.Core.GetMemorySize(id)
+	ptr := state.Core.GetMemoryData(id)
+	if ptr == nil || size == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(ptr), size)
+}
+
+// consoleMemory reads achievement addresses in the memory of a core
+type consoleMemory struct {
+	regions []region
+	bus     Memory
+	data    [][]byte // the memory of each region, when there's no bus
+}
+
+// newConsoleMemory maps the memory of a core with the memory map of a
+// console. Like rcheevos, when the core has no memory map, the regions of
+// system RAM and save RAM take the following bytes of the memory of the
+// same kind exposed by the core, and virtual regions are not readable.
+func newConsoleMemory(console int, ram RAM) *consoleMemory {
+	regions, ok := consoles[console]
+	if !ok {
+		regions = nil
+		if n := len(ram.System); n > 0 {
+			regions = append(regions, region{0, uint32(n - 1), 0, SystemRAM})
+		}
+		if n := len(ram.Save); n > 0 {
+			start := uint32(len(ram.System))
+			regions = append(regions, region{start, start + uint32(n-1), 0, SaveRAM})
+		}
+		ram.Bus = nil
+	}
+	m := &consoleMemory{regions: regions, bus: ram.Bus}
+	if m.bus != nil {
+		return m
+	}
+	m.data = make([][]byte, len(regions))
+	buffers := map[int][]byte{SystemRAM: ram.System, SaveRAM: ram.Save}
+	for i, r := range regions {
+		b := buffers[r.kind]
+		n := min(int(r.end-r.start)+1, len(b))
+		m.data[i], buffers[r.kind] = b[:n], b[n:]
+	}
+	return m
+}
+
+// Peek reads the byte at an achievement address
+func (m *consoleMemory) Peek(address uint32) (byte, bool) {
+	for i, r := range m.regions {
+		if address < r.start || address > r.end {
+			continue
+		}
+		offset := address - r.start
+		if m.bus != nil {
+			return m.bus.Peek(r.real + offset)
+		}
+		if offset >= uint32(len(m.data[i])) {
+			return 0, false
+		}
+		return m.data[i][offset], true
+	}
+	return 0, false
+}
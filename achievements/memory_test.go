@@ -0,0 +1,56 @@
+package achievements
+
+import "testing"
+
+func Test_consoleMemory(t *testing.T) {
+	system := make([]byte, 0x800)
+	system[0x10] = 1
+	save := make([]byte, 0x2000)
+	save[0x20] = 2
+	// A bus with the RAM of the NES and its mirrors
+	bus := fakeMemory(make([]byte, 0x8000))
+	bus[0x10], bus[0x810], bus[0x6020] = 1, 1, 2
+
+	tests := []struct {
+		name    string
+		console int
+		ram     RAM
+		address uint32
+		want    byte
+		wantOk  bool
+	}{
+		{"Reads the RAM through the memory map", 7, RAM{Bus: bus}, 0x10, 1, true},
+		{"Reads the mirrors through the memory map", 7, RAM{Bus: bus}, 0x810, 1, true},
+		{"Reads the save RAM through the memory map", 7, RAM{Bus: bus}, 0x6020, 2, true},
+		{"Reads the system RAM without memory map", 7, RAM{System: system, Save: save}, 0x10, 1, true},
+		{"Reads the save RAM without memory map", 7, RAM{System: system, Save: save}, 0x6020, 2, true},
+		{"Can't read the mirrors without memory map", 7, RAM{System: system, Save: save}, 0x810, 0, false},
+		{"Can't read out of the regions", 7, RAM{Bus: bus}, 0x4000, 0, false},
+		{"Reads the save RAM after the system RAM for other consoles", 0, RAM{System: system, Save: save}, 0x820, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := newConsoleMemory(tt.console, tt.ram).Peek(tt.address)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Peek() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	t.Run("Maps the addresses of the Mega Drive to its bus", func(t *testing.T) {
+		m := newConsoleMemory(1, RAM{Bus: megaDrive{}})
+		if got, _ := m.Peek(0x1234); got != 0x34 {
+			t.Errorf("Peek() = %#x, want %#x", got, 0x34)
+		}
+	})
+}
+
+// megaDrive has its RAM at 0xff0000, it returns the low byte of the address
+type megaDrive struct{}
+
+func (megaDrive) Peek(address uint32) (byte, bool) {
+	if address < 0xff0000 {
+		return 0, false
+	}
+	return byte(address), true
+}
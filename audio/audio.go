@@ -1,29 +1,44 @@
-// Package audio uses OpenAL to play game audio by exposing the two audio
-// callbacks Sample and SampleBatch for the libretro implementation.
+// Package audio plays game audio by exposing the two audio callbacks Sample
+// and SampleBatch for the libretro implementation. The audio is resampled,
+// filtered and queued in a ring buffer, which a Driver plays from its own
+// goroutine: OpenAL, or a null or file driver that don't need a sound device.
 package audio
 
 import (
 	"log"
 	"path/filepath"
-	"time"
 	"unsafe"
 
+	"github.com/libretro/ludo/dsp"
+	"github.com/libretro/ludo/pacing"
+	"github.com/libretro/ludo/record"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/utils"
 	"golang.org/x/mobile/exp/audio/al"
 )
 
-const bufSize = 1024 * 8
+// ringSize is the size of the ring buffer between the emulation and the
+// driver, about 85ms of audio. The dynamic rate control keeps it half full.
+const ringSize = 1024 * 16
+
+// outputRate is the sample rate of the audio given to the driver. The audio of
+// the cores is resampled to it.
+const outputRate = 48000
 
 var (
-	source     al.Source
-	buffers    []al.Buffer
-	rate       int32
-	numBuffers int32
-	tmpBuf     [bufSize]byte
-	tmpBufPtr  int32
-	resPtr     int32
+	driver   Driver
+	custom   Driver // driver forced by UseDriver
+	deviceOK bool   // the OpenAL device could be opened
+	ring     *Ring
+	rate     int32
+
+	resampler dsp.Resampler
+	filters   dsp.Chain
+	drc       = dsp.RateControl{MaxDelta: 0.005}
+	samples   []float32 // audio of the core
+	resampled []float32 // audio at the output rate
+	pcm       []byte    // audio at the output rate, as queued
 )
 
 // Effects are sound effects
@@ -31,7 +46,15 @@ var Effects map[string]*Effect
 
 // SetVolume sets the audio volume
 func SetVolume(vol float32) {
-	source.SetGain(vol)
+	if driver != nil {
+		driver.SetVolume(vol)
+	}
+}
+
+// UseDriver makes the games play through d instead of the driver set in the
+// settings. It is meant to be called before Init.
+func UseDriver(d Driver) {
+	custom = d
 }
 
 // Init initializes the audio device
@@ -40,6 +63,7 @@ func Init() {
 	if err != nil {
 		log.Println(err)
 	}
+	deviceOK = err == nil
 
 	Effects = map[string]*Effect{}
 
@@ -50,95 +74,99 @@ func Init() {
 		filename := utils.FileName(path)
 		Effects[filename], _ = LoadEffect(path)
 	}
-}
-
-// Reconfigure initializes the audio package. It sets the number of buffers, the
-// volume and the source for the games.
-func Reconfigure(r int32) {
-	rate = r
-	numBuffers = 4
 
-	log.Printf("[OpenAL]: Using %v buffers of %v bytes.\n", numBuffers, bufSize)
-
-	source = al.GenSources(1)[0]
-	buffers = al.GenBuffers(int(numBuffers))
-	resPtr = numBuffers
-	tmpBufPtr = 0
-	tmpBuf = [bufSize]byte{}
-
-	source.SetGain(settings.Current.AudioVolume)
+	UpdateFilters()
 }
 
-func min(a, b int32) int32 {
-	if a < b {
-		return a
+// UpdateFilters builds the DSP chain from the settings. The filters process
+// the audio at the output rate, after resampling.
+func UpdateFilters() {
+	s := settings.Current
+	filters = dsp.Chain{}
+	if s.AudioEQGain != 0 {
+		filters = append(filters, dsp.NewPeaking(outputRate, float64(s.AudioEQFrequency), 1, float64(s.AudioEQGain)))
+	}
+	if s.AudioBassBoost != 0 {
+		filters = append(filters, dsp.NewBassBoost(outputRate, float64(s.AudioBassBoost)))
+	}
+	if s.AudioLowPass > 0 {
+		filters = append(filters, dsp.NewLowPass(outputRate, float64(s.AudioLowPass)))
+	}
+	if s.AudioReverb > 0 {
+		filters = append(filters, dsp.NewReverb(outputRate, float64(s.AudioReverb)))
 	}
-	return b
 }
 
-func alUnqueueBuffers() bool {
-	val := source.BuffersProcessed()
-
-	if val <= 0 {
-		return false
+// Reconfigure initializes the audio package for a core producing audio at
+// rate r, and starts the driver.
+func Reconfigure(r int32) {
+	rate = r
+	resampler.Reset()
+	if state.Headless {
+		return
 	}
-
-	source.UnqueueBuffers(buffers[resPtr:val]...)
-	resPtr += val
-	return true
+	Restart()
 }
 
-func alGetBuffer() al.Buffer {
-	if resPtr == 0 {
-		for {
-			if alUnqueueBuffers() {
-				break
-			}
-			time.Sleep(time.Millisecond)
-		}
+// newDriver returns the driver set in the settings
+func newDriver() Driver {
+	if settings.Current.AudioDriver == "OpenAL" && deviceOK {
+		return NewOpenAL()
 	}
+	return NewNull()
+}
 
-	resPtr--
-	return buffers[resPtr]
+// Restart stops the driver and starts it again with an empty ring, to apply
+// a change of driver
+func Restart() {
+	Deinit()
+	driver = custom
+	if driver == nil {
+		driver = newDriver()
+	}
+	ring = NewRing(ringSize)
+	if err := driver.Start(ring, outputRate); err != nil {
+		log.Println("[Audio]:", err)
+		driver = NewNull()
+		driver.Start(ring, outputRate)
+	}
+	driver.SetVolume(settings.Current.AudioVolume)
 }
 
-func fillInternalBuf(buf []byte) int32 {
-	readSize := min(bufSize-tmpBufPtr, int32(len(buf)))
-	copy(tmpBuf[tmpBufPtr:], buf[:readSize])
-	tmpBufPtr += readSize
-	return readSize
+// Deinit stops the driver
+func Deinit() {
+	if driver != nil {
+		driver.Stop()
+		driver = nil
+	}
 }
 
+// write resamples the audio of the core to the output rate and queues it. The
+// ratio follows the speed of the emulation, and is adjusted by the dynamic
+// rate control.
 func write(buf []byte, size int32) int32 {
-	written := int32(0)
-
-	if state.FastForward {
-		return size
+	// The recording gets the audio of the core as is, at any speed
+	if !state.Rewinding && !state.Replaying {
+		record.Audio(buf[:size])
 	}
 
-	for size > 0 {
-
-		rc := fillInternalBuf(buf[written:])
-
-		written += rc
-		size -= rc
-
-		if tmpBufPtr != bufSize {
-			break
-		}
+	speed := pacing.Ratio()
 
-		buffer := alGetBuffer()
-
-		buffer.BufferData(al.FormatStereo16, tmpBuf[:], rate)
-		tmpBufPtr = 0
-		source.QueueBuffers(buffer)
-
-		if source.State() != al.Playing {
-			al.PlaySources(source)
-		}
+	// The audio is dropped in unlimited fast-forward, and in headless mode,
+	// which has no audio device
+	if speed == 0 || rate == 0 || ring == nil || state.Rewinding || state.Replaying || state.Headless {
+		return size
 	}
 
-	return written
+	fill := float64(ring.Len()) / float64(ring.Size())
+	ratio := drc.Ratio(outputRate/float64(rate)/speed, fill)
+	samples = dsp.FromInt16(samples[:0], buf[:size])
+	resampled = resampler.Process(resampled[:0], samples, ratio)
+	filters.Process(resampled)
+	pcm = dsp.ToInt16(pcm[:0], resampled)
+	// The excess is dropped when the ring is full, the emulation never waits
+	ring.Write(pcm)
+	return size
 }
 
 // Sample renders a single audio frame.
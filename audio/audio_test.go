@@ -1,58 +1,132 @@
 package audio
 
 import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
-func Test_alUnqueueBuffers(t *testing.T) {
-	t.Run("Return false if no buffers were processed", func(t *testing.T) {
-		got := alUnqueueBuffers()
-		if got {
-			t.Errorf("alUnqueueBuffers() = %v, want %v", got, false)
+func Test_Sample(t *testing.T) {
+	t.Run("Doesn't crash when called", func(t *testing.T) {
+		Sample(-30000, -30000)
+		Sample(30000, 30000)
+	})
+}
+
+func Test_SampleBatch(t *testing.T) {
+	UseDriver(NewNull())
+	defer UseDriver(nil)
+	Reconfigure(32000)
+	defer Deinit()
+
+	t.Run("Queues the resampled audio in the ring", func(t *testing.T) {
+		ring.Read(make([]byte, ringSize))
+		SampleBatch(make([]byte, 320*4), 320)
+		// 320 frames at 32kHz are 480 frames at 48kHz
+		if got := ring.Len() / 4; got < 470 || got > 490 {
+			t.Errorf("ring.Len() = %d frames, want about %d", got, 480)
 		}
 	})
 }
 
-func Test_Sample(t *testing.T) {
-	t.Run("Doesn't crash when called", func(t *testing.T) {
-		Sample(-30000, -30000)
-		Sample( 30000,  30000)
+func Test_Ring(t *testing.T) {
+	t.Run("Reads what was written across the end of the buffer", func(t *testing.T) {
+		r := NewRing(8)
+		r.Write([]byte{1, 2, 3, 4, 5, 6})
+		r.Read(make([]byte, 4))
+		r.Write([]byte{7, 8, 9, 10})
+		got := make([]byte, 8)
+		n := r.Read(got)
+		if want := []byte{5, 6, 7, 8, 9, 10}; !bytes.Equal(got[:n], want) {
+			t.Errorf("Read() = %v, want %v", got[:n], want)
+		}
+	})
+
+	t.Run("Drops what doesn't fit", func(t *testing.T) {
+		r := NewRing(4)
+		if n := r.Write([]byte{1, 2, 3, 4, 5, 6}); n != 4 {
+			t.Errorf("Write() = %d, want %d", n, 4)
+		}
+		if n := r.Len(); n != 4 {
+			t.Errorf("Len() = %d, want %d", n, 4)
+		}
+	})
+
+	t.Run("Passes a stream between goroutines", func(t *testing.T) {
+		r := NewRing(64)
+		const total = 100000
+		var wg sync.WaitGroup
+		wg.Add(1)
+		got := []byte{}
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 16)
+			for len(got) < total {
+				n := r.Read(buf)
+				got = append(got, buf[:n]...)
+			}
+		}()
+		for i := 0; i < total; {
+			if r.Write([]byte{byte(i)}) == 1 {
+				i++
+			}
+		}
+		wg.Wait()
+		for i := range got {
+			if got[i] != byte(i) {
+				t.Fatalf("byte %d = %d, want %d", i, got[i], byte(i))
+			}
+		}
 	})
 }
 
-func Test_fillInternalBuf(t *testing.T) {
-	Reconfigure(48000)
-	type args struct {
-		buf  []byte
-		size int32
-	}
-	tests := []struct {
-		name string
-		args args
-		want int32
-	}{
-		{
-			name: "Fill the buffer partially",
-			args: args{
-				buf:  make([]byte, bufSize),
-				size: 6000,
-			},
-			want: 6000,
-		},
-		{
-			name: "Fill the buffer fully",
-			args: args{
-				buf:  make([]byte, bufSize),
-				size: 6000,
-			},
-			want: 2192,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := fillInternalBuf(tt.args.buf[:tt.args.size]); got != tt.want {
-				t.Errorf("fillInternalBuf() = %v, want %v", got, tt.want)
+func Test_File(t *testing.T) {
+	t.Run("Writes the ring to a WAV file in real time", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out.wav")
+		d := NewFile(path)
+		r := NewRing(ringSize)
+		if err := d.Start(r, outputRate); err != nil {
+			t.Fatal(err)
+		}
+		r.Write(bytes.Repeat([]byte{1}, 4800*4))
+		time.Sleep(clockPeriod * 20)
+		d.Stop()
+		d.Close()
+
+		data, _ := os.ReadFile(path)
+		if len(data) < 44 {
+			t.Fatalf("len = %d, want a WAV file", len(data))
+		}
+		size := binary.LittleEndian.Uint32(data[40:])
+		if size == 0 || int(size) != len(data)-44 || int(size) > 4800*4 {
+			t.Errorf("data size = %d, want between 1 and %d", size, 4800*4)
+		}
+	})
+	t.Run("Appends to the file across restarts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out.wav")
+		d := NewFile(path)
+		r := NewRing(ringSize)
+		for i := 0; i < 2; i++ {
+			if err := d.Start(r, outputRate); err != nil {
+				t.Fatal(err)
 			}
-		})
-	}
+			r.Write(bytes.Repeat([]byte{1}, 480*4))
+			time.Sleep(clockPeriod * 5)
+			d.Stop()
+		}
+		d.Close()
+
+		data, _ := os.ReadFile(path)
+		if len(data) < 44 {
+			t.Fatalf("len = %d, want a WAV file", len(data))
+		}
+		size := binary.LittleEndian.Uint32(data[40:])
+		if int(size) != len(data)-44 || size != 2*480*4 {
+			t.Errorf("data size = %d, want %d", size, 2*480*4)
+		}
+	})
 }
@@ -0,0 +1,133 @@
+package audio
+
+import (
+	"os"
+	"time"
+
+	"github.com/libretro/ludo/record"
+)
+
+// Driver plays the stereo 16 bits audio queued in a ring buffer. Drivers
+// consume the ring from their own goroutine, at the pace of the output.
+type Driver interface {
+	// Start begins the playback of the ring at the given sample rate
+	Start(r *Ring, rate int) error
+	// Stop ends the playback and releases the resources of the driver
+	Stop()
+	// SetVolume sets the volume, from 0 to 1
+	SetVolume(vol float32)
+}
+
+// clockPeriod is how often the clocked drivers consume the ring
+const clockPeriod = 10 * time.Millisecond
+
+// clock consumes a ring at real time speed, as a sound device would, and
+// hands the audio to a sink
+type clock struct {
+	sink func(pcm []byte)
+	quit chan struct{}
+	done chan struct{}
+}
+
+func (c *clock) start(r *Ring, rate int) {
+	c.quit = make(chan struct{})
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(clockPeriod)
+		defer ticker.Stop()
+		buf := make([]byte, rate*4*int(clockPeriod)/int(time.Second))
+		for {
+			select {
+			case <-c.quit:
+				return
+			case <-ticker.C:
+				n := r.Read(buf)
+				c.sink(buf[:n])
+			}
+		}
+	}()
+}
+
+func (c *clock) stop() {
+	close(c.quit)
+	<-c.done
+}
+
+// Null is a driver discarding the audio, for machines without a sound device
+type Null struct {
+	clock
+}
+
+// NewNull returns a null driver
+func NewNull() *Null {
+	return &Null{clock{sink: func([]byte) {}}}
+}
+
+// Start begins discarding the ring
+func (d *Null) Start(r *Ring, rate int) error {
+	d.start(r, rate)
+	return nil
+}
+
+// Stop ends the playback
+func (d *Null) Stop() {
+	d.stop()
+}
+
+// SetVolume does nothing
+func (d *Null) SetVolume(vol float32) {}
+
+// File is a driver writing the audio to a WAV file, for tests and for
+// capturing the exact output, filters included. The file is created on the
+// first Start and the following restarts of the driver append to it, until
+// Close.
+type File struct {
+	clock
+	path string
+	f    *os.File
+	wav  *record.WAV
+}
+
+// NewFile returns a driver writing to the WAV file at path
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Start creates the file if needed and begins writing the ring to it
+func (d *File) Start(r *Ring, rate int) error {
+	if d.f == nil {
+		f, err := os.Create(d.path)
+		if err != nil {
+			return err
+		}
+		wav, err := record.NewWAV(f, rate)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		d.f, d.wav = f, wav
+	}
+	d.sink = func(pcm []byte) { d.wav.Write(pcm) }
+	d.start(r, rate)
+	return nil
+}
+
+// Stop ends the playback and writes the header, leaving a complete file
+func (d *File) Stop() {
+	d.stop()
+	d.wav.Close()
+}
+
+// Close closes the file, the next Start creates it again
+func (d *File) Close() error {
+	if d.f == nil {
+		return nil
+	}
+	err := d.f.Close()
+	d.f, d.wav = nil, nil
+	return err
+}
+
+// SetVolume does nothing, the file gets the audio at full volume
+func (d *File) SetVolume(vol float32) {}
@@ -0,0 +1,91 @@
+package audio
+
+import (
+	"time"
+
+	"golang.org/x/mobile/exp/audio/al"
+)
+
+// OpenAL buffers
+const (
+	alNumBuffers = 4
+	alBufSize    = 1024 * 2
+)
+
+// OpenAL is a driver playing the audio with OpenAL. The device must be opened
+// before starting it.
+type OpenAL struct {
+	source  al.Source
+	buffers []al.Buffer
+	free    []al.Buffer // buffers not queued
+	volume  float32
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// NewOpenAL returns an OpenAL driver
+func NewOpenAL() *OpenAL {
+	return &OpenAL{volume: 1}
+}
+
+// Start generates the source and the buffers, and begins streaming the ring
+func (d *OpenAL) Start(r *Ring, rate int) error {
+	d.source = al.GenSources(1)[0]
+	d.buffers = al.GenBuffers(alNumBuffers)
+	d.free = append([]al.Buffer{}, d.buffers...)
+	d.source.SetGain(d.volume)
+	d.quit = make(chan struct{})
+	d.done = make(chan struct{})
+	go d.run(r, int32(rate))
+	return nil
+}
+
+// run queues the audio of the ring in the buffers released by OpenAL
+func (d *OpenAL) run(r *Ring, rate int32) {
+	defer close(d.done)
+	chunk := make([]byte, alBufSize)
+	for {
+		select {
+		case <-d.quit:
+			return
+		default:
+		}
+
+		if n := d.source.BuffersProcessed(); n > 0 {
+			processed := make([]al.Buffer, n)
+			d.source.UnqueueBuffers(processed...)
+			d.free = append(d.free, processed...)
+		}
+
+		for len(d.free) > 0 && r.Len() >= len(chunk) {
+			r.Read(chunk)
+			b := d.free[len(d.free)-1]
+			d.free = d.free[:len(d.free)-1]
+			b.BufferData(al.FormatStereo16, chunk, rate)
+			d.source.QueueBuffers(b)
+		}
+
+		if d.source.State() != al.Playing && len(d.free) < len(d.buffers) {
+			al.PlaySources(d.source)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Stop ends the streaming and deletes the source and the buffers
+func (d *OpenAL) Stop() {
+	close(d.quit)
+	<-d.done
+	al.StopSources(d.source)
+	al.DeleteSources(d.source)
+	al.DeleteBuffers(d.buffers...)
+}
+
+// SetVolume sets the gain of the source
+func (d *OpenAL) SetVolume(vol float32) {
+	d.volume = vol
+	if d.buffers != nil {
+		d.source.SetGain(vol)
+	}
+}
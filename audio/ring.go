@@ -0,0 +1,56 @@
+package audio
+
+import "sync/atomic"
+
+// Ring is a lock-free ring buffer of bytes for a single producer, the
+// emulation thread, and a single consumer, the audio driver. Neither side ever
+// waits for the other.
+type Ring struct {
+	buf   []byte
+	read  atomic.Uint64 // total bytes read, only written by the consumer
+	write atomic.Uint64 // total bytes written, only written by the producer
+}
+
+// NewRing returns a ring buffer of size bytes
+func NewRing(size int) *Ring {
+	return &Ring{buf: make([]byte, size)}
+}
+
+// Size returns the capacity of the ring
+func (r *Ring) Size() int {
+	return len(r.buf)
+}
+
+// Len returns the number of bytes ready to be read
+func (r *Ring) Len() int {
+	return int(r.write.Load() - r.read.Load())
+}
+
+// Write copies as much of p as fits in the ring, and returns the number of
+// bytes written
+func (r *Ring) Write(p []byte) int {
+	w := r.write.Load()
+	free := len(r.buf) - int(w-r.read.Load())
+	if len(p) > free {
+		p = p[:free]
+	}
+	i := int(w % uint64(len(r.buf)))
+	n := copy(r.buf[i:], p)
+	copy(r.buf, p[n:])
+	r.write.Store(w + uint64(len(p)))
+	return len(p)
+}
+
+// Read fills p with as many bytes as available, and returns their number
+func (r *Ring) Read(p []byte) int {
+	rd := r.read.Load()
+	avail := int(r.write.Load() - rd)
+	if len(p) > avail {
+		p = p[:avail]
+	}
+	i := int(rd % uint64(len(r.buf)))
+	n := copy(p, r.buf[i:])
+	copy(p[n:], r.buf)
+	r.read.Store(rd + uint64(len(p)))
+	return len(p)
+}
@@ -0,0 +1,198 @@
+// Package cheats loads RetroArch .cht files and applies cheat codes every frame
+// by writing to the emulated memory. Game Genie, Action Replay and raw address
+// codes are supported. Addresses are resolved through the memory maps of the
+// core if it provides them, or are offsets in the system RAM otherwise.
+//
+// The .cht files are only read, they can come from the libretro database. The
+// cheats enabled by the user are saved in a game.cheats.toml file of the
+// cheats directory.
+package cheats
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// Cheat is a cheat code with a description
+type Cheat struct {
+	Desc    string
+	Code    string
+	Enabled bool
+	writes  []Write
+	retro   *retroCheat // set for cheats using the RetroArch handler
+}
+
+// Current stores the cheats of the loaded game
+var Current []Cheat
+
+// path of the game the current cheats were loaded for
+var game string
+
+// savedCheat is the state of a cheat saved by the user. It is matched with the
+// cheats of the .cht file by position, description and code.
+type savedCheat struct {
+	Index   int    `toml:"index"`
+	Desc    string `toml:"desc"`
+	Code    string `toml:"code"`
+	Enabled bool   `toml:"enabled"`
+}
+
+type savedCheats struct {
+	Cheats []savedCheat `toml:"cheat"`
+}
+
+// UserPath returns the path of the file saving the cheats enabled for a game
+func UserPath(gamePath string) string {
+	return filepath.Join(settings.Current.CheatsDirectory, utils.FileName(gamePath)+".cheats.toml")
+}
+
+// find returns the path of the .cht file of a game. The file is searched next
+// to the game, then in the cheats directory and its subdirectories, which
+// matches the layout of the libretro database.
+func find(gamePath string) string {
+	name := utils.FileName(gamePath) + ".cht"
+	candidates := []string{
+		filepath.Join(filepath.Dir(gamePath), name),
+		filepath.Join(settings.Current.CheatsDirectory, name),
+	}
+	matches, _ := filepath.Glob(filepath.Join(settings.Current.CheatsDirectory, "*", name))
+	candidates = append(candidates, matches...)
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return ""
+}
+
+// Load loads the cheats of a game, if any
+func Load(gamePath string) error {
+	Clear()
+	path := find(gamePath)
+	if path == "" {
+		return nil
+	}
+
+	cheats, err := LoadCHT(path)
+	if err != nil {
+		return err
+	}
+	for i := range cheats {
+		if cheats[i].retro != nil {
+			err = cheats[i].retro.validate()
+		} else {
+			cheats[i].writes, err = Parse(cheats[i].Code)
+		}
+		if err != nil {
+			log.Println("[Cheats]:", cheats[i].Desc+":", err)
+			cheats[i].retro = nil
+			cheats[i].Enabled = false
+		}
+	}
+	Current = cheats
+	game = gamePath
+	return restore(UserPath(gamePath), Current)
+}
+
+// restore enables the cheats as saved by the user
+func restore(userPath string, cheats []Cheat) error {
+	b, err := os.ReadFile(userPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved savedCheats
+	if err := toml.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+	for _, s := range saved.Cheats {
+		if s.Index < 0 || s.Index >= len(cheats) {
+			continue
+		}
+		c := &cheats[s.Index]
+		if c.Desc == s.Desc && c.Code == s.Code && (c.writes != nil || c.retro != nil) {
+			c.Enabled = s.Enabled
+		}
+	}
+	return nil
+}
+
+// Save persists the state of the current cheats in the user file of the game.
+// The .cht file is left untouched.
+func Save() error {
+	if game == "" {
+		return nil
+	}
+	saved := savedCheats{Cheats: []savedCheat{}}
+	for i, c := range Current {
+		saved.Cheats = append(saved.Cheats, savedCheat{Index: i, Desc: c.Desc, Code: c.Code, Enabled: c.Enabled})
+	}
+	b, err := toml.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(settings.Current.CheatsDirectory, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(UserPath(game), b, 0644)
+}
+
+// Clear unloads the current cheats
+func Clear() {
+	Current = nil
+	game = ""
+}
+
+// ApplyTo writes the enabled cheats to the memory. Like in RetroArch, the
+// conditional cheats using the RetroArch handler decide whether the next one
+// of them runs.
+func ApplyTo(m *Memory, cheats []Cheat) {
+	runNext := true
+	for _, c := range cheats {
+		if !c.Enabled {
+			continue
+		}
+		if c.retro != nil {
+			if !runNext {
+				runNext = true
+				continue
+			}
+			runNext = c.retro.apply(m)
+			continue
+		}
+		for _, w := range c.writes {
+			if w.Compare >= 0 {
+				if v, ok := m.Peek(w.Address); !ok || int(v) != w.Compare {
+					continue
+				}
+			}
+			m.Poke(w.Address, w.Value)
+		}
+	}
+}
+
+// Active returns true if a cheat is enabled
+func Active() bool {
+	for _, c := range Current {
+		if c.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply writes the enabled cheats to the memory of the running core. It is
+// meant to be called once per frame.
+func Apply() {
+	if len(Current) == 0 {
+		return
+	}
+	ApplyTo(CoreMemory(), Current)
+}
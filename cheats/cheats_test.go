@@ -0,0 +1,267 @@
+package cheats
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/settings"
+)
+
+func Test_LoadCHT(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Game.cht")
+	os.WriteFile(path, []byte(`cheats = 3
+
+cheat0_desc = "Infinite Lives"
+cheat0_code = "7E0DBE:05"
+cheat0_enable = false
+
+cheat1_desc = "Max Money"
+cheat1_code = "7E0DC0:99+7E0DC1:99"
+cheat1_enable = true
+
+cheat2_desc = "Level Select"
+cheat2_handler = "1"
+cheat2_address = "3312"
+cheat2_value = "4660"
+cheat2_memory_search_size = "4"
+cheat2_big_endian = "true"
+cheat2_enable = "false"
+`), 0644)
+
+	want := []Cheat{
+		{Desc: "Infinite Lives", Code: "7E0DBE:05", Enabled: false},
+		{Desc: "Max Money", Code: "7E0DC0:99+7E0DC1:99", Enabled: true},
+		{Desc: "Level Select", Enabled: false, retro: &retroCheat{
+			cheatType: retroSet, address: 3312, value: 4660, size: 4, bitMask: 0xff,
+			bigEndian: true, repeatCount: 1, repeatAddress: 1,
+		}},
+	}
+
+	t.Run("Reads codes and RetroArch handler cheats", func(t *testing.T) {
+		got, err := LoadCHT(path)
+		if err != nil {
+			t.Fatalf("LoadCHT() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("LoadCHT() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Rejects a cheat count bigger than the file", func(t *testing.T) {
+		huge := filepath.Join(t.TempDir(), "Huge.cht")
+		os.WriteFile(huge, []byte("cheats = 2000000000\n"), 0644)
+		if _, err := LoadCHT(huge); err == nil {
+			t.Errorf("LoadCHT() error = nil, want an error")
+		}
+	})
+}
+
+func Test_ApplyTo_retro(t *testing.T) {
+	wram := make([]byte, 0x10)
+	rom := make([]byte, 0x10)
+	sram := make([]byte, 0x10)
+	// RetroArch cheats see the two system RAM regions one after the other
+	m := &Memory{Descriptors: []libretro.MemoryDescriptor{
+		{Flags: libretro.MemDescSystemRAM, Ptr: unsafe.Pointer(&wram[0]), Start: 0x7E0000, Len: 0x10},
+		{Flags: libretro.MemDescConst, Ptr: unsafe.Pointer(&rom[0]), Start: 0x8000, Len: 0x10},
+		{Flags: libretro.MemDescSystemRAM, Ptr: unsafe.Pointer(&sram[0]), Start: 0x700000, Len: 0x10},
+	}}
+	retro := func(r retroCheat) Cheat {
+		if r.repeatCount == 0 {
+			r.repeatCount, r.repeatAddress = 1, 1
+		}
+		return Cheat{Enabled: true, retro: &r}
+	}
+
+	t.Run("Addresses are offsets in the system RAM regions", func(t *testing.T) {
+		ApplyTo(m, []Cheat{retro(retroCheat{cheatType: retroSet, address: 0x12, value: 0x1234, size: 4, bigEndian: true})})
+		if sram[2] != 0x12 || sram[3] != 0x34 {
+			t.Errorf("sram[2:4] = %#x, want %#x", sram[2:4], []byte{0x12, 0x34})
+		}
+	})
+
+	t.Run("Increases and repeats values", func(t *testing.T) {
+		wram[0] = 5
+		ApplyTo(m, []Cheat{retro(retroCheat{cheatType: retroIncrease, address: 0, value: 2, size: 3, repeatCount: 3, repeatAddress: 2, repeatValue: 1})})
+		want := []byte{7, 0, 8, 0, 9}
+		if !reflect.DeepEqual(wram[:5], want) {
+			t.Errorf("wram[:5] = %v, want %v", wram[:5], want)
+		}
+	})
+
+	t.Run("Writes the bits of the mask only", func(t *testing.T) {
+		wram[8] = 0xf0
+		ApplyTo(m, []Cheat{retro(retroCheat{cheatType: retroSet, address: 8, value: 0x0f, size: 2, bitMask: 0x03})})
+		if wram[8] != 0xf3 {
+			t.Errorf("wram[8] = %#x, want %#x", wram[8], 0xf3)
+		}
+	})
+
+	t.Run("Runs the next cheat only if the condition is met", func(t *testing.T) {
+		wram[10], wram[11] = 1, 0
+		cheats := []Cheat{
+			retro(retroCheat{cheatType: retroRunNextIfEq, address: 10, value: 2, size: 3}),
+			retro(retroCheat{cheatType: retroSet, address: 11, value: 0xaa, size: 3}),
+		}
+		ApplyTo(m, cheats)
+		if wram[11] != 0 {
+			t.Errorf("wram[11] = %#x, want %#x", wram[11], 0)
+		}
+		wram[10] = 2
+		ApplyTo(m, cheats)
+		if wram[11] != 0xaa {
+			t.Errorf("wram[11] = %#x, want %#x", wram[11], 0xaa)
+		}
+	})
+
+	t.Run("Falls back to the system RAM", func(t *testing.T) {
+		ram := make([]byte, 4)
+		ApplyTo(&Memory{RAM: ram}, []Cheat{retro(retroCheat{cheatType: retroSet, address: 3, value: 9, size: 3})})
+		if ram[3] != 9 {
+			t.Errorf("ram[3] = %#x, want %#x", ram[3], 9)
+		}
+	})
+
+	t.Run("Rejects unknown types and sizes", func(t *testing.T) {
+		for _, r := range []retroCheat{
+			{cheatType: 8, size: 3, repeatCount: 1},
+			{cheatType: retroSet, size: 6, repeatCount: 1},
+			{cheatType: retroSet, size: 0, repeatCount: 2},
+		} {
+			if err := r.validate(); err == nil {
+				t.Errorf("validate(%+v) = nil, want an error", r)
+			}
+		}
+	})
+}
+
+func Test_Save(t *testing.T) {
+	cheatsDir := settings.Current.CheatsDirectory
+	defer func() {
+		settings.Current.CheatsDirectory = cheatsDir
+		Clear()
+	}()
+	settings.Current.CheatsDirectory = t.TempDir()
+
+	// A shared file of the libretro database
+	cht := filepath.Join(settings.Current.CheatsDirectory, "Nintendo - SNES", "Game.cht")
+	os.MkdirAll(filepath.Dir(cht), os.ModePerm)
+	content := "cheats = 2\n\ncheat0_desc = \"Lives\"\ncheat0_code = \"7E0DBE:05\"\ncheat0_enable = false\n\n" +
+		"cheat1_desc = \"100% Items\"\ncheat1_code = \"7E0DC0:99\"\ncheat1_enable = true\n"
+	os.WriteFile(cht, []byte(content), 0644)
+
+	if err := Load("/roms/Game.sfc"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	Current[0].Enabled = true
+	Current[1].Enabled = false
+
+	t.Run("Saves to the user file and leaves the .cht untouched", func(t *testing.T) {
+		if err := Save(); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		b, _ := os.ReadFile(cht)
+		if string(b) != content {
+			t.Errorf("Save() changed the .cht file to %s", b)
+		}
+		if _, err := os.Stat(UserPath("/roms/Game.sfc")); err != nil {
+			t.Errorf("Save() didn't write the user file: %v", err)
+		}
+	})
+
+	t.Run("Restores the saved state when loading", func(t *testing.T) {
+		if err := Load("/roms/Game.sfc"); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !Current[0].Enabled || Current[1].Enabled {
+			t.Errorf("Load() enabled = %t, %t, want true, false", Current[0].Enabled, Current[1].Enabled)
+		}
+	})
+
+	t.Run("Ignores saved cheats that don't match anymore", func(t *testing.T) {
+		os.WriteFile(cht, []byte(strings.Replace(content, "Lives", "Infinite Lives", 1)), 0644)
+		if err := Load("/roms/Game.sfc"); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if Current[0].Enabled {
+			t.Errorf("Load() enabled = %t, want %t", Current[0].Enabled, false)
+		}
+	})
+}
+
+func Test_ApplyTo(t *testing.T) {
+	wram := make([]byte, 0x2000)
+	rom := make([]byte, 0x10)
+	m := &Memory{Descriptors: []libretro.MemoryDescriptor{
+		// 8KB of work RAM mirrored 4 times in the first 32KB
+		{Ptr: unsafe.Pointer(&wram[0]), Start: 0x0000, Select: 0x8000, Disconnect: 0x6000, Len: 0x2000},
+		{Flags: libretro.MemDescConst, Ptr: unsafe.Pointer(&rom[0]), Start: 0x8000, Len: 0x10},
+	}}
+
+	cheat := func(code string) Cheat {
+		writes, err := Parse(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Cheat{Code: code, Enabled: true, writes: writes}
+	}
+
+	t.Run("Writes through mirrored memory descriptors", func(t *testing.T) {
+		ApplyTo(m, []Cheat{cheat("6010:2A")})
+		if wram[0x10] != 0x2A {
+			t.Errorf("wram[0x10] = %#x, want %#x", wram[0x10], 0x2A)
+		}
+	})
+
+	t.Run("Only writes when the compare value matches", func(t *testing.T) {
+		c := Cheat{Enabled: true, writes: []Write{{Address: 0x20, Value: 1, Compare: 5}}}
+		ApplyTo(m, []Cheat{c})
+		if wram[0x20] != 0 {
+			t.Errorf("wram[0x20] = %#x, want %#x", wram[0x20], 0)
+		}
+		wram[0x20] = 5
+		ApplyTo(m, []Cheat{c})
+		if wram[0x20] != 1 {
+			t.Errorf("wram[0x20] = %#x, want %#x", wram[0x20], 1)
+		}
+	})
+
+	t.Run("Skips disabled cheats and constant regions", func(t *testing.T) {
+		disabled := cheat("0030:01")
+		disabled.Enabled = false
+		ApplyTo(m, []Cheat{disabled, cheat("8001:01")})
+		if wram[0x30] != 0 || rom[1] != 0 {
+			t.Errorf("wram[0x30], rom[1] = %#x, %#x, want 0, 0", wram[0x30], rom[1])
+		}
+	})
+
+	t.Run("Falls back to the system RAM", func(t *testing.T) {
+		ram := make([]byte, 16)
+		ApplyTo(&Memory{RAM: ram}, []Cheat{cheat("0004:7F"), cheat("0100:01")})
+		if ram[4] != 0x7F {
+			t.Errorf("ram[4] = %#x, want %#x", ram[4], 0x7F)
+		}
+	})
+}
+
+func Test_Memory_Regions(t *testing.T) {
+	wram := make([]byte, 0x800)
+	rom := make([]byte, 0x10)
+	m := &Memory{Descriptors: []libretro.MemoryDescriptor{
+		{Ptr: unsafe.Pointer(&wram[0]), Start: 0x0000, Len: 0x800},
+		{Ptr: unsafe.Pointer(&wram[0]), Start: 0x0800, Len: 0x800},
+		{Flags: libretro.MemDescConst, Ptr: unsafe.Pointer(&rom[0]), Start: 0x8000, Len: 0x10},
+	}}
+
+	t.Run("Lists writable regions once", func(t *testing.T) {
+		got := m.Regions()
+		if len(got) != 1 || got[0].Address != 0 || len(got[0].Data) != 0x800 {
+			t.Errorf("Regions() = %d regions, want the work RAM only", len(got))
+		}
+	})
+}
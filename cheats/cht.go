@@ -0,0 +1,179 @@
+package cheats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Types of the cheats using the RetroArch handler
+const (
+	retroDisabled = iota
+	retroSet
+	retroIncrease
+	retroDecrease
+	retroRunNextIfEq
+	retroRunNextIfNeq
+	retroRunNextIfLt
+	retroRunNextIfGt
+)
+
+// retroSizes maps the memory_search_size field of RetroArch cheats to a
+// number of bits
+var retroSizes = [...]int{1, 2, 4, 8, 16, 32}
+
+// retroCheat is a cheat using the RetroArch handler, described by an address
+// and a value instead of a code. The address is an offset in the memory seen
+// by RetroArch, see Memory.resolveOffset.
+type retroCheat struct {
+	cheatType     int
+	address       uint32
+	value         uint32
+	size          int  // index in retroSizes
+	bitMask       byte // bits written by cheats smaller than a byte
+	bigEndian     bool
+	repeatCount   int    // number of consecutive items written
+	repeatAddress int    // items between two written items
+	repeatValue   uint32 // added to the value for each item
+}
+
+// LoadCHT reads a RetroArch .cht file. Cheats using the RetroArch handler are
+// validated when loaded by Load.
+func LoadCHT(path string) ([]Cheat, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		fields[strings.TrimSpace(kv[0])] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every cheat takes at least a line of the file
+	count, err := strconv.Atoi(fields["cheats"])
+	if err != nil || count < 0 || count > len(fields) {
+		return nil, fmt.Errorf("%s: invalid cheat count", path)
+	}
+
+	cheats := make([]Cheat, count)
+	for i := range cheats {
+		field := func(name string) string {
+			return fields[fmt.Sprintf("cheat%d_%s", i, name)]
+		}
+		cheats[i] = Cheat{
+			Desc:    field("desc"),
+			Code:    field("code"),
+			Enabled: field("enable") == "true",
+		}
+		if field("handler") == "1" {
+			cheats[i].retro = parseRetroCheat(field)
+		}
+	}
+	return cheats, nil
+}
+
+// parseRetroCheat reads the fields of a cheat using the RetroArch handler.
+// Missing fields take the defaults of RetroArch.
+func parseRetroCheat(field func(string) string) *retroCheat {
+	number := func(name string, def int) int {
+		n, err := strconv.ParseInt(field(name), 10, 64)
+		if err != nil {
+			return def
+		}
+		return int(n)
+	}
+	return &retroCheat{
+		cheatType:     number("cheat_type", retroSet),
+		address:       uint32(number("address", 0)),
+		value:         uint32(number("value", 0)),
+		size:          number("memory_search_size", 3),
+		bitMask:       byte(number("address_bit_position", 0xff)),
+		bigEndian:     field("big_endian") == "true",
+		repeatCount:   number("repeat_count", 1),
+		repeatAddress: number("repeat_add_to_address", 1),
+		repeatValue:   uint32(number("repeat_add_to_value", 0)),
+	}
+}
+
+// validate rejects the cheats that can't be applied like RetroArch does
+func (r *retroCheat) validate() error {
+	if r.cheatType < retroDisabled || r.cheatType > retroRunNextIfGt {
+		return fmt.Errorf("unknown cheat type %d", r.cheatType)
+	}
+	if r.size < 0 || r.size >= len(retroSizes) {
+		return fmt.Errorf("unknown memory search size %d", r.size)
+	}
+	if r.repeatCount < 1 || r.repeatCount > 0x10000 || r.repeatAddress < 0 {
+		return errors.New("invalid repeat")
+	}
+	// RetroArch moves the bit mask instead of the address, which isn't worth
+	// reproducing
+	if retroSizes[r.size] < 8 && r.repeatCount > 1 {
+		return errors.New("repeated cheats smaller than a byte are not supported")
+	}
+	return nil
+}
+
+// apply applies the cheat to the memory. It returns false if the next cheat
+// must be skipped.
+func (r *retroCheat) apply(m *Memory) bool {
+	bits := retroSizes[r.size]
+	bytes := (bits + 7) / 8
+	mask := uint32(1<<bits - 1)
+
+	current, ok := m.readOffset(r.address, bytes, r.bigEndian)
+	if !ok {
+		return true
+	}
+
+	var value uint32
+	switch r.cheatType {
+	case retroSet:
+		value = r.value
+	case retroIncrease:
+		value = current + r.value
+	case retroDecrease:
+		value = current - r.value
+	case retroRunNextIfEq:
+		return current == r.value
+	case retroRunNextIfNeq:
+		return current != r.value
+	case retroRunNextIfLt:
+		return r.value < current
+	case retroRunNextIfGt:
+		return r.value > current
+	default:
+		return true
+	}
+
+	address := r.address
+	for i := 0; i < r.repeatCount; i++ {
+		if bits < 8 {
+			if old, ok := m.readOffset(address, 1, false); ok {
+				m.writeOffset(address, old&^uint32(r.bitMask)|value&uint32(r.bitMask), 1, false)
+			}
+		} else {
+			m.writeOffset(address, value&mask, bytes, r.bigEndian)
+		}
+		value = (value + r.repeatValue) & mask
+		address += uint32(r.repeatAddress * bytes)
+	}
+	return true
+}
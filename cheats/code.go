@@ -0,0 +1,135 @@
+package cheats
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Write is a single byte written to the emulated memory by a cheat code
+type Write struct {
+	Address uint32
+	Value   byte
+	Compare int // the write only happens if the current value matches, -1 to always write
+}
+
+const (
+	nesGameGenie  = "APZLGITYEOXUKSVN"
+	snesGameGenie = "DF4709156BC8A23E"
+)
+
+// Parse decodes a cheat code into memory writes. Several codes can be
+// chained with a +. The supported formats are:
+//
+//	7E0DBE:05        raw address and value, longer values are little endian
+//	7E0DBE05         Pro Action Replay, 24 bits address and 8 bits value
+//	SXIOPO, YEUZUGAA NES Game Genie, 6 or 8 letters
+//	C2C1-6FA4        SNES Game Genie
+func Parse(code string) ([]Write, error) {
+	writes := []Write{}
+	for _, c := range strings.Split(code, "+") {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		var w []Write
+		var err error
+		switch {
+		case strings.Contains(c, ":"):
+			w, err = parseRaw(c)
+		case len(c) == 9 && c[4] == '-':
+			w, err = parseSNESGameGenie(c)
+		case len(c) == 8 && isHex(c):
+			w, err = parseActionReplay(c)
+		case (len(c) == 6 || len(c) == 8) && strings.Trim(c, nesGameGenie) == "":
+			w, err = parseNESGameGenie(c)
+		default:
+			err = errors.New("unknown cheat format: " + c)
+		}
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, w...)
+	}
+	return writes, nil
+}
+
+func isHex(s string) bool {
+	_, err := strconv.ParseUint(s, 16, 64)
+	return err == nil
+}
+
+func parseRaw(code string) ([]Write, error) {
+	parts := strings.Split(code, ":")
+	if len(parts) != 2 || len(parts[1]) == 0 || len(parts[1]) > 8 {
+		return nil, errors.New("invalid raw code: " + code)
+	}
+	address, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return nil, errors.New("invalid raw code: " + code)
+	}
+	value, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return nil, errors.New("invalid raw code: " + code)
+	}
+
+	size := (len(parts[1]) + 1) / 2
+	writes := make([]Write, size)
+	for i := range writes {
+		writes[i] = Write{
+			Address: uint32(address) + uint32(i),
+			Value:   byte(value >> (8 * i)),
+			Compare: -1,
+		}
+	}
+	return writes, nil
+}
+
+func parseActionReplay(code string) ([]Write, error) {
+	n, err := strconv.ParseUint(code, 16, 32)
+	if err != nil {
+		return nil, errors.New("invalid Action Replay code: " + code)
+	}
+	return []Write{{Address: uint32(n >> 8), Value: byte(n), Compare: -1}}, nil
+}
+
+func parseNESGameGenie(code string) ([]Write, error) {
+	n := make([]int, len(code))
+	for i, c := range code {
+		n[i] = strings.IndexRune(nesGameGenie, c)
+	}
+
+	address := 0x8000 +
+		((n[3] & 7) << 12) |
+		((n[5] & 7) << 8) | ((n[4] & 8) << 8) |
+		((n[2] & 7) << 4) | ((n[1] & 8) << 4) |
+		(n[4] & 7) | (n[3] & 8)
+
+	w := Write{Address: uint32(address), Compare: -1}
+	if len(code) == 6 {
+		w.Value = byte(((n[1] & 7) << 4) | ((n[0] & 8) << 4) | (n[0] & 7) | (n[5] & 8))
+	} else {
+		w.Value = byte(((n[1] & 7) << 4) | ((n[0] & 8) << 4) | (n[0] & 7) | (n[7] & 8))
+		w.Compare = ((n[7] & 7) << 4) | ((n[6] & 8) << 4) | (n[6] & 7) | (n[5] & 8)
+	}
+	return []Write{w}, nil
+}
+
+func parseSNESGameGenie(code string) ([]Write, error) {
+	var t uint32
+	for _, c := range strings.Replace(code, "-", "", 1) {
+		i := strings.IndexRune(snesGameGenie, c)
+		if i < 0 {
+			return nil, errors.New("invalid Game Genie code: " + code)
+		}
+		t = t<<4 | uint32(i)
+	}
+
+	a := t & 0xFFFFFF
+	address := ((a & 0x003C00) << 10) |
+		((a & 0x00003C) << 14) |
+		((a & 0xF00000) >> 8) |
+		((a & 0x000003) << 10) |
+		((a & 0x00C000) >> 6) |
+		((a & 0x0F0000) >> 12) |
+		((a & 0x0003C0) >> 6)
+
+	return []Write{{Address: address, Value: byte(t >> 24), Compare: -1}}, nil
+}
@@ -0,0 +1,69 @@
+package cheats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []Write
+	}{
+		{
+			name: "Decodes a raw code",
+			code: "7E0DBE:05",
+			want: []Write{{Address: 0x7E0DBE, Value: 0x05, Compare: -1}},
+		},
+		{
+			name: "Decodes a raw code with a 16 bits value",
+			code: "C0F0:1234",
+			want: []Write{
+				{Address: 0xC0F0, Value: 0x34, Compare: -1},
+				{Address: 0xC0F1, Value: 0x12, Compare: -1},
+			},
+		},
+		{
+			name: "Decodes a Pro Action Replay code",
+			code: "7E0DBE05",
+			want: []Write{{Address: 0x7E0DBE, Value: 0x05, Compare: -1}},
+		},
+		{
+			name: "Decodes a 6 letters NES Game Genie code",
+			code: "SXIOPO",
+			want: []Write{{Address: 0x91D9, Value: 0xAD, Compare: -1}},
+		},
+		{
+			name: "Decodes an 8 letters NES Game Genie code",
+			code: "YEUZUGAA",
+			want: []Write{{Address: 0xACB3, Value: 0x07, Compare: 0x00}},
+		},
+		{
+			name: "Decodes chained codes",
+			code: "7E0DBE:05+7E0DBF:06",
+			want: []Write{
+				{Address: 0x7E0DBE, Value: 0x05, Compare: -1},
+				{Address: 0x7E0DBF, Value: 0x06, Compare: -1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.code)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Rejects unknown formats", func(t *testing.T) {
+		_, err := Parse("not a cheat")
+		if err == nil {
+			t.Errorf("Parse() error = %v, want an error", err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package cheats
+
+import (
+	"unsafe"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/ramsearch"
+	"github.com/libretro/ludo/state"
+)
+
+// Memory gives access to the emulated memory, addressed like the emulated
+// system does. When the core provides memory maps, addresses are resolved
+// through the memory descriptors. Otherwise they are offsets in the system RAM.
+// Cheats using the RetroArch handler use offsets instead, see resolveOffset.
+type Memory struct {
+	Descriptors []libretro.MemoryDescriptor
+	RAM         []byte
+}
+
+// CoreMemory returns the memory of the running core
+func CoreMemory() *Memory {
+	if state.Core == nil {
+		return &Memory{}
+	}
+	m := &Memory{Descriptors: state.Core.MemoryMap}
+	size := state.Core.GetMemorySize(libretro.MemorySystemRAM)
+	ptr := state.Core.GetMemoryData(libretro.MemorySystemRAM)
+	if ptr != nil && size > 0 {
+		m.RAM = unsafe.Slice((*byte)(ptr), size)
+	}
+	return m
+}
+
+// reduce removes the bits set in mask from address, shifting the higher
+// bits down. This is how libretro describes mirrored or disconnected lines.
+func reduce(address, mask uintptr) uintptr {
+	for mask != 0 {
+		low := (mask - 1) & ^mask
+		address = (address & low) | ((address >> 1) & ^low)
+		mask = (mask & (mask - 1)) >> 1
+	}
+	return address
+}
+
+// resolve returns a pointer to the byte at address, and the flags of the
+// memory region containing it
+func (m *Memory) resolve(address uint32) (*byte, uint64) {
+	a := uintptr(address)
+	if len(m.Descriptors) == 0 {
+		if a >= uintptr(len(m.RAM)) {
+			return nil, 0
+		}
+		return &m.RAM[a], 0
+	}
+
+	for _, d := range m.Descriptors {
+		if d.Ptr == nil || d.Len == 0 {
+			continue
+		}
+		if d.Select == 0 {
+			if a < d.Start || a >= d.Start+d.Len {
+				continue
+			}
+		} else if a&d.Select != d.Start&d.Select {
+			continue
+		}
+		offset := reduce((a-d.Start)&^d.Disconnect, d.Disconnect)
+		if offset >= d.Len {
+			continue
+		}
+		return (*byte)(unsafe.Add(d.Ptr, d.Offset+offset)), d.Flags
+	}
+	return nil, 0
+}
+
+// resolveOffset returns a pointer to the byte at an offset of the memory seen
+// by RetroArch cheats: the descriptors flagged as system RAM one after the
+// other, or the system RAM if there are none. Like RetroArch, the offsets of
+// the descriptors are not applied.
+func (m *Memory) resolveOffset(offset uint32) *byte {
+	o := uintptr(offset)
+	mapped := false
+	for _, d := range m.Descriptors {
+		if d.Flags&libretro.MemDescSystemRAM == 0 || d.Ptr == nil || d.Len == 0 {
+			continue
+		}
+		mapped = true
+		if o < d.Len {
+			return (*byte)(unsafe.Add(d.Ptr, o))
+		}
+		o -= d.Len
+	}
+	if mapped || o >= uintptr(len(m.RAM)) {
+		return nil
+	}
+	return &m.RAM[o]
+}
+
+// readOffset reads a value of n bytes at an offset, see resolveOffset
+func (m *Memory) readOffset(offset uint32, n int, bigEndian bool) (uint32, bool) {
+	var v uint32
+	for i := 0; i < n; i++ {
+		p := m.resolveOffset(offset + uint32(i))
+		if p == nil {
+			return 0, false
+		}
+		if bigEndian {
+			v = v<<8 | uint32(*p)
+		} else {
+			v |= uint32(*p) << (8 * i)
+		}
+	}
+	return v, true
+}
+
+// writeOffset writes a value of n bytes at an offset, see resolveOffset
+func (m *Memory) writeOffset(offset, v uint32, n int, bigEndian bool) {
+	for i := 0; i < n; i++ {
+		p := m.resolveOffset(offset + uint32(i))
+		if p == nil {
+			return
+		}
+		shift := 8 * i
+		if bigEndian {
+			shift = 8 * (n - 1 - i)
+		}
+		*p = byte(v >> shift)
+	}
+}
+
+// Peek reads the byte at address
+func (m *Memory) Peek(address uint32) (byte, bool) {
+	p, _ := m.resolve(address)
+	if p == nil {
+		return 0, false
+	}
+	return *p, true
+}
+
+// Poke writes the byte at address. Regions flagged as constant by the core
+// are never written to.
+func (m *Memory) Poke(address uint32, value byte) bool {
+	p, flags := m.resolve(address)
+	if p == nil || flags&libretro.MemDescConst != 0 {
+		return false
+	}
+	*p = value
+	return true
+}
+
+// Regions returns the writable memory areas, for searching. Mirrors of the
+// same area are only listed once.
+func (m *Memory) Regions() []ramsearch.Region {
+	if len(m.Descriptors) == 0 {
+		if len(m.RAM) == 0 {
+			return nil
+		}
+		return []ramsearch.Region{{Data: m.RAM}}
+	}
+
+	regions := []ramsearch.Region{}
+	seen := map[unsafe.Pointer]bool{}
+	for _, d := range m.Descriptors {
+		if d.Ptr == nil || d.Len == 0 || d.Flags&libretro.MemDescConst != 0 {
+			continue
+		}
+		ptr := unsafe.Add(d.Ptr, d.Offset)
+		if seen[ptr] {
+			continue
+		}
+		seen[ptr] = true
+		regions = append(regions, ramsearch.Region{
+			Address: uint32(d.Start),
+			Data:    unsafe.Slice((*byte)(ptr), d.Len),
+		})
+	}
+	return regions
+}
@@ -5,31 +5,61 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/cheats"
+	"github.com/libretro/ludo/disc"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/movie"
+	"github.com/libretro/ludo/netplay"
 	"github.com/libretro/ludo/options"
+	"github.com/libretro/ludo/pacing"
 	"github.com/libretro/ludo/patch"
+	"github.com/libretro/ludo/record"
+	"github.com/libretro/ludo/rewind"
+	"github.com/libretro/ludo/runahead"
 	"github.com/libretro/ludo/savefiles"
+	"github.com/libretro/ludo/script"
+	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
 
 	"github.com/mholt/archiver/v3"
 )
 
-var vid *video.Video
+var vid video.Renderer
+
+// patchCacheDir is where patched images are written for cores that need a
+// path to the game
+var patchCacheDir = filepath.Join(xdg.CacheHome, "ludo", "patched")
+
+// patchedPath is the path of the patched image of the current game, if any
+var patchedPath string
+
+// content is what the core was given for the current game: the path of the
+// content, and its bytes if the core doesn't need the full path
+var content struct {
+	path string
+	data []byte
+}
 
 // Options holds the settings for the current core
 var Options *options.Options
 
 // Init is there mainly for dependency injection.
 // Call Init before calling other functions of this package.
-func Init(v *video.Video) {
+func Init(v video.Renderer) {
 	vid = v
 }
 
@@ -134,6 +164,147 @@ func unarchiveGame(filename string) (string, int64, error) {
 	return path, size, err
 }
 
+// patchToCache applies the patches of a game and writes the result to the
+// patch cache directory. This is used to softpatch games for cores that need a
+// path to the game instead of its content. It returns an empty path if there
+// is nothing to patch. For cue sheets, the patches apply to the data track.
+func patchToCache(gamePath, romPath string) (string, error) {
+	patches, err := patch.List(gamePath)
+	if err != nil || !patch.HasEnabled(patches) {
+		return "", err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(romPath)); ext {
+	case ".cue":
+		return patchCueToCache(gamePath, romPath, patches)
+	case ".gdi", ".m3u", ".ccd", ".mds", ".toc":
+		return "", fmt.Errorf("can't soft-patch %s images made of several files", ext)
+	}
+
+	bytes, err := os.ReadFile(romPath)
+	if err != nil {
+		return "", err
+	}
+
+	patched, err := patch.Apply(gamePath, bytes, patches)
+	if err != nil || patched == nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(patchCacheDir, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the original file name, some cores rely on it
+	path := filepath.Join(patchCacheDir, filepath.Base(romPath))
+	err = os.WriteFile(path, *patched, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	patchedPath = path
+	return path, nil
+}
+
+// patchCueToCache patches the data track of a cue sheet. The patched track is
+// written to a directory of the cache, next to the cue sheet and links to the
+// other tracks, so that the core finds every file where the cue sheet says.
+func patchCueToCache(gamePath, cuePath string, patches []patch.Patch) (string, error) {
+	tracks, err := disc.ReadCue(cuePath)
+	if err != nil {
+		return "", err
+	}
+	data, ok := disc.FirstDataTrack(tracks)
+	if !ok {
+		return "", errors.New("no data track to patch")
+	}
+
+	bytes, err := os.ReadFile(data.File)
+	if err != nil {
+		return "", err
+	}
+	patched, err := patch.Apply(gamePath, bytes, patches)
+	if err != nil || patched == nil {
+		return "", err
+	}
+
+	dir := filepath.Join(patchCacheDir, utils.FileName(cuePath))
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	patchedPath = dir
+
+	path := filepath.Join(dir, filepath.Base(cuePath))
+	if err := linkFile(cuePath, path); err != nil {
+		cleanPatchCache()
+		return "", err
+	}
+	for _, f := range disc.Files(tracks) {
+		rel, err := filepath.Rel(filepath.Dir(cuePath), f)
+		if err != nil || filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+			cleanPatchCache()
+			return "", fmt.Errorf("%s: tracks have to be next to the cue sheet", filepath.Base(cuePath))
+		}
+		dst := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			cleanPatchCache()
+			return "", err
+		}
+		if f == data.File {
+			err = os.WriteFile(dst, *patched, 0644)
+		} else {
+			err = linkFile(f, dst)
+		}
+		if err != nil {
+			cleanPatchCache()
+			return "", err
+		}
+	}
+
+	return path, nil
+}
+
+// linkFile makes a symbolic link to a file, or copies it where links aren't
+// supported
+func linkFile(src, dst string) error {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(abs, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// cleanPatchCache removes the patched image of the current game, if any
+func cleanPatchCache() {
+	if patchedPath == "" {
+		return
+	}
+	if err := os.RemoveAll(patchedPath); err != nil {
+		log.Println("[Patch]:", err)
+	}
+	patchedPath = ""
+}
+
 // LoadGame loads a game. A core has to be loaded first.
 func LoadGame(gamePath string) error {
 	if _, err := os.Stat(gamePath); os.IsNotExist(err) {
@@ -163,37 +334,68 @@ func LoadGame(gamePath string) error {
 			return err
 		}
 
-		if patched, _ := patch.Try(gamePath, bytes); patched != nil {
-			gi.Size = int64(len(*patched))
-			gi.SetData(*patched)
-		} else {
-			gi.SetData(bytes)
+		patched, err := patch.Try(gamePath, bytes)
+		if err != nil {
+			log.Println("[Patch]:", err)
+		}
+		if patched != nil {
+			bytes = *patched
+		}
+		gi.Size = int64(len(bytes))
+		gi.SetData(bytes)
+		content.data = bytes
+	} else {
+		path, err := patchToCache(gamePath, gi.Path)
+		if err != nil {
+			log.Println("[Patch]:", err)
+		}
+		if path != "" {
+			gi.Path = path
 		}
+		content.data = nil
 	}
+	content.path = gi.Path
 
 	ok := state.Core.LoadGame(*gi)
 	if !ok {
 		state.CoreRunning = false
+		cleanPatchCache()
+		if v, ok := vid.(*video.Video); ok {
+			v.DeinitHWRender()
+		}
+		state.Core.HWRenderCallback = nil
 		return errors.New("failed to load the game")
 	}
 
 	avi := state.Core.GetSystemAVInfo()
 
-	vid.Geom = avi.Geometry
+	vid.SetGeometry(avi.Geometry)
+
+	if err := initHWRender(avi.Geometry); err != nil {
+		state.Core.UnloadGame()
+		state.CoreRunning = false
+		cleanPatchCache()
+		return err
+	}
 
 	// Append the library name to the window title.
 	if len(si.LibraryName) > 0 {
 		vid.SetTitle("Ludo - " + si.LibraryName)
 	}
 
-	input.Init(vid)
+	// The input is read from the window, only the OpenGL renderer has one
+	if v, ok := vid.(*video.Video); ok && !state.Headless {
+		input.Init(v)
+	}
 	audio.Reconfigure(int32(avi.Timing.SampleRate))
+	pacing.Init(avi.Timing.FPS)
 	if state.Core.AudioCallback != nil {
 		state.Core.AudioCallback.SetState(true)
 	}
 
 	state.CoreRunning = true
 	state.FastForward = false
+	state.Paused = false
 	state.GamePath = gamePath
 
 	state.Core.SetControllerPortDevice(0, libretro.DeviceJoypad)
@@ -204,10 +406,48 @@ func LoadGame(gamePath string) error {
 
 	log.Println("[Core]: Game loaded: " + gamePath)
 	savefiles.LoadSRAM()
+	rewind.Init()
+	runahead.Init(*gi)
+	if err := cheats.Load(gamePath); err != nil {
+		log.Println("[Cheats]:", err)
+	}
+	if settings.Current.Achievements {
+		if !state.Core.SupportsAchievements {
+			log.Println("[Achievements]: The core doesn't declare achievements support")
+		}
+		LoadAchievements()
+	}
 
 	return nil
 }
 
+// LoadAchievements fetches the achievements of the current game. The game is
+// identified by the content given to the core, once extracted and patched.
+func LoadAchievements() {
+	achievements.Load(content.path, content.data)
+}
+
+// ContentCRC returns the CRC32 of the content given to the core, to check that
+// netplay peers run the same game
+func ContentCRC() (uint32, error) {
+	if content.data != nil {
+		return crc32.ChecksumIEEE(content.data), nil
+	}
+	if content.path == "" {
+		return 0, nil
+	}
+	fd, err := os.Open(content.path)
+	if err != nil {
+		return 0, err
+	}
+	defer fd.Close()
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, fd); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
 // Unload unloads a libretro core
 func Unload() {
 	if state.Core != nil {
@@ -222,8 +462,27 @@ func Unload() {
 // UnloadGame unloads a game.
 func UnloadGame() {
 	if state.CoreRunning {
+		netplay.Disconnect()
+		if err := movie.Stop(); err != nil {
+			log.Println("[Movie]:", err)
+		}
 		savefiles.SaveSRAM()
+		// Like RetroArch, the core releases its GL resources in context_destroy
+		// before the game is unloaded
+		deinitHWRender()
 		state.Core.UnloadGame()
+		runahead.Deinit()
+		script.Stop()
+		if err := record.StopAudio(); err != nil {
+			log.Println("[Record]:", err)
+		}
+		if err := record.StopVideo(); err != nil {
+			log.Println("[Record]:", err)
+		}
+		cleanPatchCache()
+		cheats.Clear()
+		achievements.Unload()
+		content.path, content.data = "", nil
 		state.GamePath = ""
 		state.CoreRunning = false
 		vid.ResetPitch()
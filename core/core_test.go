@@ -263,3 +263,85 @@ func Test_coreLoadGameWithoutCore(t *testing.T) {
 		t.Fatalf("LoadGame() error = %v, want %v", err, "no core loaded")
 	}
 }
+
+func Test_patchToCache(t *testing.T) {
+	dir := t.TempDir()
+	patchCacheDir = filepath.Join(dir, "cache")
+	gamePath := filepath.Join(dir, "Game.iso")
+	os.WriteFile(gamePath, []byte("AAAAAAAA"), 0644)
+
+	t.Run("Returns an empty path when there is nothing to patch", func(t *testing.T) {
+		got, err := patchToCache(gamePath, gamePath)
+		if got != "" || err != nil {
+			t.Errorf("patchToCache() = %v, %v, want empty path", got, err)
+		}
+	})
+
+	t.Run("Writes the patched image to the cache and cleans it up", func(t *testing.T) {
+		ips := []byte("PATCH\x00\x00\x01\x00\x02BBEOF")
+		os.WriteFile(filepath.Join(dir, "Game.ips"), ips, 0644)
+
+		got, err := patchToCache(gamePath, gamePath)
+		if err != nil {
+			t.Fatalf("patchToCache() error = %v", err)
+		}
+		want := filepath.Join(patchCacheDir, "Game.iso")
+		if got != want {
+			t.Errorf("patchToCache() = %v, want %v", got, want)
+		}
+		bytes, _ := os.ReadFile(got)
+		if string(bytes) != "ABBAAAAA" {
+			t.Errorf("patched image = %s, want %s", bytes, "ABBAAAAA")
+		}
+
+		cleanPatchCache()
+		if _, err := os.Stat(got); !os.IsNotExist(err) {
+			t.Errorf("cleanPatchCache() left %v", got)
+		}
+	})
+
+	t.Run("Patches the data track of a cue sheet and keeps the other tracks", func(t *testing.T) {
+		cue := filepath.Join(dir, "Disc.cue")
+		os.WriteFile(cue, []byte("FILE \"Disc (Track 1).bin\" BINARY\n  TRACK 01 MODE1/2352\n    INDEX 01 00:00:00\n"+
+			"FILE \"Disc (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n    INDEX 01 00:00:00\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "Disc (Track 1).bin"), []byte("AAAAAAAA"), 0644)
+		os.WriteFile(filepath.Join(dir, "Disc (Track 2).bin"), []byte("MUSIC"), 0644)
+		os.WriteFile(filepath.Join(dir, "Disc.ips"), []byte("PATCH\x00\x00\x01\x00\x02BBEOF"), 0644)
+
+		got, err := patchToCache(cue, cue)
+		if err != nil {
+			t.Fatalf("patchToCache() error = %v", err)
+		}
+		want := filepath.Join(patchCacheDir, "Disc", "Disc.cue")
+		if got != want {
+			t.Errorf("patchToCache() = %v, want %v", got, want)
+		}
+		for name, content := range map[string]string{
+			"Disc (Track 1).bin": "ABBAAAAA",
+			"Disc (Track 2).bin": "MUSIC",
+		} {
+			bytes, _ := os.ReadFile(filepath.Join(filepath.Dir(got), name))
+			if string(bytes) != content {
+				t.Errorf("%s = %s, want %s", name, bytes, content)
+			}
+		}
+		original, _ := os.ReadFile(filepath.Join(dir, "Disc (Track 1).bin"))
+		if string(original) != "AAAAAAAA" {
+			t.Errorf("original track = %s, want %s", original, "AAAAAAAA")
+		}
+
+		cleanPatchCache()
+		if _, err := os.Stat(filepath.Dir(got)); !os.IsNotExist(err) {
+			t.Errorf("cleanPatchCache() left %v", filepath.Dir(got))
+		}
+	})
+
+	t.Run("Refuses other images made of several files", func(t *testing.T) {
+		m3u := filepath.Join(dir, "Disc.m3u")
+		os.WriteFile(m3u, []byte("Disc.cue\n"), 0644)
+		got, err := patchToCache(m3u, m3u)
+		if got != "" || err == nil {
+			t.Errorf("patchToCache() = %v, %v, want an error", got, err)
+		}
+	})
+}
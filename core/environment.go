@@ -9,6 +9,7 @@ import (
 
 	"github.com/libretro/ludo/libretro"
 	"github.com/libretro/ludo/options"
+	"github.com/libretro/ludo/pacing"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 )
@@ -129,6 +130,33 @@ func environmentSetCoreOptionsIntl(data unsafe.Pointer) bool {
 	return true
 }
 
+// supportedQuirks are the serialization quirks the frontend knows about
+const supportedQuirks = libretro.SerializationQuirkIncomplete |
+	libretro.SerializationQuirkMustInitialize |
+	libretro.SerializationQuirkCoreVariableSize |
+	libretro.SerializationQuirkFrontVariableSize |
+	libretro.SerializationQuirkSingleSession
+
+func environmentSetSerializationQuirks(data unsafe.Pointer) bool {
+	quirks := (*uint64)(data)
+	state.Core.SerializationQuirks = *quirks
+	// Report which quirks are supported by clearing the others
+	*quirks &= supportedQuirks
+	return true
+}
+
+func environmentGetAudioVideoEnable(data unsafe.Pointer) bool {
+	enable := uint(0)
+	if !state.SkipVideo {
+		enable |= 1 << 0
+	}
+	if pacing.Ratio() != 0 && !state.Rewinding && !state.Replaying {
+		enable |= 1 << 1
+	}
+	libretro.SetUint(data, enable)
+	return true
+}
+
 func environment(cmd uint32, data unsafe.Pointer) bool {
 	switch cmd {
 	case libretro.EnvironmentSetRotation:
@@ -168,11 +196,19 @@ func environment(cmd uint32, data unsafe.Pointer) bool {
 		Options.Updated = false
 	case libretro.EnvironmentSetMemoryMaps:
 		state.Core.MemoryMap = libretro.GetMemoryMap(data)
+	case libretro.EnvironmentSetSupportAchievements:
+		state.Core.SupportsAchievements = *(*bool)(data)
+	case libretro.EnvironmentSetSerializationQuirks:
+		return environmentSetSerializationQuirks(data)
+	case libretro.EnvironmentGetAudioVideoEnable:
+		return environmentGetAudioVideoEnable(data)
 	case libretro.EnvironmentSetGeometry:
-		vid.Geom = libretro.GetGeometry(data)
+		vid.SetGeometry(libretro.GetGeometry(data))
 	case libretro.EnvironmentSetSystemAVInfo:
 		avi := libretro.GetSystemAVInfo(data)
-		vid.Geom = avi.Geometry
+		vid.SetGeometry(avi.Geometry)
+		resizeHWRender(avi.Geometry)
+		pacing.Init(avi.Timing.FPS)
 	case libretro.EnvironmentGetFastforwarding:
 		libretro.SetBool(data, state.FastForward)
 	case libretro.EnvironmentGetLanguage:
@@ -181,6 +217,10 @@ func environment(cmd uint32, data unsafe.Pointer) bool {
 		libretro.SetUint(data, 0)
 	case libretro.EnvironmentSetDiskControlInterface:
 		state.Core.SetDiskControlCallback(data)
+	case libretro.EnvironmentSetHWRender:
+		return environmentSetHWRender(data)
+	case libretro.EnvironmentGetPrefferedHWRender:
+		return environmentGetPreferredHWRender(data)
 	default:
 		//log.Println("[Env]: Not implemented:", cmd)
 		return false
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"log"
+	"unsafe"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/video"
+)
+
+// environmentSetHWRender creates the OpenGL context requested by a core. Only
+// the OpenGL renderer supports it.
+func environmentSetHWRender(data unsafe.Pointer) bool {
+	v, ok := vid.(*video.Video)
+	if !ok {
+		return false
+	}
+	state.Core.SetHWRenderCallback(data, v.HWFramebuffer, v.HWProcAddress)
+	if err := v.InitHWRender(state.Core.HWRenderCallback); err != nil {
+		log.Println("[Video]:", err)
+		state.Core.HWRenderCallback = nil
+		return false
+	}
+	return true
+}
+
+func environmentGetPreferredHWRender(data unsafe.Pointer) bool {
+	if _, ok := vid.(*video.Video); !ok {
+		return false
+	}
+	libretro.SetUint(data, uint(libretro.HWContextOpenGL))
+	return true
+}
+
+// initHWRender creates the framebuffer of a core rendering with OpenGL, once
+// the game is loaded, and lets the core set up its resources
+func initHWRender(geom libretro.GameGeometry) error {
+	hw := state.Core.HWRenderCallback
+	v, ok := vid.(*video.Video)
+	if hw == nil || !ok {
+		return nil
+	}
+	var err error
+	state.Core.Do(func() {
+		err = v.CreateHWFramebuffer(geom.MaxWidth, geom.MaxHeight)
+	})
+	if err != nil {
+		state.Core.Do(v.DestroyHWFramebuffer)
+		v.DeinitHWRender()
+		state.Core.HWRenderCallback = nil
+		return err
+	}
+	hw.ContextReset()
+	return nil
+}
+
+// deinitHWRender lets the core release its resources and destroys the context
+func deinitHWRender() {
+	hw := state.Core.HWRenderCallback
+	v, ok := vid.(*video.Video)
+	if hw == nil || !ok {
+		return
+	}
+	hw.ContextDestroy()
+	state.Core.Do(v.DestroyHWFramebuffer)
+	v.DeinitHWRender()
+	state.Core.HWRenderCallback = nil
+}
+
+// resizeHWRender grows the framebuffer when the core changes its geometry. The
+// environment callback runs in the thread of the core.
+func resizeHWRender(geom libretro.GameGeometry) {
+	if v, ok := vid.(*video.Video); ok && state.Core.HWRenderCallback != nil {
+		v.ResizeHWFramebuffer(geom.MaxWidth, geom.MaxHeight)
+	}
+}
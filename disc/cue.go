@@ -0,0 +1,150 @@
+// Package disc reads CD images described by cue sheets. It is used to patch
+// the data track of a game and to hash discs for the achievements.
+package disc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Track is a track of a cue sheet
+type Track struct {
+	Number     int
+	Mode       string // AUDIO, MODE1/2352, MODE2/2352...
+	File       string // path of the file holding the track
+	SectorSize int
+	Offset     int64 // offset of the track in its file, from INDEX 01
+}
+
+// Data returns true for tracks holding data instead of audio
+func (t Track) Data() bool {
+	return t.Mode != "AUDIO"
+}
+
+// ReadCue parses a cue sheet. The paths of the files are resolved relative to
+// the cue sheet.
+func ReadCue(path string) ([]Track, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var tracks []Track
+	file := ""
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		fields := cueFields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "FILE":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%s: invalid FILE", filepath.Base(path))
+			}
+			file = fields[1]
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+		case "TRACK":
+			if len(fields) < 3 || file == "" {
+				return nil, fmt.Errorf("%s: invalid TRACK", filepath.Base(path))
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid TRACK", filepath.Base(path))
+			}
+			mode := strings.ToUpper(fields[2])
+			tracks = append(tracks, Track{
+				Number:     n,
+				Mode:       mode,
+				File:       file,
+				SectorSize: sectorSize(mode),
+			})
+		case "INDEX":
+			if len(fields) < 3 || len(tracks) == 0 || fields[1] != "01" {
+				continue
+			}
+			t := &tracks[len(tracks)-1]
+			t.Offset = int64(msfToSector(fields[2])) * int64(t.SectorSize)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, errors.New(filepath.Base(path) + ": no track")
+	}
+	return tracks, nil
+}
+
+// Files returns the files referenced by tracks, without duplicates
+func Files(tracks []Track) []string {
+	var files []string
+	for _, t := range tracks {
+		if len(files) == 0 || files[len(files)-1] != t.File {
+			files = append(files, t.File)
+		}
+	}
+	return files
+}
+
+// FirstDataTrack returns the first data track of a disc
+func FirstDataTrack(tracks []Track) (Track, bool) {
+	for _, t := range tracks {
+		if t.Data() {
+			return t, true
+		}
+	}
+	return Track{}, false
+}
+
+// cueFields splits a line of cue sheet in words, keeping quoted words whole
+func cueFields(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				fields = append(fields, line[1:])
+				break
+			}
+			fields = append(fields, line[1:end+1])
+			line = strings.TrimSpace(line[end+2:])
+			continue
+		}
+		word, rest, _ := strings.Cut(line, " ")
+		fields = append(fields, word)
+		line = strings.TrimSpace(rest)
+	}
+	return fields
+}
+
+// sectorSize returns the size of the sectors of a track mode
+func sectorSize(mode string) int {
+	if _, size, ok := strings.Cut(mode, "/"); ok {
+		if n, err := strconv.Atoi(size); err == nil {
+			return n
+		}
+	}
+	return 2352
+}
+
+// msfToSector converts a minutes:seconds:frames position to a sector
+func msfToSector(msf string) int {
+	parts := strings.Split(msf, ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	m, _ := strconv.Atoi(parts[0])
+	s, _ := strconv.Atoi(parts[1])
+	f, _ := strconv.Atoi(parts[2])
+	return (m*60+s)*75 + f
+}
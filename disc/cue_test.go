@@ -0,0 +1,70 @@
+package disc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadCue(t *testing.T) {
+	dir := t.TempDir()
+	cue := filepath.Join(dir, "Game.cue")
+	os.WriteFile(cue, []byte(`FILE "Game (Track 1).bin" BINARY
+  TRACK 01 MODE2/2352
+    INDEX 01 00:00:00
+FILE "Game (Track 2).bin" BINARY
+  TRACK 02 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:02:00
+  TRACK 03 AUDIO
+    INDEX 01 01:00:00
+`), 0644)
+
+	t.Run("Reads the tracks and their files", func(t *testing.T) {
+		got, err := ReadCue(cue)
+		if err != nil {
+			t.Fatalf("ReadCue() error = %v", err)
+		}
+		track1 := filepath.Join(dir, "Game (Track 1).bin")
+		track2 := filepath.Join(dir, "Game (Track 2).bin")
+		want := []Track{
+			{Number: 1, Mode: "MODE2/2352", File: track1, SectorSize: 2352},
+			{Number: 2, Mode: "AUDIO", File: track2, SectorSize: 2352, Offset: 150 * 2352},
+			{Number: 3, Mode: "AUDIO", File: track2, SectorSize: 2352, Offset: 4500 * 2352},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadCue() = %v, want %v", got, want)
+		}
+		if files := Files(got); !reflect.DeepEqual(files, []string{track1, track2}) {
+			t.Errorf("Files() = %v, want %v", files, []string{track1, track2})
+		}
+		if data, ok := FirstDataTrack(got); !ok || data.Number != 1 {
+			t.Errorf("FirstDataTrack() = %v, %v, want track 1", data, ok)
+		}
+	})
+
+	t.Run("Rejects a cue sheet without track", func(t *testing.T) {
+		empty := filepath.Join(dir, "Empty.cue")
+		os.WriteFile(empty, []byte("REM nothing\n"), 0644)
+		if _, err := ReadCue(empty); err == nil {
+			t.Errorf("ReadCue() error = nil, want an error")
+		}
+	})
+}
+
+func Test_cueFields(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`FILE "My Game.bin" BINARY`, []string{"FILE", "My Game.bin", "BINARY"}},
+		{`  TRACK 01   AUDIO`, []string{"TRACK", "01", "AUDIO"}},
+		{``, nil},
+	}
+	for _, tt := range tests {
+		if got := cueFields(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("cueFields(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package disc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SectorSize is the size of the user data of a data sector
+const SectorSize = 2048
+
+// Reader reads the sectors of a data track
+type Reader struct {
+	track Track
+	fd    *os.File
+}
+
+// Open opens a data track for reading
+func Open(t Track) (*Reader, error) {
+	if !t.Data() {
+		return nil, fmt.Errorf("track %d is not a data track", t.Number)
+	}
+	fd, err := os.Open(t.File)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{track: t, fd: fd}, nil
+}
+
+// Close closes the file of the track
+func (r *Reader) Close() error {
+	return r.fd.Close()
+}
+
+// ReadSector reads the user data of a sector, numbered from the start of the
+// track. Raw sectors have a header before their data, its size depends on the
+// mode of the sector.
+func (r *Reader) ReadSector(n int) ([]byte, error) {
+	size := int64(r.track.SectorSize)
+	raw := make([]byte, size)
+	if _, err := r.fd.ReadAt(raw, r.track.Offset+int64(n)*size); err != nil {
+		return nil, err
+	}
+	switch size {
+	case 2352:
+		if raw[15] == 2 {
+			return raw[24 : 24+SectorSize], nil
+		}
+		return raw[16 : 16+SectorSize], nil
+	case 2336:
+		return raw[8 : 8+SectorSize], nil
+	case SectorSize:
+		return raw, nil
+	}
+	return nil, fmt.Errorf("unsupported sector size %d", size)
+}
+
+// ReadFile reads size bytes starting at a sector
+func (r *Reader) ReadFile(sector, size int) ([]byte, error) {
+	var data []byte
+	for size > 0 {
+		b, err := r.ReadSector(sector)
+		if err != nil {
+			return nil, err
+		}
+		if size < len(b) {
+			b = b[:size]
+		}
+		data = append(data, b...)
+		size -= len(b)
+		sector++
+	}
+	return data, nil
+}
+
+// FindFile looks for a file of the ISO 9660 file system of the track. The
+// directories of the path are separated by slashes or backslashes, the names
+// are not case sensitive. It returns the first sector and the size of the
+// file.
+func (r *Reader) FindFile(path string) (sector, size int, err error) {
+	pvd, err := r.ReadSector(16)
+	if err != nil {
+		return 0, 0, err
+	}
+	if string(pvd[1:6]) != "CD001" {
+		return 0, 0, errors.New("no ISO 9660 file system")
+	}
+	// The root directory record
+	sector = int(binary.LittleEndian.Uint32(pvd[158:]))
+	size = int(binary.LittleEndian.Uint32(pvd[166:]))
+
+	names := strings.FieldsFunc(path, func(c rune) bool { return c == '/' || c == '\\' })
+	for _, name := range names {
+		sector, size, err = r.findEntry(sector, size, name)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return sector, size, nil
+}
+
+// findEntry looks for an entry of a directory
+func (r *Reader) findEntry(dirSector, dirSize int, name string) (int, int, error) {
+	for i := 0; i*SectorSize < dirSize; i++ {
+		b, err := r.ReadSector(dirSector + i)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return 0, 0, err
+		}
+		// Records don't cross sectors, a zero length pads the end
+		for off := 0; off+33 < len(b) && b[off] != 0; off += int(b[off]) {
+			n := int(b[off+32])
+			if off+33+n > len(b) {
+				break
+			}
+			entry, _, _ := strings.Cut(string(b[off+33:off+33+n]), ";")
+			if strings.EqualFold(entry, name) {
+				return int(binary.LittleEndian.Uint32(b[off+2:])), int(binary.LittleEndian.Uint32(b[off+10:])), nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("%s: file not found", name)
+}
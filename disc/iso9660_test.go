@@ -0,0 +1,90 @@
+package disc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeISO builds an ISO 9660 image with files in its root directory, in
+// sectors of 2048 bytes or raw mode 2 sectors of 2352 bytes
+func makeISO(sectorSize int, files []string, contents [][]byte) []byte {
+	sectors := make([][]byte, 20)
+	for i := range sectors {
+		sectors[i] = make([]byte, SectorSize)
+	}
+	pvd := sectors[16]
+	pvd[0] = 1
+	copy(pvd[1:], "CD001")
+	binary.LittleEndian.PutUint32(pvd[158:], 18)
+	binary.LittleEndian.PutUint32(pvd[166:], SectorSize)
+
+	dir := sectors[18]
+	off := 0
+	next := 20
+	for i, name := range files {
+		name += ";1"
+		length := 33 + len(name) + (33+len(name))%2
+		dir[off] = byte(length)
+		binary.LittleEndian.PutUint32(dir[off+2:], uint32(next))
+		binary.LittleEndian.PutUint32(dir[off+10:], uint32(len(contents[i])))
+		dir[off+32] = byte(len(name))
+		copy(dir[off+33:], name)
+		off += length
+		for start := 0; start < len(contents[i]) || start == 0; start += SectorSize {
+			s := make([]byte, SectorSize)
+			copy(s, contents[i][start:])
+			sectors = append(sectors, s)
+			next++
+		}
+	}
+
+	image := []byte{}
+	for _, s := range sectors {
+		if sectorSize == 2352 {
+			header := make([]byte, 24)
+			header[15] = 2
+			image = append(image, header...)
+			image = append(image, s...)
+			image = append(image, make([]byte, 2352-24-SectorSize)...)
+		} else {
+			image = append(image, s...)
+		}
+	}
+	return image
+}
+
+func TestReader(t *testing.T) {
+	dir := t.TempDir()
+	big := bytes.Repeat([]byte("0123456789abcdef"), 200)
+
+	for _, size := range []int{2048, 2352} {
+		t.Run(fmt.Sprintf("Finds and reads files in sectors of %d bytes", size), func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("%d.bin", size))
+			os.WriteFile(path, makeISO(size, []string{"SYSTEM.CNF", "MAIN.EXE"}, [][]byte{[]byte("BOOT = cdrom:\\MAIN.EXE;1\n"), big}), 0644)
+			r, err := Open(Track{Number: 1, Mode: fmt.Sprintf("MODE2/%d", size), File: path, SectorSize: size})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			sector, n, err := r.FindFile("\\main.exe")
+			if err != nil {
+				t.Fatalf("FindFile() error = %v", err)
+			}
+			got, err := r.ReadFile(sector, n)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if !bytes.Equal(got, big) {
+				t.Errorf("ReadFile() = %d bytes, want %d bytes", len(got), len(big))
+			}
+			if _, _, err := r.FindFile("MISSING.EXE"); err == nil {
+				t.Errorf("FindFile() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package dsp processes the audio of the cores. It is pure Go and doesn't
+// depend on the audio device, so that it can be tested on synthetic signals.
+// Audio is handled as interleaved stereo frames of float32 samples between -1
+// and 1.
+package dsp
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// FromInt16 appends the stereo 16 bits little endian PCM of buf to dst as
+// float samples
+func FromInt16(dst []float32, buf []byte) []float32 {
+	for i := 0; i+1 < len(buf); i += 2 {
+		s := int16(binary.LittleEndian.Uint16(buf[i:]))
+		dst = append(dst, float32(s)/32768)
+	}
+	return dst
+}
+
+// ToInt16 appends the float samples of src to dst as 16 bits little endian
+// PCM. Samples out of range are clipped.
+func ToInt16(dst []byte, src []float32) []byte {
+	for _, s := range src {
+		v := math.Round(float64(s) * 32768)
+		if v > math.MaxInt16 {
+			v = math.MaxInt16
+		}
+		if v < math.MinInt16 {
+			v = math.MinInt16
+		}
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(int16(v)))
+	}
+	return dst
+}
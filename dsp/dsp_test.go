@@ -0,0 +1,22 @@
+package dsp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_ToInt16(t *testing.T) {
+	t.Run("Round trips with FromInt16", func(t *testing.T) {
+		in := []byte{0x00, 0x00, 0xFF, 0x7F, 0x00, 0x80, 0x34, 0x12}
+		if got := ToInt16(nil, FromInt16(nil, in)); !bytes.Equal(got, in) {
+			t.Errorf("ToInt16() = %v, want %v", got, in)
+		}
+	})
+
+	t.Run("Clips samples out of range", func(t *testing.T) {
+		want := []byte{0xFF, 0x7F, 0x00, 0x80}
+		if got := ToInt16(nil, []float32{2, -2}); !bytes.Equal(got, want) {
+			t.Errorf("ToInt16() = %v, want %v", got, want)
+		}
+	})
+}
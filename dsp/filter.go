@@ -0,0 +1,93 @@
+package dsp
+
+import "math"
+
+// Filter processes interleaved stereo frames in place. Filters keep their
+// state between calls, so that a stream can be processed batch by batch.
+type Filter interface {
+	Process(buf []float32)
+}
+
+// Chain is a list of filters applied in order
+type Chain []Filter
+
+// Process applies the filters of the chain to buf
+func (c Chain) Process(buf []float32) {
+	for _, f := range c {
+		f.Process(buf)
+	}
+}
+
+// Biquad is a second order IIR filter, designed with the formulas of the
+// Audio EQ Cookbook by Robert Bristow-Johnson
+type Biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z                  [2][2]float64 // state of each channel
+}
+
+// newBiquad normalizes the coefficients of a biquad by a0
+func newBiquad(b0, b1, b2, a0, a1, a2 float64) *Biquad {
+	return &Biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+// omega returns the normalized angular frequency of freq, kept under the
+// Nyquist frequency
+func omega(rate, freq float64) float64 {
+	if freq > rate*0.49 {
+		freq = rate * 0.49
+	}
+	return 2 * math.Pi * freq / rate
+}
+
+// NewLowPass returns a low-pass filter, which softens the high frequencies
+// above cutoff
+func NewLowPass(rate, cutoff float64) *Biquad {
+	w := omega(rate, cutoff)
+	// A Q of 1/√2 gives a flat pass band
+	cos, alpha := math.Cos(w), math.Sin(w)/math.Sqrt2
+	return newBiquad((1-cos)/2, 1-cos, (1-cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+// NewPeaking returns a band of parametric EQ, which boosts or cuts the
+// frequencies around freq by gain dB. The higher q, the narrower the band.
+func NewPeaking(rate, freq, q, gain float64) *Biquad {
+	w := omega(rate, freq)
+	a := math.Pow(10, gain/40)
+	cos, alpha := math.Cos(w), math.Sin(w)/(2*q)
+	return newBiquad(1+alpha*a, -2*cos, 1-alpha*a, 1+alpha/a, -2*cos, 1-alpha/a)
+}
+
+// bassFrequency is the corner frequency of the bass boost
+const bassFrequency = 120
+
+// NewBassBoost returns a low shelf filter, which boosts the frequencies below
+// 120 Hz by gain dB
+func NewBassBoost(rate, gain float64) *Biquad {
+	w := omega(rate, bassFrequency)
+	a := math.Pow(10, gain/40)
+	// A slope of 1 is the steepest without overshoot
+	cos, alpha := math.Cos(w), math.Sin(w)/2*math.Sqrt2
+	sq := 2 * math.Sqrt(a) * alpha
+	return newBiquad(
+		a*((a+1)-(a-1)*cos+sq),
+		2*a*((a-1)-(a+1)*cos),
+		a*((a+1)-(a-1)*cos-sq),
+		(a+1)+(a-1)*cos+sq,
+		-2*((a-1)+(a+1)*cos),
+		(a+1)+(a-1)*cos-sq,
+	)
+}
+
+// Process filters buf
+func (b *Biquad) Process(buf []float32) {
+	for i := 0; i+1 < len(buf); i += 2 {
+		for c := 0; c < 2; c++ {
+			z := &b.z[c]
+			x := float64(buf[i+c])
+			y := b.b0*x + z[0]
+			z[0] = b.b1*x - b.a1*y + z[1]
+			z[1] = b.b2*x - b.a2*y
+			buf[i+c] = float32(y)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// gain returns the gain in dB of a filter on a sine of frequency f, measured
+// once the filter settled
+func gain(f Filter, freq float64) float64 {
+	in := sine(48000, freq, 48000)
+	out := append([]float32{}, in...)
+	f.Process(out)
+	return 20 * math.Log10(rms(out[len(out)/2:])/rms(in[len(in)/2:]))
+}
+
+func rms(buf []float32) float64 {
+	sum := 0.0
+	for _, s := range buf {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(buf)))
+}
+
+func Test_Biquad(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter func() Filter
+		freq   float64
+		want   float64 // dB
+	}{
+		{"Low-pass passes low frequencies", func() Filter { return NewLowPass(48000, 2000) }, 200, 0},
+		{"Low-pass is at -3 dB at the cutoff", func() Filter { return NewLowPass(48000, 2000) }, 2000, -3},
+		{"EQ boosts its band", func() Filter { return NewPeaking(48000, 1000, 1, 6) }, 1000, 6},
+		{"EQ cuts its band", func() Filter { return NewPeaking(48000, 1000, 1, -6) }, 1000, -6},
+		{"EQ leaves other bands", func() Filter { return NewPeaking(48000, 1000, 1, 6) }, 15000, 0},
+		{"Bass boost boosts the bass", func() Filter { return NewBassBoost(48000, 9) }, 30, 9},
+		{"Bass boost leaves the treble", func() Filter { return NewBassBoost(48000, 9) }, 5000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gain(tt.filter(), tt.freq); math.Abs(got-tt.want) > 0.5 {
+				t.Errorf("gain(%v Hz) = %.2f dB, want %.2f dB", tt.freq, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Low-pass cuts high frequencies", func(t *testing.T) {
+		if got := gain(NewLowPass(48000, 2000), 16000); got > -30 {
+			t.Errorf("gain(16000 Hz) = %.2f dB, want less than -30 dB", got)
+		}
+	})
+
+	t.Run("Filters a stream batch by batch", func(t *testing.T) {
+		in := sine(1000, 440, 48000)
+		whole := append([]float32{}, in...)
+		NewLowPass(48000, 1000).Process(whole)
+		batched := append([]float32{}, in...)
+		f := NewLowPass(48000, 1000)
+		for i := 0; i < len(batched); i += 100 {
+			f.Process(batched[i : i+100])
+		}
+		for i := range whole {
+			if whole[i] != batched[i] {
+				t.Fatalf("Process() sample %d = %v, want %v", i, batched[i], whole[i])
+			}
+		}
+	})
+}
+
+func Test_Chain(t *testing.T) {
+	t.Run("Leaves the audio untouched when empty", func(t *testing.T) {
+		if got := gain(Chain{}, 440); math.Abs(got) > 1e-6 {
+			t.Errorf("gain() = %v dB, want 0 dB", got)
+		}
+	})
+
+	t.Run("Applies the filters in order", func(t *testing.T) {
+		c := Chain{NewPeaking(48000, 1000, 1, 6), NewPeaking(48000, 1000, 1, 3)}
+		if got := gain(c, 1000); math.Abs(got-9) > 0.5 {
+			t.Errorf("gain() = %.2f dB, want %.2f dB", got, 9.0)
+		}
+	})
+}
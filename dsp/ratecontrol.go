@@ -0,0 +1,25 @@
+package dsp
+
+// RateControl adjusts the resampling ratio to the fill level of the output
+// buffer. The core and the display never run at exactly the rates they
+// report, so without adjustment the buffer slowly drains, which crackles, or
+// overflows, which adds latency and blocks the emulation. Producing slightly
+// more frames when the buffer is under half full and fewer when it is over
+// keeps it around half full.
+type RateControl struct {
+	// MaxDelta is the maximum relative change of the ratio. It must be small
+	// enough for the pitch change to be inaudible, 0.005 is a good default.
+	MaxDelta float64
+}
+
+// Ratio returns ratio adjusted for a buffer filled at fill, from 0 when empty
+// to 1 when full
+func (c RateControl) Ratio(ratio, fill float64) float64 {
+	if fill < 0 {
+		fill = 0
+	}
+	if fill > 1 {
+		fill = 1
+	}
+	return ratio * (1 + c.MaxDelta*(1-2*fill))
+}
@@ -0,0 +1,42 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_RateControl_Ratio(t *testing.T) {
+	c := RateControl{MaxDelta: 0.005}
+	tests := []struct {
+		name string
+		fill float64
+		want float64
+	}{
+		{"Produce more frames when the buffer is empty", 0, 1.005},
+		{"Keep the ratio when the buffer is half full", 0.5, 1},
+		{"Produce fewer frames when the buffer is full", 1, 0.995},
+		{"Clamp the fill level", 2, 0.995},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Ratio(1, tt.fill); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Ratio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Converges to half full", func(t *testing.T) {
+		// The consumer reads 0.3% faster than the nominal rate, which the
+		// controller must compensate
+		const size = 4096.0
+		fill := 0.0
+		for i := 0; i < 10000; i++ {
+			fill += 100 * c.Ratio(1, fill/size)
+			fill -= 100 * 1.003
+			fill = math.Max(0, math.Min(size, fill))
+		}
+		if fill < size*0.1 || fill > size*0.9 {
+			t.Errorf("fill = %v, want between %v and %v", fill, size*0.1, size*0.9)
+		}
+	})
+}
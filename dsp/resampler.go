@@ -0,0 +1,46 @@
+package dsp
+
+// Resampler converts stereo audio from a sample rate to another with cubic
+// Hermite interpolation. It keeps the last input frames between calls, so a
+// stream can be resampled batch by batch, and the ratio can change from one
+// batch to the next.
+type Resampler struct {
+	hist [4][2]float32 // last four input frames
+	pos  float64       // position of the next output frame between hist[1] and hist[2]
+}
+
+// Reset clears the history of the resampler
+func (r *Resampler) Reset() {
+	*r = Resampler{}
+}
+
+// hermite interpolates between y1 and y2 at t, from 0 to 1
+func hermite(y0, y1, y2, y3, t float32) float32 {
+	c0 := y1
+	c1 := (y2 - y0) / 2
+	c2 := y0 - 2.5*y1 + 2*y2 - y3/2
+	c3 := (y3-y0)/2 + 1.5*(y1-y2)
+	return ((c3*t+c2)*t+c1)*t + c0
+}
+
+// Process resamples the interleaved stereo frames of in and appends them to
+// out. Ratio is the output rate divided by the input rate: a ratio of 2
+// doubles the number of frames.
+func (r *Resampler) Process(out, in []float32, ratio float64) []float32 {
+	if ratio <= 0 {
+		return out
+	}
+	step := 1 / ratio
+	for i := 0; i+1 < len(in); i += 2 {
+		r.hist[0], r.hist[1], r.hist[2] = r.hist[1], r.hist[2], r.hist[3]
+		r.hist[3] = [2]float32{in[i], in[i+1]}
+		for ; r.pos < 1; r.pos += step {
+			t := float32(r.pos)
+			for c := 0; c < 2; c++ {
+				out = append(out, hermite(r.hist[0][c], r.hist[1][c], r.hist[2][c], r.hist[3][c], t))
+			}
+		}
+		r.pos--
+	}
+	return out
+}
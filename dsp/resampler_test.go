@@ -0,0 +1,90 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// sine returns n stereo frames of a sine wave of frequency f at rate
+func sine(n int, f, rate float64) []float32 {
+	buf := make([]float32, 0, n*2)
+	for i := 0; i < n; i++ {
+		v := float32(0.5 * math.Sin(2*math.Pi*f*float64(i)/rate))
+		buf = append(buf, v, v)
+	}
+	return buf
+}
+
+func Test_Resampler_Process(t *testing.T) {
+	t.Run("Produces the number of frames of the ratio", func(t *testing.T) {
+		tests := []struct {
+			ratio float64
+			want  int
+		}{
+			{1, 1000},
+			{1.5, 1500},
+			{0.5, 500},
+			{48000.0 / 32040.5, 1498},
+		}
+		for _, tt := range tests {
+			var r Resampler
+			got := len(r.Process(nil, sine(1000, 440, 32000), tt.ratio)) / 2
+			if got < tt.want-1 || got > tt.want+1 {
+				t.Errorf("Process(%v) = %d frames, want %d", tt.ratio, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Preserves the waveform of a sine", func(t *testing.T) {
+		var r Resampler
+		ratio := 48000.0 / 32000
+		out := r.Process(nil, sine(3200, 440, 32000), ratio)
+		for j := 0; j < len(out)/2; j++ {
+			// The resampler has a latency of two input frames
+			pos := float64(j)/ratio - 2
+			if pos < 2 {
+				continue
+			}
+			want := 0.5 * math.Sin(2*math.Pi*440*pos/32000)
+			if math.Abs(float64(out[j*2])-want) > 1e-3 || out[j*2] != out[j*2+1] {
+				t.Fatalf("Process() frame %d = %v, want %v", j, out[j*2], want)
+			}
+		}
+	})
+
+	t.Run("Resamples a stream batch by batch", func(t *testing.T) {
+		in := sine(1000, 440, 32000)
+		var whole, batched Resampler
+		want := whole.Process(nil, in, 1.5)
+		got := []float32{}
+		for i := 0; i < len(in); i += 128 {
+			end := i + 128
+			if end > len(in) {
+				end = len(in)
+			}
+			got = batched.Process(got, in[i:end], 1.5)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Process() = %d samples, want %d", len(got), len(want))
+		}
+		for i := range got {
+			if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+				t.Fatalf("Process() sample %d = %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Keeps a constant signal constant", func(t *testing.T) {
+		var r Resampler
+		in := make([]float32, 200)
+		for i := range in {
+			in[i] = 0.25
+		}
+		out := r.Process(nil, in, 0.7)
+		for i := 8; i < len(out); i++ {
+			if math.Abs(float64(out[i])-0.25) > 1e-6 {
+				t.Fatalf("Process() sample %d = %v, want %v", i, out[i], 0.25)
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package dsp
+
+// Delays of the Freeverb algorithm by Jezar at Dreampoint, in frames at 44100
+// Hz. The right channel uses slightly longer delays for a stereo image.
+var (
+	combDelays    = []int{1116, 1188, 1277, 1356}
+	allpassDelays = []int{556, 441}
+)
+
+const (
+	stereoSpread    = 23
+	combFeedback    = 0.84
+	combDamping     = 0.2
+	allpassFeedback = 0.5
+	reverbGain      = 0.03 // keeps the sum of the combs in range
+)
+
+// comb is a feedback comb filter with a low-pass in the loop, which damps the
+// high frequencies of the echoes
+type comb struct {
+	buf   []float32
+	pos   int
+	store float32
+}
+
+func (c *comb) process(x float32) float32 {
+	y := c.buf[c.pos]
+	c.store = y*(1-combDamping) + c.store*combDamping
+	c.buf[c.pos] = x + c.store*combFeedback
+	c.pos = (c.pos + 1) % len(c.buf)
+	return y
+}
+
+// allpass diffuses the echoes without coloring them
+type allpass struct {
+	buf []float32
+	pos int
+}
+
+func (a *allpass) process(x float32) float32 {
+	b := a.buf[a.pos]
+	a.buf[a.pos] = x + b*allpassFeedback
+	a.pos = (a.pos + 1) % len(a.buf)
+	return b - x
+}
+
+// Reverb simulates a room with parallel comb filters followed by allpass
+// filters, like Freeverb
+type Reverb struct {
+	mix       float32
+	combs     [2][]comb
+	allpasses [2][]allpass
+}
+
+// NewReverb returns a reverb mixing a proportion mix, from 0 to 1, of the
+// reverberated signal with the dry signal
+func NewReverb(rate, mix float64) *Reverb {
+	r := &Reverb{mix: float32(mix)}
+	scale := func(d, c int) int {
+		return int(float64(d+c*stereoSpread) * rate / 44100)
+	}
+	for c := 0; c < 2; c++ {
+		for _, d := range combDelays {
+			r.combs[c] = append(r.combs[c], comb{buf: make([]float32, scale(d, c))})
+		}
+		for _, d := range allpassDelays {
+			r.allpasses[c] = append(r.allpasses[c], allpass{buf: make([]float32, scale(d, c))})
+		}
+	}
+	return r
+}
+
+// Process adds reverberation to buf
+func (r *Reverb) Process(buf []float32) {
+	for i := 0; i+1 < len(buf); i += 2 {
+		// Both channels feed the room, which gives it some width
+		in := (buf[i] + buf[i+1]) * reverbGain
+		for c := 0; c < 2; c++ {
+			var wet float32
+			for j := range r.combs[c] {
+				wet += r.combs[c][j].process(in)
+			}
+			for j := range r.allpasses[c] {
+				wet = r.allpasses[c][j].process(wet)
+			}
+			buf[i+c] = buf[i+c]*(1-r.mix) + wet*r.mix
+		}
+	}
+}
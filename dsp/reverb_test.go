@@ -0,0 +1,60 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Reverb(t *testing.T) {
+	impulse := func(n int) []float32 {
+		buf := make([]float32, n*2)
+		buf[0], buf[1] = 1, 1
+		return buf
+	}
+
+	t.Run("Passes the dry signal without mix", func(t *testing.T) {
+		buf := sine(1000, 440, 48000)
+		want := append([]float32{}, buf...)
+		NewReverb(48000, 0).Process(buf)
+		for i := range buf {
+			if buf[i] != want[i] {
+				t.Fatalf("Process() sample %d = %v, want %v", i, buf[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Echoes an impulse after the shortest delay", func(t *testing.T) {
+		buf := impulse(48000)
+		NewReverb(48000, 0.5).Process(buf)
+		first := int(float64(combDelays[0])*48000/44100) * 2
+		if e := rms(buf[2:first]); e != 0 {
+			t.Errorf("energy before the first echo = %v, want 0", e)
+		}
+		if e := rms(buf[first:]); e == 0 {
+			t.Errorf("energy of the tail = %v, want more than 0", e)
+		}
+	})
+
+	t.Run("Decays", func(t *testing.T) {
+		buf := impulse(48000 * 4)
+		NewReverb(48000, 1).Process(buf)
+		early := rms(buf[:48000])
+		late := rms(buf[len(buf)-48000:])
+		if late > early/100 {
+			t.Errorf("late energy = %v, want less than %v", late, early/100)
+		}
+	})
+
+	t.Run("Stays in range with a loud input", func(t *testing.T) {
+		buf := make([]float32, 48000*2)
+		for i := range buf {
+			buf[i] = float32(math.Copysign(1, math.Sin(float64(i/2)/10)))
+		}
+		NewReverb(48000, 1).Process(buf)
+		for i, s := range buf {
+			if s > 2 || s < -2 {
+				t.Fatalf("Process() sample %d = %v, want between -2 and 2", i, s)
+			}
+		}
+	})
+}
@@ -13,6 +13,7 @@ require (
 	github.com/pelletier/go-toml v1.9.5
 	github.com/tanema/gween v0.0.0-20250522035225-e874ee3ae01a
 	github.com/youpy/go-wav v0.3.2
+	github.com/yuin/gopher-lua v1.1.2
 	golang.org/x/image v0.39.0
 	golang.org/x/mobile v0.0.0-20260410095206-2cfb76559b7b
 )
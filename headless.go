@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"unsafe"
+
+	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/movie"
+	"github.com/libretro/ludo/record"
+	"github.com/libretro/ludo/script"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/video"
+)
+
+// headless holds the options of the headless mode
+var headless struct {
+	frames     int
+	screenshot string
+	ram        string
+	movie      string
+	record     string
+	render     string
+}
+
+// runHeadless runs a game for a number of frames, or until the script ends,
+// without window, audio or input devices. It can record the run, and then
+// writes the last frame and the system RAM if requested. The video is drawn
+// by the software renderer, so it is meant for automated testing of cores on
+// machines without a GPU.
+func runHeadless(gamePath string) error {
+	if state.CorePath == "" || gamePath == "" {
+		return errors.New("a core and a game are required")
+	}
+
+	vid := video.NewSoftware(384*2, 240*2)
+	core.Init(vid)
+	script.Init(vid)
+	record.Init(vid)
+
+	if err := core.Load(state.CorePath); err != nil {
+		return err
+	}
+	defer core.Unload()
+
+	if err := core.LoadGame(gamePath); err != nil {
+		return err
+	}
+
+	if headless.movie != "" {
+		if err := movie.Play(headless.movie); err != nil {
+			return err
+		}
+	}
+
+	if scriptPath != "" {
+		if err := script.Load(scriptPath); err != nil {
+			return err
+		}
+	}
+
+	if headless.record != "" {
+		if err := record.StartVideo(headless.record); err != nil {
+			return err
+		}
+	}
+
+	// With a script, run until it ends instead of a number of frames
+	for i := 0; ; i++ {
+		if scriptPath != "" && !script.Running() || scriptPath == "" && i >= headless.frames {
+			break
+		}
+		input.NewState = input.States{}
+		script.Process()
+		movie.Process()
+		state.Core.Run()
+		if state.Core.FrameTimeCallback != nil {
+			state.Core.FrameTimeCallback.Callback(state.Core.FrameTimeCallback.Reference)
+		}
+		if state.Core.AudioCallback != nil {
+			state.Core.AudioCallback.Callback()
+		}
+		record.Process()
+	}
+
+	if err := record.StopVideo(); err != nil {
+		return err
+	}
+
+	if headless.screenshot != "" {
+		if err := vid.SaveFrame(headless.screenshot); err != nil {
+			return err
+		}
+	}
+
+	if headless.render != "" {
+		vid.Render()
+		if err := vid.SaveCanvas(headless.render); err != nil {
+			return err
+		}
+	}
+
+	if headless.ram != "" {
+		size := state.Core.GetMemorySize(libretro.MemorySystemRAM)
+		data := state.Core.GetMemoryData(libretro.MemorySystemRAM)
+		if size == 0 || data == nil {
+			return errors.New("the core doesn't expose its system RAM")
+		}
+		ram := unsafe.Slice((*byte)(data), size)
+		if err := os.WriteFile(headless.ram, ram, 0644); err != nil {
+			return err
+		}
+	}
+
+	return script.Err()
+}
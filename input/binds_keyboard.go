@@ -19,6 +19,13 @@ var keyBinds = map[glfw.Key]uint32{
 	glfw.KeyEnter:      libretro.DeviceIDJoypadStart,
 	glfw.KeyRightShift: libretro.DeviceIDJoypadSelect,
 	glfw.KeySpace:      ActionFastForwardToggle,
+	glfw.KeyR:          ActionRewind,
+	glfw.KeyO:          ActionPause,
+	glfw.KeyPause:      ActionPause,
+	glfw.KeyK:          ActionFrameAdvance,
+	glfw.KeyL:          ActionSlowMotion,
+	glfw.KeyF9:         ActionAudioRecordToggle,
+	glfw.KeyF10:        ActionVideoRecordToggle,
 	glfw.KeyP:          ActionMenuToggle,
 	glfw.KeyF:          ActionFullscreenToggle,
 	glfw.KeyEscape:     ActionShouldClose,
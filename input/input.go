@@ -43,10 +43,22 @@ const (
 	ActionFullscreenToggle uint32 = lr.DeviceIDJoypadR3 + 2
 	// ActionShouldClose will cause the program to shutdown
 	ActionShouldClose uint32 = lr.DeviceIDJoypadR3 + 3
-	// ActionFastForwardToggle will run the core as fast as possible
+	// ActionFastForwardToggle will run the core at the fast-forward ratio
 	ActionFastForwardToggle uint32 = lr.DeviceIDJoypadR3 + 4
+	// ActionRewind steps back in time while held, if rewind is enabled
+	ActionRewind uint32 = lr.DeviceIDJoypadR3 + 5
+	// ActionPause pauses or resumes the emulation
+	ActionPause uint32 = lr.DeviceIDJoypadR3 + 6
+	// ActionFrameAdvance runs a single frame and pauses
+	ActionFrameAdvance uint32 = lr.DeviceIDJoypadR3 + 7
+	// ActionSlowMotion runs the core at the slow motion ratio while held
+	ActionSlowMotion uint32 = lr.DeviceIDJoypadR3 + 8
+	// ActionAudioRecordToggle starts or stops recording the audio
+	ActionAudioRecordToggle uint32 = lr.DeviceIDJoypadR3 + 9
+	// ActionVideoRecordToggle starts or stops recording the video
+	ActionVideoRecordToggle uint32 = lr.DeviceIDJoypadR3 + 10
 	// ActionLast is used for iterating
-	ActionLast uint32 = lr.DeviceIDJoypadR3 + 5
+	ActionLast uint32 = lr.DeviceIDJoypadR3 + 11
 )
 
 // joystickCallback is triggered when a joypad is plugged.
@@ -203,7 +215,7 @@ func State(port uint, device uint32, index uint, id uint) int16 {
 		return NewAnalogState[port][index][id]
 	}
 
-	if device == lr.DeviceMouse {
+	if device == lr.DeviceMouse && vid != nil {
 		x, y := vid.Window.GetCursorPos()
 		if id == uint(lr.DeviceIDMouseX) {
 			d := x - oldMouseX
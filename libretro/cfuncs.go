@@ -103,6 +103,10 @@ void run_wrapper(void *f) {
 }
 
 void cothread_init() {
+	// The thread is shared by all the instances of cores
+	if (s_use_thread) {
+		return;
+	}
 	s_use_thread = true;
 
 	SEM_INIT(s_sem_do);
@@ -257,6 +261,10 @@ unsigned bridge_retro_get_num_images(retro_get_num_images_t f) {
 	return ((unsigned (*)())f)();
 }
 
+void bridge_retro_hw_context_reset(retro_hw_context_reset_t f) {
+	run_wrapper((void*)f);
+}
+
 bool coreEnvironment_cgo(unsigned cmd, void *data) {
 	bool coreEnvironment(unsigned, void*);
 	return coreEnvironment(cmd, data);
@@ -303,5 +311,20 @@ int64_t coreGetTimeUsec_cgo() {
 	return coreGetTimeUsec();
 }
 
+uintptr_t coreGetCurrentFramebuffer_cgo() {
+	uintptr_t coreGetCurrentFramebuffer();
+	return coreGetCurrentFramebuffer();
+}
+
+retro_proc_address_t coreGetProcAddress_cgo(const char *sym) {
+	void* coreGetProcAddress(const char*);
+	return (retro_proc_address_t)coreGetProcAddress(sym);
+}
+
+void coreDo_cgo() {
+	void coreDo();
+	coreDo();
+}
+
 */
 import "C"
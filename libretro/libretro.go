@@ -45,6 +45,8 @@ bool bridge_retro_get_eject_state(retro_get_eject_state_t f);
 unsigned bridge_retro_get_image_index(retro_get_image_index_t f);
 void bridge_retro_set_image_index(retro_set_image_index_t f, unsigned index);
 unsigned bridge_retro_get_num_images(retro_get_num_images_t f);
+void bridge_retro_hw_context_reset(retro_hw_context_reset_t f);
+void run_wrapper(void *f);
 
 bool coreEnvironment_cgo(unsigned cmd, void *data);
 void coreVideoRefresh_cgo(void *data, unsigned width, unsigned height, size_t pitch);
@@ -54,6 +56,9 @@ size_t coreAudioSampleBatch_cgo(const int16_t *data, size_t frames);
 int16_t coreInputState_cgo(unsigned port, unsigned device, unsigned index, unsigned id);
 void coreLog_cgo(enum retro_log_level level, const char *msg);
 int64_t coreGetTimeUsec_cgo();
+uintptr_t coreGetCurrentFramebuffer_cgo();
+retro_proc_address_t coreGetProcAddress_cgo(const char *sym);
+void coreDo_cgo();
 */
 import "C"
 import (
@@ -225,6 +230,19 @@ type AudioCallback struct {
 	SetState func(bool)
 }
 
+// HWRenderCallback stores the hardware rendering context requested by a core
+// and its context reset and destroy callbacks
+type HWRenderCallback struct {
+	ContextType      uint32
+	Depth            bool
+	Stencil          bool
+	BottomLeftOrigin bool
+	VersionMajor     uint
+	VersionMinor     uint
+	ContextReset     func()
+	ContextDestroy   func()
+}
+
 // The pixel format the core must use to render into data.
 // This format could differ from the format used in SET_PIXEL_FORMAT.
 // Set by frontend in GET_CURRENT_SOFTWARE_FRAMEBUFFER.
@@ -234,6 +252,25 @@ const (
 	PixelFormatRGB565   = uint32(C.RETRO_PIXEL_FORMAT_RGB565)
 )
 
+// Hardware rendering contexts
+const (
+	HWContextNone            = uint32(C.RETRO_HW_CONTEXT_NONE)
+	HWContextOpenGL          = uint32(C.RETRO_HW_CONTEXT_OPENGL)
+	HWContextOpenGLES2       = uint32(C.RETRO_HW_CONTEXT_OPENGLES2)
+	HWContextOpenGLCore      = uint32(C.RETRO_HW_CONTEXT_OPENGL_CORE)
+	HWContextOpenGLES3       = uint32(C.RETRO_HW_CONTEXT_OPENGLES3)
+	HWContextOpenGLESVersion = uint32(C.RETRO_HW_CONTEXT_OPENGLES_VERSION)
+	HWContextVulkan          = uint32(C.RETRO_HW_CONTEXT_VULKAN)
+	HWContextDirect3D        = uint32(C.RETRO_HW_CONTEXT_DIRECT3D)
+)
+
+// IsHWFrame returns true if the data passed to the video refresh callback is
+// RETRO_HW_FRAME_BUFFER_VALID, meaning the frame was rendered in the
+// framebuffer of the hardware context
+func IsHWFrame(data unsafe.Pointer) bool {
+	return uintptr(data) == ^uintptr(0)
+}
+
 // Libretro's fundamental device abstractions.
 //
 // Libretro's input system consists of some standardized device types,
@@ -412,6 +449,26 @@ const (
 	MemoryVideoRAM  = uint32(C.RETRO_MEMORY_VIDEO_RAM)
 )
 
+// Memory descriptor flags
+const (
+	MemDescConst     = uint64(C.RETRO_MEMDESC_CONST)
+	MemDescBigEndian = uint64(C.RETRO_MEMDESC_BIGENDIAN)
+	MemDescSystemRAM = uint64(C.RETRO_MEMDESC_SYSTEM_RAM)
+	MemDescSaveRAM   = uint64(C.RETRO_MEMDESC_SAVE_RAM)
+	MemDescVideoRAM  = uint64(C.RETRO_MEMDESC_VIDEO_RAM)
+)
+
+// Serialization quirks
+const (
+	SerializationQuirkIncomplete        = uint64(C.RETRO_SERIALIZATION_QUIRK_INCOMPLETE)
+	SerializationQuirkMustInitialize    = uint64(C.RETRO_SERIALIZATION_QUIRK_MUST_INITIALIZE)
+	SerializationQuirkCoreVariableSize  = uint64(C.RETRO_SERIALIZATION_QUIRK_CORE_VARIABLE_SIZE)
+	SerializationQuirkFrontVariableSize = uint64(C.RETRO_SERIALIZATION_QUIRK_FRONT_VARIABLE_SIZE)
+	SerializationQuirkSingleSession     = uint64(C.RETRO_SERIALIZATION_QUIRK_SINGLE_SESSION)
+	SerializationQuirkEndianDependent   = uint64(C.RETRO_SERIALIZATION_QUIRK_ENDIAN_DEPENDENT)
+	SerializationQuirkPlatformDependent = uint64(C.RETRO_SERIALIZATION_QUIRK_PLATFORM_DEPENDENT)
+)
+
 type (
 	environmentFunc      func(uint32, unsafe.Pointer) bool
 	videoRefreshFunc     func(unsafe.Pointer, int32, int32, int32)
@@ -421,6 +478,8 @@ type (
 	inputStateFunc       func(uint, uint32, uint, uint) int16
 	logFunc              func(uint32, string)
 	getTimeUsecFunc      func() int64
+	getFramebufferFunc   func() uintptr
+	getProcAddressFunc   func(string) unsafe.Pointer
 )
 
 var (
@@ -432,6 +491,9 @@ var (
 	inputState       inputStateFunc
 	log              logFunc
 	getTimeUsec      getTimeUsecFunc
+	getFramebuffer   getFramebufferFunc
+	getProcAddress   getProcAddressFunc
+	do               func()
 )
 
 // Load dynamically loads a libretro core at the given path and returns a Core instance
@@ -497,6 +559,14 @@ func (core *Core) Deinit() {
 	getTimeUsec = nil
 }
 
+// Close deinitializes an additional instance of a core and unloads its
+// library. Unlike Deinit, it keeps the callbacks in place, as they are shared
+// with the main instance.
+func (core *Core) Close() {
+	C.bridge_retro_deinit(core.symRetroDeinit)
+	DlClose(core.handle)
+}
+
 // Run runs the game for one video frame.
 // During retro_run(), input_poll callback must be called at least once.
 // If a frame is not rendered for reasons where a game "dropped" a frame,
@@ -657,6 +727,59 @@ func (core *Core) BindPerfCallback(data unsafe.Pointer, f getTimeUsecFunc) {
 	cb.get_time_usec = (C.retro_perf_get_time_usec_t)(C.coreGetTimeUsec_cgo)
 }
 
+// SetHWRenderCallback is an environment callback helper to set the
+// HWRenderCallback. The frontend provides the framebuffer the core renders to,
+// and the addresses of the OpenGL functions.
+func (core *Core) SetHWRenderCallback(data unsafe.Pointer, fb getFramebufferFunc, proc getProcAddressFunc) {
+	getFramebuffer = fb
+	getProcAddress = proc
+	cb := (*C.struct_retro_hw_render_callback)(data)
+	cb.get_current_framebuffer = (C.retro_hw_get_current_framebuffer_t)(C.coreGetCurrentFramebuffer_cgo)
+	cb.get_proc_address = (C.retro_hw_get_proc_address_t)(C.coreGetProcAddress_cgo)
+
+	reset, destroy := cb.context_reset, cb.context_destroy
+	hrc := &HWRenderCallback{
+		ContextType:      uint32(cb.context_type),
+		Depth:            bool(cb.depth),
+		Stencil:          bool(cb.stencil),
+		BottomLeftOrigin: bool(cb.bottom_left_origin),
+		VersionMajor:     uint(cb.version_major),
+		VersionMinor:     uint(cb.version_minor),
+	}
+	hrc.ContextReset = func() {
+		if reset != nil {
+			C.bridge_retro_hw_context_reset(reset)
+		}
+	}
+	hrc.ContextDestroy = func() {
+		if destroy != nil {
+			C.bridge_retro_hw_context_reset(destroy)
+		}
+	}
+	core.HWRenderCallback = hrc
+}
+
+// Do runs f in the thread running the core. The hardware context is current in
+// this thread, so OpenGL calls for the core must go through Do.
+func (core *Core) Do(f func()) {
+	do = f
+	C.run_wrapper(C.coreDo_cgo)
+	do = nil
+}
+
+// ShareCallbacks registers the callbacks that are already set, like the ones
+// of the main instance, with this instance of the core. This allows running a
+// second instance of a core, as the callbacks are global. Must be called
+// before Init.
+func (core *Core) ShareCallbacks() {
+	C.bridge_retro_set_environment(core.symRetroSetEnvironment, C.coreEnvironment_cgo)
+	C.bridge_retro_set_video_refresh(core.symRetroSetVideoRefresh, C.coreVideoRefresh_cgo)
+	C.bridge_retro_set_input_poll(core.symRetroSetInputPoll, C.coreInputPoll_cgo)
+	C.bridge_retro_set_input_state(core.symRetroSetInputState, C.coreInputState_cgo)
+	C.bridge_retro_set_audio_sample(core.symRetroSetAudioSample, C.coreAudioSample_cgo)
+	C.bridge_retro_set_audio_sample_batch(core.symRetroSetAudioSampleBatch, C.coreAudioSampleBatch_cgo)
+}
+
 // SetControllerPortDevice sets the device type attached to a controller port
 func (core *Core) SetControllerPortDevice(port uint, device uint32) {
 	C.bridge_retro_set_controller_port_device(core.symRetroSetControllerPortDevice, C.unsigned(port), C.unsigned(device))
@@ -718,6 +841,29 @@ func coreLog(level C.enum_retro_log_level, msg *C.char) {
 	log(level, C.GoString(msg))
 }
 
+//export coreGetCurrentFramebuffer
+func coreGetCurrentFramebuffer() C.uintptr_t {
+	if getFramebuffer == nil {
+		return 0
+	}
+	return C.uintptr_t(getFramebuffer())
+}
+
+//export coreGetProcAddress
+func coreGetProcAddress(sym *C.char) unsafe.Pointer {
+	if getProcAddress == nil {
+		return nil
+	}
+	return getProcAddress(C.GoString(sym))
+}
+
+//export coreDo
+func coreDo() {
+	if do != nil {
+		do()
+	}
+}
+
 //export coreGetTimeUsec
 func coreGetTimeUsec() C.uint64_t {
 	if getTimeUsec == nil {
@@ -817,7 +963,8 @@ func GetMemoryMap(data unsafe.Pointer) []MemoryDescriptor {
 		descriptors[i] = MemoryDescriptor{
 			Flags:      uint64(d.flags),
 			Ptr:        d.ptr,
-			Offset:     uintptr(d.start),
+			Offset:     uintptr(d.offset),
+			Start:      uintptr(d.start),
 			Select:     uintptr(d._select),
 			Disconnect: uintptr(d.disconnect),
 			Len:        uintptr(d.len),
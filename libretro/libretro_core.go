@@ -31,6 +31,12 @@ type Core struct {
 	AudioCallback       *AudioCallback
 	FrameTimeCallback   *FrameTimeCallback
 	DiskControlCallback *DiskControlCallback
+	HWRenderCallback    *HWRenderCallback
 
 	MemoryMap []MemoryDescriptor
+
+	SupportsAchievements bool
+
+	// SerializationQuirks are the limitations of the savestates of the core
+	SerializationQuirks uint64
 }
@@ -9,15 +9,24 @@ import (
 	"time"
 
 	"github.com/go-gl/glfw/v3.4/glfw"
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/cheats"
 	"github.com/libretro/ludo/core"
 	"github.com/libretro/ludo/history"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/menu"
+	"github.com/libretro/ludo/movie"
+	"github.com/libretro/ludo/netplay"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/pacing"
 	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/record"
+	"github.com/libretro/ludo/rewind"
+	"github.com/libretro/ludo/runahead"
 	"github.com/libretro/ludo/savefiles"
 	"github.com/libretro/ludo/scanner"
+	"github.com/libretro/ludo/script"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/video"
@@ -30,6 +39,12 @@ func init() {
 
 var frame = 0
 
+// scriptPath is the script to run once the game is loaded
+var scriptPath string
+
+// audioFile is the WAV file to write the audio output to, if any
+var audioFile string
+
 func runLoop(vid *video.Video, m *menu.Menu) {
 	var currTime time.Time
 	prevTime := time.Now()
@@ -43,16 +58,44 @@ func runLoop(vid *video.Video, m *menu.Menu) {
 		m.UpdatePalette()
 		input.Poll()
 		if !state.MenuActive {
-			if state.CoreRunning {
-				state.Core.Run()
+			// While paused, the last frame keeps being rendered. Netplay can't
+			// be paused as the peer would wait.
+			if state.CoreRunning && (!state.Paused || state.FrameAdvance || netplay.Active()) {
+				state.FrameAdvance = false
+				if netplay.Active() {
+					netplay.Run()
+				} else {
+					// Rewinding would break the recorded or played movie
+					state.Rewinding = settings.Current.Rewind && movie.Mode() == movie.Stopped && input.NewState[0][input.ActionRewind] == 1
+					if state.Rewinding {
+						if _, err := rewind.Step(); err != nil {
+							log.Println("[Rewind]:", err)
+						}
+					}
+					cheats.Apply()
+					script.Process()
+					movie.Process()
+					runahead.Run()
+				}
 				if state.Core.FrameTimeCallback != nil {
 					state.Core.FrameTimeCallback.Callback(state.Core.FrameTimeCallback.Reference)
 				}
 				if state.Core.AudioCallback != nil {
 					state.Core.AudioCallback.Callback()
 				}
+				if !state.Rewinding {
+					record.Process()
+				}
+				// Achievements can't unlock while the game isn't played normally
+				achievements.Process(achievements.CoreRAM(), state.Rewinding || cheats.Active() || movie.Mode() == movie.Playing)
+				if !state.Rewinding && !netplay.Active() {
+					if err := rewind.Capture(); err != nil {
+						log.Println("[Rewind]:", err)
+					}
+				}
 			}
 			vid.Render()
+			m.RenderRAMWatch()
 			frame++
 			if frame%600 == 0 { // save sram about every 10 sec
 				savefiles.SaveSRAM()
@@ -63,12 +106,17 @@ func runLoop(vid *video.Video, m *menu.Menu) {
 			m.Render(dt)
 		}
 		m.RenderNotifications()
-		if state.FastForward {
+		// Out of normal speed, the frames are paced by sleeping instead of vsync
+		paced := state.CoreRunning && !state.MenuActive && !state.Paused && pacing.Ratio() != 1
+		if paced {
 			glfw.SwapInterval(0)
 		} else {
 			glfw.SwapInterval(1)
 		}
 		vid.Window.SwapBuffers()
+		if paced {
+			pacing.Wait()
+		}
 		prevTime = currTime
 	}
 }
@@ -95,6 +143,15 @@ func main() {
 	flag.StringVar(&state.CorePath, "L", "", "Path to the libretro core")
 	flag.BoolVar(&state.Verbose, "v", false, "Verbose logs")
 	flag.BoolVar(&state.LudOS, "ludos", false, "Expose the features related to LudOS")
+	flag.BoolVar(&state.Headless, "headless", false, "Run the game without window, audio or input, for automated testing")
+	flag.IntVar(&headless.frames, "frames", 600, "Number of frames to run in headless mode")
+	flag.StringVar(&headless.screenshot, "screenshot", "", "Save the last frame to this PNG file in headless mode")
+	flag.StringVar(&headless.ram, "ram", "", "Dump the system RAM to this file in headless mode")
+	flag.StringVar(&headless.movie, "movie", "", "Play the input of this movie in headless mode")
+	flag.StringVar(&headless.record, "record", "", "Record the video and audio to this AVI file in headless mode")
+	flag.StringVar(&headless.render, "render", "", "Save the screen drawn by the software renderer, with the viewport and the overlay, to this PNG file in headless mode")
+	flag.StringVar(&scriptPath, "script", "", "Run this script once the game is loaded. In headless mode, run until the script ends")
+	flag.StringVar(&audioFile, "audio-file", "", "Write the audio output to this WAV file instead of playing it")
 	flag.Parse()
 	args := flag.Args()
 
@@ -103,6 +160,13 @@ func main() {
 		gamePath = args[0]
 	}
 
+	if state.Headless {
+		if err := runHeadless(gamePath); err != nil {
+			log.Fatalln("[Headless]:", err)
+		}
+		return
+	}
+
 	if err := glfw.Init(); err != nil {
 		log.Fatalln("Failed to initialize glfw", err)
 	}
@@ -119,12 +183,21 @@ func main() {
 
 	vid := video.Init(settings.Current.VideoFullscreen)
 
+	if audioFile != "" {
+		file := audio.NewFile(audioFile)
+		defer file.Close()
+		audio.UseDriver(file)
+	}
 	audio.Init()
 
 	m := menu.Init(vid)
 
 	core.Init(vid)
 
+	script.Init(vid)
+
+	record.Init(vid)
+
 	input.Init(vid)
 
 	if len(state.CorePath) > 0 {
@@ -136,6 +209,11 @@ func main() {
 					ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
 				} else {
 					m.WarpToQuickMenu()
+					if scriptPath != "" {
+						if err := script.Load(scriptPath); err != nil {
+							ntf.DisplayAndLog(ntf.Error, "Script", err.Error())
+						}
+					}
 				}
 			}
 		} else {
@@ -150,4 +228,5 @@ func main() {
 
 	// Unload and deinit in the core.
 	core.Unload()
+	audio.Deinit()
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/libretro/ludo/audio"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/netplay"
 	ntf "github.com/libretro/ludo/notifications"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
@@ -213,6 +214,41 @@ func (m *Menu) ProcessHotkeys() {
 		}
 	}
 
+	// Slow motion lasts as long as the hotkey is held. Netplay can't be slowed
+	// down as the peer would wait.
+	state.SlowMotion = input.NewState[0][input.ActionSlowMotion] == 1 && !state.MenuActive && !netplay.Active()
+
+	if input.Pressed[0][input.ActionPause] == 1 && !state.MenuActive && state.CoreRunning {
+		if netplay.Active() {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "Can't pause during netplay")
+		} else {
+			state.Paused = !state.Paused
+			if state.Paused {
+				ntf.DisplayAndLog(ntf.Info, "Menu", "Paused")
+			} else {
+				ntf.DisplayAndLog(ntf.Info, "Menu", "Resumed")
+			}
+		}
+	}
+
+	// Frame advance pauses first, then runs one frame per press
+	if input.Pressed[0][input.ActionFrameAdvance] == 1 && !state.MenuActive && state.CoreRunning && !netplay.Active() {
+		if state.Paused {
+			state.FrameAdvance = true
+		} else {
+			state.Paused = true
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Paused")
+		}
+	}
+
+	if input.Pressed[0][input.ActionAudioRecordToggle] == 1 && !state.MenuActive && state.CoreRunning {
+		toggleAudioRecording()
+	}
+
+	if input.Pressed[0][input.ActionVideoRecordToggle] == 1 && !state.MenuActive && state.CoreRunning {
+		toggleVideoRecording()
+	}
+
 	// Close if ActionShouldClose is pressed, but display a confirmation dialog
 	// in case a game is running
 	if input.Pressed[0][input.ActionShouldClose] == 1 {
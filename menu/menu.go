@@ -23,17 +23,17 @@ type Menu struct {
 	ratio  float32
 	t      float64
 
-	*video.Video // we embbed video here to have direct access to drawing functions
+	video.Renderer // we embbed video here to have direct access to drawing functions
 }
 
 // Init initializes the menu.
 // If a game is already running, it will warp the user to the quick menu.
 // If not, it will display the menu tabs.
-func Init(v *video.Video) *Menu {
+func Init(v video.Renderer) *Menu {
 	w, _ := v.GetFramebufferSize()
 
 	menu = &Menu{}
-	menu.Video = v
+	menu.Renderer = v
 	menu.stack = []Scene{}
 	menu.tweens = make(Tweens)
 	menu.ratio = float32(w) / 1920
@@ -92,14 +92,14 @@ func (m *Menu) ContextReset() {
 	for _, path := range paths {
 		path := path
 		filename := utils.FileName(path)
-		m.icons[filename] = video.NewImage(path)
+		m.icons[filename] = m.NewImage(path)
 	}
 
 	paths, _ = filepath.Glob(assets + "/flags/*.png")
 	for _, path := range paths {
 		path := path
 		filename := utils.FileName(path)
-		m.icons[filename] = video.NewImage(path)
+		m.icons[filename] = m.NewImage(path)
 	}
 
 	currentScreenIndex := len(m.stack) - 1
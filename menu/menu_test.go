@@ -1,7 +1,11 @@
 package menu
 
 import (
+	"flag"
+	"image/color"
+	"image/png"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -12,6 +16,9 @@ import (
 	"github.com/tanema/gween/ease"
 )
 
+// update rewrites the golden images with the current output
+var update = flag.Bool("update", false, "update the golden images")
+
 func Test_WarpToQuickMenu(t *testing.T) {
 	m := Init(&video.Video{})
 
@@ -188,6 +195,56 @@ func Test_buildExplorer(t *testing.T) {
 	})
 }
 
+func Test_cycle(t *testing.T) {
+	cutoffs := []int{0, 3000, 5000}
+	tests := []struct {
+		name      string
+		v         int
+		direction int
+		want      int
+	}{
+		{"Goes to the next value", 0, 1, 3000},
+		{"Goes to the previous value", 5000, -1, 3000},
+		{"Wraps around after the last value", 5000, 1, 0},
+		{"Wraps around before the first value", 0, -1, 5000},
+		{"Starts from the first value for unknown values", 42, 1, 3000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cycle(cutoffs, tt.v, tt.direction); got != tt.want {
+				t.Errorf("cycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRAMValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		size    int
+		want    uint32
+		wantErr bool
+	}{
+		{"Parses decimal values", "1234", 2, 1234, false},
+		{"Parses hexadecimal values", "0X7FFF", 2, 0x7FFF, false},
+		{"Parses 32 bits values", "4294967295", 4, 0xFFFFFFFF, false},
+		{"Rejects values larger than the size", "256", 1, 0, true},
+		{"Rejects other text", "TWELVE", 4, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRAMValue(tt.s, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRAMValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRAMValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractTags(t *testing.T) {
 	var empty []string
 	tests := []struct {
@@ -233,3 +290,70 @@ func TestExtractTags(t *testing.T) {
 		})
 	}
 }
+
+func Test_Render(t *testing.T) {
+	state.CoreRunning = false
+	state.MenuActive = true
+	sw := video.NewSoftware(384, 216)
+	m := Init(sw)
+	m.UpdatePalette()
+	sw.Render()
+	m.Render(0.016)
+
+	t.Run("Draws the background", func(t *testing.T) {
+		got := sw.Canvas().RGBAAt(0, 0)
+		want := color.RGBA{
+			R: uint8(bgColor.R*255 + 0.5),
+			G: uint8(bgColor.G*255 + 0.5),
+			B: uint8(bgColor.B*255 + 0.5),
+			A: 0xFF,
+		}
+		if got != want {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Matches the golden image", func(t *testing.T) {
+		golden := filepath.Join("testdata", "render.png")
+		got := sw.Canvas()
+		if *update {
+			os.MkdirAll("testdata", os.ModePerm)
+			fd, err := os.Create(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fd.Close()
+			if err := png.Encode(fd, got); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fd, err := os.Open(golden)
+		if err != nil {
+			t.Fatalf("%v, run the test with -update to create it", err)
+		}
+		defer fd.Close()
+		want, err := png.Decode(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want.Bounds() != got.Bounds() {
+			t.Fatalf("size = %v, want %v", got.Bounds(), want.Bounds())
+		}
+		// Allow rounding differences between platforms
+		diff := func(a, b uint32) bool { return a>>8 > b>>8+2 || b>>8 > a>>8+2 }
+		different := 0
+		for y := 0; y < got.Rect.Dy(); y++ {
+			for x := 0; x < got.Rect.Dx(); x++ {
+				r1, g1, b1, a1 := got.At(x, y).RGBA()
+				r2, g2, b2, a2 := want.At(x, y).RGBA()
+				if diff(r1, r2) || diff(g1, g2) || diff(b1, b2) || diff(a1, a2) {
+					different++
+				}
+			}
+		}
+		if different > 0 {
+			t.Errorf("%d pixels differ from %s", different, golden)
+		}
+	})
+}
@@ -11,7 +11,7 @@ var severityBgColor map[ntf.Severity]video.Color
 // RenderNotifications draws the list of notification messages on the viewport
 func (m *Menu) RenderNotifications() {
 	fbw, fbh := m.GetFramebufferSize()
-	m.Font.UpdateResolution(fbw, fbh)
+	m.Font().UpdateResolution(fbw, fbh)
 	var h float32 = 75
 	stack := h
 	for _, n := range ntf.List() {
@@ -20,7 +20,7 @@ func (m *Menu) RenderNotifications() {
 			fading = 1
 		}
 		offset := fading*h - h
-		lw := m.Font.Width(0.5*m.ratio, n.Message)
+		lw := m.Font().Width(0.5*m.ratio, n.Message)
 		fg := severityFgColor[n.Severity]
 		bg := severityBgColor[n.Severity]
 		m.DrawRect(
@@ -31,8 +31,8 @@ func (m *Menu) RenderNotifications() {
 			0.25,
 			bg.Alpha(fading),
 		)
-		m.Font.SetColor(fg.Alpha(fading))
-		m.Font.Print(
+		m.Font().SetColor(fg.Alpha(fading))
+		m.Font().Print(
 			45*m.ratio,
 			(stack+offset)*m.ratio,
 			0.5*m.ratio,
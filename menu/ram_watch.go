@@ -0,0 +1,48 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/cheats"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/video"
+)
+
+// RenderRAMWatch draws the values of the watched addresses over the game
+func (m *Menu) RenderRAMWatch() {
+	if len(ramWatches) == 0 || !state.CoreRunning || ramSearch.game != state.GamePath {
+		return
+	}
+
+	fbw, fbh := m.GetFramebufferSize()
+	m.Font().UpdateResolution(fbw, fbh)
+
+	regions := cheats.CoreMemory().Regions()
+	lines := []string{}
+	for _, w := range ramWatches {
+		if v, ok := w.Read(regions); ok {
+			lines = append(lines, fmt.Sprintf("%06X: %0*X", w.Address, w.Size*2, v))
+		}
+	}
+
+	var lh float32 = 40
+	var width float32
+	for _, l := range lines {
+		if lw := m.Font().Width(0.5*m.ratio, l); lw > width {
+			width = lw
+		}
+	}
+	x := float32(fbw) - width - 65*m.ratio
+	m.DrawRect(
+		x,
+		25*m.ratio,
+		width+40*m.ratio,
+		(float32(len(lines))*lh+30)*m.ratio,
+		0.05,
+		video.Color{R: 0, G: 0, B: 0, A: 0.75},
+	)
+	m.Font().SetColor(video.Color{R: 1, G: 1, B: 1, A: 1})
+	for i, l := range lines {
+		m.Font().Print(x+20*m.ratio, (float32(i+1)*lh+25)*m.ratio, 0.5*m.ratio, l)
+	}
+}
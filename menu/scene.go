@@ -194,13 +194,13 @@ func genericRender(list *entry) {
 			0.5, 0, textColor.Alpha(e.iconAlpha))
 
 		if e.labelAlpha > 0 {
-			menu.Font.SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
-			menu.Font.Print(
+			menu.Font().SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
+			menu.Font().Print(
 				(670+1)*menu.ratio,
 				float32(h)*e.yp+fontOffset+1*menu.ratio,
 				0.5*menu.ratio, e.label)
-			menu.Font.SetColor(textColor.Alpha(e.labelAlpha))
-			menu.Font.Print(
+			menu.Font().SetColor(textColor.Alpha(e.labelAlpha))
+			menu.Font().Print(
 				670*menu.ratio,
 				float32(h)*e.yp+fontOffset,
 				0.5*menu.ratio, e.label)
@@ -208,8 +208,8 @@ func genericRender(list *entry) {
 			if e.widget != nil {
 				e.widget(&e)
 			} else if e.stringValue != nil {
-				lw := menu.Font.Width(0.5*menu.ratio, e.stringValue())
-				menu.Font.Print(
+				lw := menu.Font().Width(0.5*menu.ratio, e.stringValue())
+				menu.Font().Print(
 					float32(w)-lw-128*menu.ratio,
 					float32(h)*e.yp+fontOffset,
 					0.5*menu.ratio, e.stringValue())
@@ -268,7 +268,7 @@ func askDeleteSavestateConfirmation(cb func()) {
 }
 
 func genericDrawHintBar() {
-	w, h := menu.Window.GetFramebufferSize()
+	w, h := menu.GetFramebufferSize()
 	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 88*menu.ratio, 0, hintBgColor)
 	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 2*menu.ratio, 0, sepColor)
 
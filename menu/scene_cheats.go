@@ -0,0 +1,93 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/cheats"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+)
+
+type sceneCheats struct {
+	entry
+}
+
+func buildCheats() Scene {
+	var list sceneCheats
+	list.label = "Cheats"
+
+	for i := range cheats.Current {
+		i := i
+		label := cheats.Current[i].Desc
+		if label == "" {
+			label = cheats.Current[i].Code
+		}
+		toggle := func() {
+			cheats.Current[i].Enabled = !cheats.Current[i].Enabled
+			if err := cheats.Save(); err != nil {
+				ntf.DisplayAndLogf(ntf.Error, "Menu", "Error saving cheats: %v", err)
+			}
+		}
+		list.children = append(list.children, entry{
+			label:      label,
+			icon:       "subsetting",
+			value:      func() interface{} { return cheats.Current[i].Enabled },
+			widget:     widgets["switch"],
+			callbackOK: toggle,
+			incr:       func(int) { toggle() },
+		})
+	}
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No cheats",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneCheats) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCheats) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCheats) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCheats) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCheats) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCheats) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCheats) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 88*menu.ratio, 0, hintBgColor)
+	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 2*menu.ratio, 0, sepColor)
+
+	_, upDown, _, a, b, _, _, _, _, guide := hintIcons()
+
+	lstack := float32(75) * menu.ratio
+	rstack := float32(w) - 96*menu.ratio
+	list := menu.stack[len(menu.stack)-1].Entry()
+	stackHintLeft(&lstack, upDown, "Navigate", h)
+	if list.children[list.ptr].incr != nil {
+		stackHintRight(&rstack, a, "Toggle", h)
+	}
+	stackHintRight(&rstack, b, "Back", h)
+	if state.CoreRunning {
+		stackHintRight(&rstack, guide, "Resume", h)
+	}
+}
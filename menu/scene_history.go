@@ -199,17 +199,17 @@ func (s *sceneHistory) render() {
 			}
 
 			stack := 840 * menu.ratio
-			menu.Font.SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
-			menu.Font.Print(
+			menu.Font().SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
+			menu.Font().Print(
 				(840+1)*menu.ratio,
 				float32(h)*e.yp+fontOffset-slOffset+1*menu.ratio,
 				0.5*menu.ratio, e.label)
-			menu.Font.SetColor(textColor.Alpha(e.labelAlpha))
-			menu.Font.Print(
+			menu.Font().SetColor(textColor.Alpha(e.labelAlpha))
+			menu.Font().Print(
 				840*menu.ratio,
 				float32(h)*e.yp+fontOffset-slOffset,
 				0.5*menu.ratio, e.label)
-			stack += float32(int(menu.Font.Width(0.5*menu.ratio, e.label)))
+			stack += float32(int(menu.Font().Width(0.5*menu.ratio, e.label)))
 			stack += 10
 
 			for _, tag := range e.tags {
@@ -225,8 +225,8 @@ func (s *sceneHistory) render() {
 				}
 			}
 
-			menu.Font.SetColor(mutedTextColor.Alpha(e.subLabelAlpha))
-			menu.Font.Print(
+			menu.Font().SetColor(mutedTextColor.Alpha(e.subLabelAlpha))
+			menu.Font().Print(
 				840*menu.ratio,
 				float32(h)*e.yp+fontOffset+60*menu.ratio-slOffset,
 				0.5*menu.ratio, e.subLabel)
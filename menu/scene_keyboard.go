@@ -161,8 +161,8 @@ func (s *sceneKeyboard) render() {
 		white.Alpha(s.alpha))
 
 	// Label
-	menu.Font.SetColor(black)
-	menu.Font.Print(
+	menu.Font().SetColor(black)
+	menu.Font().Print(
 		float32(w)/2-ttw/2,
 		s.y+float32(h)*0.15-ksz/2+ksz*0.6,
 		ksz/260, s.label)
@@ -170,7 +170,7 @@ func (s *sceneKeyboard) render() {
 	// Value
 	menu.DrawRect(float32(w)/2-ttw/2, s.y+float32(h)*0.25-ksz/2, ttw, ksz, 0,
 		video.Color{R: 0.95, G: 0.95, B: 0.95, A: 1})
-	menu.Font.Print(
+	menu.Font().Print(
 		float32(w)/2-ttw/2+ksz/4,
 		s.y+float32(h)*0.25-ksz/2+ksz*0.62,
 		ksz/200, s.value+"|")
@@ -179,12 +179,12 @@ func (s *sceneKeyboard) render() {
 
 	menu.DrawRect(0, s.y+float32(h)-kbh, float32(w), kbh, 0, black)
 
-	menu.Font.SetColor(white)
+	menu.Font().SetColor(white)
 
 	for i, key := range layouts[s.layout] {
 		x := float32(i%10)*ksp - ttw/2 + float32(w)/2
 		y := s.y + float32(i/10)*ksp + ksp/2 + float32(h) - kbh
-		gw := menu.Font.Width(ksz/200, key)
+		gw := menu.Font().Width(ksz/200, key)
 
 		c1 := video.Color{R: 0.15, G: 0.15, B: 0.15, A: 1}
 		c2 := video.Color{R: 0.25, G: 0.25, B: 0.25, A: 1}
@@ -196,7 +196,7 @@ func (s *sceneKeyboard) render() {
 		menu.DrawRect(x, y, ksz, ksz, 0.2, c1)
 		menu.DrawRect(x, y, ksz, ksz*0.95, 0.2, c2)
 
-		menu.Font.Print(
+		menu.Font().Print(
 			x+ksz/2-gw/2,
 			y+ksz*0.6,
 			ksz/200, key)
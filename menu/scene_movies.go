@@ -0,0 +1,121 @@
+package menu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libretro/ludo/movie"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneMovies struct {
+	entry
+}
+
+// movieStatus describes the movie being recorded or played
+func movieStatus() string {
+	switch movie.Mode() {
+	case movie.Recording:
+		return fmt.Sprintf("Recording, frame %d", movie.Position())
+	case movie.Playing:
+		return fmt.Sprintf("Playing, frame %d", movie.Position())
+	default:
+		return ""
+	}
+}
+
+func buildMovies() Scene {
+	var list sceneMovies
+	list.label = "Movies"
+
+	record := func(fromState bool) {
+		if err := movie.Record(fromState); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Movie", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Info, "Movie", "Recording.")
+		state.MenuActive = false
+	}
+
+	list.children = append(list.children, entry{
+		label:      "Record From Power-On",
+		icon:       "subsetting",
+		callbackOK: func() { record(false) },
+	})
+
+	list.children = append(list.children, entry{
+		label:      "Record From Current State",
+		icon:       "subsetting",
+		callbackOK: func() { record(true) },
+	})
+
+	list.children = append(list.children, entry{
+		label:       "Stop",
+		icon:        "close",
+		stringValue: movieStatus,
+		callbackOK: func() {
+			if movie.Mode() == movie.Stopped {
+				return
+			}
+			if err := movie.Stop(); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Movie", err.Error())
+				return
+			}
+			menu.stack[len(menu.stack)-1] = buildMovies()
+			menu.tweens.FastForward()
+			ntf.DisplayAndLog(ntf.Info, "Movie", "Stopped.")
+		},
+	})
+
+	gameName := utils.FileName(state.GamePath)
+	for _, path := range movie.List(state.GamePath) {
+		path := path
+		date := strings.Replace(utils.FileName(path), gameName+"@", "", 1)
+		list.children = append(list.children, entry{
+			label: "Play " + date,
+			icon:  "subsetting",
+			callbackOK: func() {
+				if err := movie.Play(path); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Movie", err.Error())
+					return
+				}
+				ntf.DisplayAndLog(ntf.Info, "Movie", "Playing.")
+				state.MenuActive = false
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneMovies) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneMovies) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneMovies) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneMovies) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneMovies) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneMovies) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneMovies) drawHintBar() {
+	genericDrawHintBar()
+}
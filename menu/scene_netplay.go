@@ -0,0 +1,125 @@
+package menu
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/netplay"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+type sceneNetplay struct {
+	entry
+}
+
+// netplayStatus describes the current session
+func netplayStatus() string {
+	switch {
+	case !netplay.Active():
+		return ""
+	case !netplay.Current.Connected():
+		return "Waiting"
+	default:
+		return fmt.Sprintf("Player %d", netplay.Current.Local+1)
+	}
+}
+
+func buildNetplay() Scene {
+	var list sceneNetplay
+	list.label = "Netplay"
+
+	list.children = append(list.children, entry{
+		label:       "Host Game",
+		icon:        "subsetting",
+		stringValue: netplayStatus,
+		callbackOK: func() {
+			crc, err := core.ContentCRC()
+			if err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Netplay", err.Error())
+				return
+			}
+			if err := netplay.Host(crc); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Netplay", err.Error())
+				return
+			}
+			ntf.DisplayAndLogf(ntf.Info, "Netplay", "Waiting for a player on port %d.", settings.Current.NetplayPort)
+			state.MenuActive = false
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Join Game",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			k := buildKeyboard("Host Address", func(addr string) {
+				if _, _, err := net.SplitHostPort(addr); err != nil {
+					addr = net.JoinHostPort(addr, strconv.Itoa(settings.Current.NetplayPort))
+				}
+				settings.Current.NetplayHost = addr
+				settings.Save()
+				crc, err := core.ContentCRC()
+				if err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Netplay", err.Error())
+					return
+				}
+				if err := netplay.Join(addr, crc); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Netplay", err.Error())
+					return
+				}
+				ntf.DisplayAndLogf(ntf.Info, "Netplay", "Joining %s.", addr)
+				state.MenuActive = false
+			})
+			k.(*sceneKeyboard).value = settings.Current.NetplayHost
+			menu.Push(k)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Disconnect",
+		icon:  "close",
+		callbackOK: func() {
+			if !netplay.Active() {
+				return
+			}
+			netplay.Disconnect()
+			ntf.DisplayAndLog(ntf.Info, "Netplay", "Disconnected.")
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneNetplay) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneNetplay) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneNetplay) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneNetplay) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneNetplay) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneNetplay) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneNetplay) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -0,0 +1,113 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/core"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/patch"
+	"github.com/libretro/ludo/state"
+)
+
+type scenePatches struct {
+	entry
+}
+
+func buildPatches() Scene {
+	var list scenePatches
+	list.label = "Patches"
+
+	patches, err := patch.List(state.GamePath)
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+	}
+
+	for i := range patches {
+		i := i
+		toggle := func() {
+			patches[i].Enabled = !patches[i].Enabled
+			if err := patch.SaveList(state.GamePath, patches); err != nil {
+				ntf.DisplayAndLogf(ntf.Error, "Menu", "Error saving patches: %v", err)
+			}
+		}
+		list.children = append(list.children, entry{
+			label:      patches[i].File,
+			icon:       "subsetting",
+			value:      func() interface{} { return patches[i].Enabled },
+			widget:     widgets["switch"],
+			callbackOK: toggle,
+			incr:       func(int) { toggle() },
+		})
+	}
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No patches",
+			icon:  "subsetting",
+		})
+	} else {
+		list.children = append(list.children, entry{
+			label: "Reload Game",
+			icon:  "reset",
+			callbackOK: func() {
+				gamePath := state.GamePath
+				core.UnloadGame()
+				if err := core.LoadGame(gamePath); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+					return
+				}
+				menu.WarpToQuickMenu()
+				state.MenuActive = false
+				ntf.DisplayAndLog(ntf.Success, "Menu", "Game reloaded.")
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *scenePatches) Entry() *entry {
+	return &s.entry
+}
+
+func (s *scenePatches) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *scenePatches) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *scenePatches) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *scenePatches) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *scenePatches) render() {
+	genericRender(&s.entry)
+}
+
+func (s *scenePatches) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 88*menu.ratio, 0, hintBgColor)
+	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 2*menu.ratio, 0, sepColor)
+
+	_, upDown, _, a, b, _, _, _, _, guide := hintIcons()
+
+	lstack := float32(75) * menu.ratio
+	rstack := float32(w) - 96*menu.ratio
+	list := menu.stack[len(menu.stack)-1].Entry()
+	stackHintLeft(&lstack, upDown, "Navigate", h)
+	if list.children[list.ptr].incr != nil {
+		stackHintRight(&rstack, a, "Toggle", h)
+	} else if list.children[list.ptr].callbackOK != nil {
+		stackHintRight(&rstack, a, "Ok", h)
+	}
+	stackHintRight(&rstack, b, "Back", h)
+	if state.CoreRunning {
+		stackHintRight(&rstack, guide, "Resume", h)
+	}
+}
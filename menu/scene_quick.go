@@ -1,7 +1,10 @@
 package menu
 
 import (
+	"github.com/libretro/ludo/cheats"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/patch"
+	"github.com/libretro/ludo/record"
 	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/utils"
 )
@@ -56,6 +59,22 @@ func buildQuickMenu() Scene {
 		},
 	})
 
+	list.children = append(list.children, entry{
+		label:      "Record Audio",
+		icon:       "subsetting",
+		value:      func() interface{} { return record.AudioRecording() },
+		widget:     widgets["switch"],
+		callbackOK: toggleAudioRecording,
+	})
+
+	list.children = append(list.children, entry{
+		label:      "Record Video",
+		icon:       "subsetting",
+		value:      func() interface{} { return record.VideoRecording() },
+		widget:     widgets["switch"],
+		callbackOK: toggleVideoRecording,
+	})
+
 	list.children = append(list.children, entry{
 		label: "Options",
 		icon:  "subsetting",
@@ -65,6 +84,24 @@ func buildQuickMenu() Scene {
 		},
 	})
 
+	list.children = append(list.children, entry{
+		label: "Shader Parameters",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildShaderParameters())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Viewport",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildViewport())
+		},
+	})
+
 	if state.Core != nil && state.Core.DiskControlCallback != nil {
 		list.children = append(list.children, entry{
 			label: "Disk Control",
@@ -76,11 +113,112 @@ func buildQuickMenu() Scene {
 		})
 	}
 
+	if len(cheats.Current) > 0 {
+		list.children = append(list.children, entry{
+			label: "Cheats",
+			icon:  "subsetting",
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildCheats())
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Netplay",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildNetplay())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Movies",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildMovies())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Scripts",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildScripts())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Run-Ahead",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildRunAhead())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "RAM Search",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildRAMSearch())
+		},
+	})
+
+	if patches, _ := patch.List(state.GamePath); len(patches) > 0 {
+		list.children = append(list.children, entry{
+			label: "Patches",
+			icon:  "subsetting",
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildPatches())
+			},
+		})
+	}
+
 	list.segueMount()
 
 	return &list
 }
 
+// toggleAudioRecording starts or stops recording the audio of the game
+func toggleAudioRecording() {
+	if record.AudioRecording() {
+		if err := record.StopAudio(); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Success, "Record", "Audio recording saved.")
+		return
+	}
+	if err := record.StartAudio(); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+		return
+	}
+	ntf.DisplayAndLog(ntf.Info, "Record", "Recording audio.")
+}
+
+// toggleVideoRecording starts or stops recording the video of the game
+func toggleVideoRecording() {
+	if record.VideoRecording() {
+		if err := record.StopVideo(); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Success, "Record", "Video recording saved.")
+		return
+	}
+	if err := record.StartVideo(""); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+		return
+	}
+	ntf.DisplayAndLog(ntf.Info, "Record", "Recording video.")
+}
+
 func (s *sceneQuick) Entry() *entry {
 	return &s.entry
 }
@@ -0,0 +1,331 @@
+package menu
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/libretro/ludo/cheats"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/ramsearch"
+	"github.com/libretro/ludo/state"
+)
+
+// maxRAMResults is the number of candidates listed in the results scene
+const maxRAMResults = 100
+
+// ramSearch is the ongoing memory search. It is kept while navigating the
+// menu and playing, and dropped when another game is loaded.
+var ramSearch = struct {
+	search    *ramsearch.Search
+	game      string
+	size      int
+	bigEndian bool
+	value     uint32
+}{size: 1}
+
+// ramWatches are the addresses displayed over the game
+var ramWatches []ramsearch.Watch
+
+type sceneRAMSearch struct {
+	entry
+}
+
+func resetRAMSearch() {
+	if ramSearch.game != state.GamePath {
+		ramSearch.search = nil
+		ramSearch.game = state.GamePath
+		ramWatches = nil
+	}
+}
+
+// parseRAMValue parses a value typed in decimal, or in hexadecimal with the 0x
+// prefix, that fits in size bytes
+func parseRAMValue(s string, size int) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, size*8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %d bits value: %s", size*8, s)
+	}
+	return uint32(v), nil
+}
+
+func buildRAMSearch() Scene {
+	var list sceneRAMSearch
+	list.label = "RAM Search"
+
+	resetRAMSearch()
+
+	list.children = append(list.children, entry{
+		label: "Value Size",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("<%d bits>", ramSearch.size*8)
+		},
+		incr: func(direction int) {
+			sizes := []int{1, 2, 4}
+			i := 0
+			for j, s := range sizes {
+				if s == ramSearch.size {
+					i = j
+				}
+			}
+			i = (i + direction + len(sizes)) % len(sizes)
+			ramSearch.size = sizes[i]
+			ramSearch.search = nil
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Byte Order",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if ramSearch.bigEndian {
+				return "<Big Endian>"
+			}
+			return "<Little Endian>"
+		},
+		incr: func(direction int) {
+			ramSearch.bigEndian = !ramSearch.bigEndian
+			ramSearch.search = nil
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Value",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d (0x%X)", ramSearch.value, ramSearch.value)
+		},
+		incr: func(direction int) {
+			max := uint32(1)<<(ramSearch.size*8) - 1
+			v := int64(ramSearch.value) + int64(direction)
+			if v >= 0 && v <= int64(max) {
+				ramSearch.value = uint32(v)
+			}
+		},
+		callbackOK: func() {
+			list.segueNext()
+			k := buildKeyboard("Value", func(s string) {
+				v, err := parseRAMValue(s, ramSearch.size)
+				if err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+					return
+				}
+				ramSearch.value = v
+			})
+			k.(*sceneKeyboard).value = strconv.FormatUint(uint64(ramSearch.value), 10)
+			menu.Push(k)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "New Search",
+		icon:  "reset",
+		callbackOK: func() {
+			regions := cheats.CoreMemory().Regions()
+			if len(regions) == 0 {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "The core doesn't expose its memory.")
+				return
+			}
+			ramSearch.search = ramsearch.New(regions, ramSearch.size, ramSearch.bigEndian)
+			ntf.DisplayAndLogf(ntf.Info, "Menu", "%d candidates.", ramSearch.search.Count())
+		},
+	})
+
+	filters := []struct {
+		label    string
+		op       ramsearch.Op
+		previous bool
+	}{
+		{"Equal to Value", ramsearch.Equal, false},
+		{"Not Equal to Value", ramsearch.NotEqual, false},
+		{"Greater than Value", ramsearch.Greater, false},
+		{"Less than Value", ramsearch.Less, false},
+		{"Increased", ramsearch.Greater, true},
+		{"Decreased", ramsearch.Less, true},
+		{"Changed", ramsearch.NotEqual, true},
+		{"Unchanged", ramsearch.Equal, true},
+	}
+	for _, f := range filters {
+		f := f
+		list.children = append(list.children, entry{
+			label: f.label,
+			icon:  "subsetting",
+			callbackOK: func() {
+				if ramSearch.search == nil {
+					ntf.DisplayAndLog(ntf.Warning, "Menu", "Start a new search first.")
+					return
+				}
+				regions := cheats.CoreMemory().Regions()
+				var err error
+				if f.previous {
+					err = ramSearch.search.FilterPrevious(regions, f.op)
+				} else {
+					err = ramSearch.search.FilterValue(regions, f.op, ramSearch.value)
+				}
+				if err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+					return
+				}
+				ntf.DisplayAndLogf(ntf.Info, "Menu", "%d candidates.", ramSearch.search.Count())
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Results",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if ramSearch.search == nil {
+				return ""
+			}
+			return fmt.Sprintf("%d", ramSearch.search.Count())
+		},
+		callbackOK: func() {
+			if ramSearch.search == nil {
+				ntf.DisplayAndLog(ntf.Warning, "Menu", "Start a new search first.")
+				return
+			}
+			list.segueNext()
+			menu.Push(buildRAMSearchResults())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Clear Watches",
+		icon:  "reset",
+		callbackOK: func() {
+			ramWatches = nil
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Watches cleared.")
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneRAMSearch) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneRAMSearch) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneRAMSearch) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneRAMSearch) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneRAMSearch) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneRAMSearch) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneRAMSearch) drawHintBar() {
+	genericDrawHintBar()
+}
+
+type sceneRAMSearchResults struct {
+	entry
+}
+
+// watchIndex returns the index of the watch on address, or -1
+func watchIndex(address uint32) int {
+	for i, w := range ramWatches {
+		if w.Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
+func buildRAMSearchResults() Scene {
+	var list sceneRAMSearchResults
+	list.label = "Results"
+
+	regions := cheats.CoreMemory().Regions()
+	for _, r := range ramSearch.search.Results(regions, maxRAMResults) {
+		r := r
+		list.children = append(list.children, entry{
+			label: fmt.Sprintf("%06X: %d (was %d)", r.Address, r.Value, r.Previous),
+			icon:  "subsetting",
+			value: func() interface{} {
+				return watchIndex(r.Address) >= 0
+			},
+			widget: widgets["switch"],
+			callbackOK: func() {
+				if i := watchIndex(r.Address); i >= 0 {
+					ramWatches = append(ramWatches[:i], ramWatches[i+1:]...)
+				} else {
+					ramWatches = append(ramWatches, ramsearch.Watch{
+						Address:   r.Address,
+						Size:      ramSearch.search.Size,
+						BigEndian: ramSearch.search.BigEndian,
+					})
+				}
+			},
+		})
+	}
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No results",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneRAMSearchResults) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneRAMSearchResults) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneRAMSearchResults) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneRAMSearchResults) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneRAMSearchResults) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneRAMSearchResults) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneRAMSearchResults) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 88*menu.ratio, 0, hintBgColor)
+	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 2*menu.ratio, 0, sepColor)
+
+	_, upDown, _, a, b, _, _, _, _, guide := hintIcons()
+
+	lstack := float32(75) * menu.ratio
+	rstack := float32(w) - 96*menu.ratio
+	list := menu.stack[len(menu.stack)-1].Entry()
+	stackHintLeft(&lstack, upDown, "Navigate", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHintRight(&rstack, a, "Watch", h)
+	}
+	stackHintRight(&rstack, b, "Back", h)
+	if state.CoreRunning {
+		stackHintRight(&rstack, guide, "Resume", h)
+	}
+}
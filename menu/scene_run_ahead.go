@@ -0,0 +1,74 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/runahead"
+)
+
+type sceneRunAhead struct {
+	entry
+}
+
+func buildRunAhead() Scene {
+	var list sceneRunAhead
+	list.label = "Run-Ahead"
+
+	list.children = append(list.children, entry{
+		label: "Frames",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if runahead.Frames() == 0 {
+				return "<Off>"
+			}
+			return fmt.Sprintf("<%d>", runahead.Frames())
+		},
+		incr: func(direction int) {
+			runahead.SetFrames(runahead.Frames() + direction)
+		},
+	})
+
+	toggle := func() {
+		runahead.SetSecondInstance(!runahead.SecondInstance())
+	}
+	list.children = append(list.children, entry{
+		label:      "Second Instance",
+		icon:       "subsetting",
+		value:      func() interface{} { return runahead.SecondInstance() },
+		widget:     widgets["switch"],
+		callbackOK: toggle,
+		incr:       func(int) { toggle() },
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneRunAhead) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneRunAhead) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneRunAhead) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneRunAhead) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneRunAhead) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneRunAhead) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneRunAhead) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -153,14 +153,14 @@ func (s *sceneSavestates) render() {
 					e.scale, 0, textColor.Alpha(e.iconAlpha))
 			}
 
-			menu.Font.SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
-			menu.Font.Print(
+			menu.Font().SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
+			menu.Font().Print(
 				(840+1)*menu.ratio,
 				float32(h)*e.yp+fontOffset+1*menu.ratio,
 				0.5*menu.ratio, e.label)
 
-			menu.Font.SetColor(textColor.Alpha(e.labelAlpha))
-			menu.Font.Print(
+			menu.Font().SetColor(textColor.Alpha(e.labelAlpha))
+			menu.Font().Print(
 				840*menu.ratio,
 				float32(h)*e.yp+fontOffset,
 				0.5*menu.ratio, e.label)
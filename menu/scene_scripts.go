@@ -0,0 +1,88 @@
+package menu
+
+import (
+	"fmt"
+	"path/filepath"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/script"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneScripts struct {
+	entry
+}
+
+func buildScripts() Scene {
+	var list sceneScripts
+	list.label = "Scripts"
+
+	list.children = append(list.children, entry{
+		label: "Stop Script",
+		icon:  "close",
+		stringValue: func() string {
+			if !script.Running() {
+				return ""
+			}
+			return "Running"
+		},
+		callbackOK: func() {
+			if !script.Running() {
+				return
+			}
+			script.Stop()
+			ntf.DisplayAndLog(ntf.Info, "Script", "Stopped.")
+		},
+	})
+
+	paths, _ := filepath.Glob(filepath.Join(settings.Current.ScriptsDirectory, "*.lua"))
+	for _, path := range paths {
+		path := path
+		list.children = append(list.children, entry{
+			label: fmt.Sprintf("Run %s", utils.FileName(path)),
+			icon:  "subsetting",
+			callbackOK: func() {
+				if err := script.Load(path); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Script", err.Error())
+					return
+				}
+				ntf.DisplayAndLogf(ntf.Info, "Script", "Running %s.", utils.FileName(path))
+				state.MenuActive = false
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneScripts) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneScripts) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneScripts) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneScripts) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneScripts) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneScripts) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneScripts) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -8,12 +8,16 @@ import (
 	"github.com/fatih/structs"
 	"github.com/go-gl/glfw/v3.4/glfw"
 
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/core"
 	"github.com/libretro/ludo/ludos"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/rewind"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
 )
 
 type sceneSettings struct {
@@ -45,6 +49,15 @@ func buildSettings() Scene {
 		})
 	}
 
+	list.children = append(list.children, entry{
+		label: "Viewport",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildViewport())
+		},
+	})
+
 	fields := structs.Fields(&settings.Current)
 	for _, f := range fields {
 		f := f
@@ -197,6 +210,29 @@ var incrCallbacks = map[string]callbackIncrement{
 		menu.UpdateFilter(filters[i])
 		settings.Save()
 	},
+	"VideoShader": func(f *structs.Field, direction int) {
+		shaders := append([]string{"None"}, video.ShaderPresets()...)
+		v := f.Value().(string)
+		i := utils.IndexOfString(v, shaders)
+		i += direction
+		if i < 0 {
+			i = len(shaders) - 1
+		}
+		if i > len(shaders)-1 {
+			i = 0
+		}
+		f.Set(shaders[i])
+		if err := menu.LoadShader(video.ShaderPresetPath(shaders[i])); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Video", err.Error())
+		}
+		settings.Save()
+	},
+	"VideoOverlay": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
 	"VideoDarkMode": func(f *structs.Field, direction int) {
 		v := f.Value().(bool)
 		v = !v
@@ -224,6 +260,73 @@ var incrCallbacks = map[string]callbackIncrement{
 		f.Set(v)
 		settings.Save()
 	},
+	"Rewind": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		rewind.Init()
+		settings.Save()
+	},
+	"RewindGranularity": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 1 {
+			v = 1
+		}
+		if v > 60 {
+			v = 60
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"RewindBufferSize": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += 10 * direction
+		if v < 10 {
+			v = 10
+		}
+		if v > 1000 {
+			v = 1000
+		}
+		f.Set(v)
+		rewind.Init()
+		settings.Save()
+	},
+	"Achievements": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		if v && state.CoreRunning {
+			core.LoadAchievements()
+		} else {
+			achievements.Unload()
+		}
+		settings.Save()
+	},
+	"NetplayPort": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 1024 {
+			v = 1024
+		}
+		if v > 65535 {
+			v = 65535
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"NetplayDelay": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 0 {
+			v = 0
+		}
+		if v > 8 {
+			v = 8
+		}
+		f.Set(v)
+		settings.Save()
+	},
 	"AudioVolume": func(f *structs.Field, direction int) {
 		v := f.Value().(float32)
 		v += 0.1 * float32(direction)
@@ -237,6 +340,98 @@ var incrCallbacks = map[string]callbackIncrement{
 		audio.SetVolume(v)
 		settings.Save()
 	},
+	"AudioDriver": func(f *structs.Field, direction int) {
+		drivers := []string{"OpenAL", "Null"}
+		v := f.Value().(string)
+		i := utils.IndexOfString(v, drivers)
+		i += direction
+		if i < 0 {
+			i = len(drivers) - 1
+		}
+		if i > len(drivers)-1 {
+			i = 0
+		}
+		f.Set(drivers[i])
+		if state.CoreRunning {
+			audio.Restart()
+		}
+		settings.Save()
+	},
+	"AudioEQFrequency": func(f *structs.Field, direction int) {
+		frequencies := []int{60, 150, 400, 1000, 2500, 6000, 12000}
+		f.Set(cycle(frequencies, f.Value().(int), direction))
+		audio.UpdateFilters()
+		settings.Save()
+	},
+	"AudioEQGain": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 3 * float32(direction)
+		if v < -12 {
+			v = -12
+		}
+		if v > 12 {
+			v = 12
+		}
+		f.Set(v)
+		audio.UpdateFilters()
+		settings.Save()
+	},
+	"AudioBassBoost": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 3 * float32(direction)
+		if v < 0 {
+			v = 0
+		}
+		if v > 12 {
+			v = 12
+		}
+		f.Set(v)
+		audio.UpdateFilters()
+		settings.Save()
+	},
+	"AudioLowPass": func(f *structs.Field, direction int) {
+		cutoffs := []int{0, 3000, 5000, 8000, 12000}
+		f.Set(cycle(cutoffs, f.Value().(int), direction))
+		audio.UpdateFilters()
+		settings.Save()
+	},
+	"AudioReverb": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.1 * float32(direction)
+		if v < 0.05 {
+			v = 0
+		}
+		if v > 1 {
+			v = 1
+		}
+		f.Set(v)
+		audio.UpdateFilters()
+		settings.Save()
+	},
+	"AudioRecordingFormat": func(f *structs.Field, direction int) {
+		formats := []string{"WAV", "FLAC"}
+		v := f.Value().(string)
+		i := utils.IndexOfString(v, formats)
+		i += direction
+		if i < 0 {
+			i = len(formats) - 1
+		}
+		if i > len(formats)-1 {
+			i = 0
+		}
+		f.Set(formats[i])
+		settings.Save()
+	},
+	"FastForwardRatio": func(f *structs.Field, direction int) {
+		ratios := []float32{0, 1.5, 2, 3, 4, 8}
+		f.Set(cycle(ratios, f.Value().(float32), direction))
+		settings.Save()
+	},
+	"SlowMotionRatio": func(f *structs.Field, direction int) {
+		ratios := []float32{0.1, 0.25, 0.5, 0.75}
+		f.Set(cycle(ratios, f.Value().(float32), direction))
+		settings.Save()
+	},
 	"MenuAudioVolume": func(f *structs.Field, direction int) {
 		v := f.Value().(float32)
 		v += 0.1 * float32(direction)
@@ -308,3 +503,22 @@ func (s *sceneSettings) drawHintBar() {
 		stackHintRight(&rstack, guide, "Resume", h)
 	}
 }
+
+// cycle returns the value next to v in the given direction, wrapping around
+// at the ends of the list. Unknown values are taken as the first one.
+func cycle[T comparable](values []T, v T, direction int) T {
+	i := 0
+	for j, value := range values {
+		if value == v {
+			i = j
+		}
+	}
+	i += direction
+	if i < 0 {
+		i = len(values) - 1
+	}
+	if i > len(values)-1 {
+		i = 0
+	}
+	return values[i]
+}
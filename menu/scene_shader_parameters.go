@@ -0,0 +1,91 @@
+package menu
+
+import (
+	"strconv"
+
+	"github.com/libretro/ludo/video"
+)
+
+type sceneShaderParameters struct {
+	entry
+}
+
+func buildShaderParameters() Scene {
+	var list sceneShaderParameters
+	list.label = "Shader Parameters"
+
+	params := menu.ShaderParameters()
+	if len(params) == 0 {
+		list.children = append(list.children, entry{
+			label: "No parameters",
+			icon:  "subsetting",
+		})
+		list.segueMount()
+		return &list
+	}
+
+	toggle := func() {
+		video.SetGameShaderParameters(!video.GameShaderParameters())
+	}
+	list.children = append(list.children, entry{
+		label:      "Save For This Game",
+		icon:       "subsetting",
+		value:      func() interface{} { return video.GameShaderParameters() },
+		widget:     widgets["switch"],
+		callbackOK: toggle,
+		incr:       func(int) { toggle() },
+	})
+
+	for _, p := range params {
+		p := p
+		list.children = append(list.children, entry{
+			label: p.Desc,
+			icon:  "subsetting",
+			stringValue: func() string {
+				v := video.ShaderParameterValue(p)
+				return "<" + strconv.FormatFloat(float64(v), 'f', -1, 32) + ">"
+			},
+			incr: func(direction int) {
+				video.IncrShaderParameter(p, direction)
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label:      "Reset To Defaults",
+		icon:       "reset",
+		callbackOK: video.ResetShaderParameters,
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneShaderParameters) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneShaderParameters) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneShaderParameters) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneShaderParameters) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneShaderParameters) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneShaderParameters) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneShaderParameters) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -298,16 +298,16 @@ func (tabs sceneTabs) render() {
 		stackWidth += e.width*menu.ratio + e.margin*menu.ratio
 
 		if e.labelAlpha > 0 {
-			lw := menu.Font.Width(0.5*menu.ratio, e.label)
-			menu.Font.SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
-			menu.Font.Print(x-lw/2+1*menu.ratio, float32(int(float32(h)/2+(250+1)*menu.ratio)), 0.5*menu.ratio, e.label)
-			menu.Font.SetColor(lablColor.Alpha(e.labelAlpha))
-			menu.Font.Print(x-lw/2, float32(int(float32(h)/2+250*menu.ratio)), 0.5*menu.ratio, e.label)
-			lw = menu.Font.Width(0.4*menu.ratio, e.subLabel)
-			menu.Font.SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
-			menu.Font.Print(x-lw/2+1*menu.ratio, float32(int(float32(h)/2+(330+1)*menu.ratio)), 0.4*menu.ratio, e.subLabel)
-			menu.Font.SetColor(lablColor.Alpha(e.labelAlpha))
-			menu.Font.Print(x-lw/2, float32(int(float32(h)/2+330*menu.ratio)), 0.4*menu.ratio, e.subLabel)
+			lw := menu.Font().Width(0.5*menu.ratio, e.label)
+			menu.Font().SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
+			menu.Font().Print(x-lw/2+1*menu.ratio, float32(int(float32(h)/2+(250+1)*menu.ratio)), 0.5*menu.ratio, e.label)
+			menu.Font().SetColor(lablColor.Alpha(e.labelAlpha))
+			menu.Font().Print(x-lw/2, float32(int(float32(h)/2+250*menu.ratio)), 0.5*menu.ratio, e.label)
+			lw = menu.Font().Width(0.4*menu.ratio, e.subLabel)
+			menu.Font().SetColor(textShadowColor.Alpha(e.labelAlpha / 2))
+			menu.Font().Print(x-lw/2+1*menu.ratio, float32(int(float32(h)/2+(330+1)*menu.ratio)), 0.4*menu.ratio, e.subLabel)
+			menu.Font().SetColor(lablColor.Alpha(e.labelAlpha))
+			menu.Font().Print(x-lw/2, float32(int(float32(h)/2+330*menu.ratio)), 0.4*menu.ratio, e.subLabel)
 		}
 
 		menu.DrawImage(menu.icons["hexagon"],
@@ -321,7 +321,7 @@ func (tabs sceneTabs) render() {
 }
 
 func (s *sceneTabs) drawHintBar() {
-	w, h := menu.Window.GetFramebufferSize()
+	w, h := menu.GetFramebufferSize()
 	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 88*menu.ratio, 0, hintBgColor)
 	menu.DrawRect(0, float32(h)-88*menu.ratio, float32(w), 2*menu.ratio, 0, sepColor)
 
@@ -0,0 +1,133 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+)
+
+type sceneViewport struct {
+	entry
+}
+
+// viewportInt returns an entry changing an integer of the viewport settings by
+// steps, without going under 0
+func viewportInt(label string, step int, field func(vp *settings.Viewport) *int) entry {
+	return entry{
+		label: label,
+		icon:  "subsetting",
+		stringValue: func() string {
+			vp := video.CurrentViewport()
+			return fmt.Sprintf("<%d>", *field(&vp))
+		},
+		incr: func(direction int) {
+			video.UpdateViewport(func(vp *settings.Viewport) {
+				v := field(vp)
+				*v += direction * step
+				if *v < 0 {
+					*v = 0
+				}
+			})
+		},
+	}
+}
+
+func buildViewport() Scene {
+	var list sceneViewport
+	list.label = "Viewport"
+
+	if state.CoreRunning {
+		toggle := func() {
+			video.SetCoreViewport(!video.CoreViewport())
+		}
+		list.children = append(list.children, entry{
+			label:      "Save For This Core",
+			icon:       "subsetting",
+			value:      func() interface{} { return video.CoreViewport() },
+			widget:     widgets["switch"],
+			callbackOK: toggle,
+			incr:       func(int) { toggle() },
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Aspect Ratio",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return "<" + video.CurrentViewport().AspectRatio + ">"
+		},
+		incr: func(direction int) {
+			video.UpdateViewport(func(vp *settings.Viewport) {
+				i := utils.IndexOfString(vp.AspectRatio, video.AspectRatios)
+				i += direction
+				if i < 0 {
+					i = len(video.AspectRatios) - 1
+				}
+				if i > len(video.AspectRatios)-1 {
+					i = 0
+				}
+				vp.AspectRatio = video.AspectRatios[i]
+			})
+		},
+	})
+
+	toggle := func() {
+		video.UpdateViewport(func(vp *settings.Viewport) {
+			vp.IntegerScale = !vp.IntegerScale
+		})
+	}
+	list.children = append(list.children, entry{
+		label:      "Integer Scale",
+		icon:       "subsetting",
+		value:      func() interface{} { return video.CurrentViewport().IntegerScale },
+		widget:     widgets["switch"],
+		callbackOK: toggle,
+		incr:       func(int) { toggle() },
+	})
+
+	list.children = append(list.children,
+		viewportInt("Custom Viewport X", 4, func(vp *settings.Viewport) *int { return &vp.CustomX }),
+		viewportInt("Custom Viewport Y", 4, func(vp *settings.Viewport) *int { return &vp.CustomY }),
+		viewportInt("Custom Viewport Width", 4, func(vp *settings.Viewport) *int { return &vp.CustomWidth }),
+		viewportInt("Custom Viewport Height", 4, func(vp *settings.Viewport) *int { return &vp.CustomHeight }),
+		viewportInt("Crop Overscan Top", 1, func(vp *settings.Viewport) *int { return &vp.CropTop }),
+		viewportInt("Crop Overscan Bottom", 1, func(vp *settings.Viewport) *int { return &vp.CropBottom }),
+		viewportInt("Crop Overscan Left", 1, func(vp *settings.Viewport) *int { return &vp.CropLeft }),
+		viewportInt("Crop Overscan Right", 1, func(vp *settings.Viewport) *int { return &vp.CropRight }),
+	)
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneViewport) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneViewport) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneViewport) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneViewport) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneViewport) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneViewport) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneViewport) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -69,7 +69,7 @@ func drawThumbnail(list *entry, i int, system, gameName string, x, y, w, h, scal
 
 	if list.children[i].thumbnail == 0 || list.children[i].thumbnail == menu.icons["img-dl"] {
 		if _, err := os.Stat(path); !os.IsNotExist(err) {
-			list.children[i].thumbnail = video.NewImage(path)
+			list.children[i].thumbnail = menu.NewImage(path)
 		} else if list.children[i].thumbnail != menu.icons["img-dl"] {
 			list.children[i].thumbnail = menu.icons["img-dl"]
 			go downloadThumbnail(list, i, url, folderPath, path)
@@ -87,7 +87,7 @@ func drawThumbnail(list *entry, i int, system, gameName string, x, y, w, h, scal
 func drawSavestateThumbnail(list *entry, i int, path string, x, y, w, h, scale float32, color video.Color) {
 	if list.children[i].thumbnail == 0 {
 		if _, err := os.Stat(path); !os.IsNotExist(err) {
-			list.children[i].thumbnail = video.NewImage(path)
+			list.children[i].thumbnail = menu.NewImage(path)
 		}
 	}
 
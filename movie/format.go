@@ -0,0 +1,147 @@
+package movie
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// magic identifies movie files and the format version
+const magic = "LMV1"
+
+// flagSavestate is set when the movie starts from a savestate
+const flagSavestate = 1 << 0
+
+// maxSize is the largest decompressed movie Read accepts, enough for hours of
+// inputs and the savestates of the biggest systems
+var maxSize int64 = 512 << 20
+
+// Write encodes the movie to w, compressed with gzip
+func (m *Movie) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+
+	flags := byte(0)
+	if m.State != nil {
+		flags |= flagSavestate
+	}
+	bw.WriteString(magic)
+	bw.WriteByte(flags)
+	writeBytes(bw, []byte(m.Core))
+	writeBytes(bw, []byte(m.Game))
+	writeBytes(bw, m.State)
+
+	binary.Write(bw, binary.BigEndian, uint32(len(m.Frames)))
+	for _, f := range m.Frames {
+		binary.Write(bw, binary.BigEndian, f)
+	}
+
+	binary.Write(bw, binary.BigEndian, uint32(len(m.Hashes)))
+	for _, h := range m.Hashes {
+		binary.Write(bw, binary.BigEndian, h)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read decodes a movie written by Write. The decompressed data is limited to
+// maxSize, and the counts read from it are checked against the remaining data
+// before allocating, so that a corrupted file can't make it allocate more
+// memory than that.
+func Read(r io.Reader) (*Movie, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(io.LimitReader(zr, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.New("movie file too large")
+	}
+	br := bytes.NewReader(data)
+
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("not a movie file")
+	}
+	flags := header[len(magic)]
+
+	m := &Movie{}
+	core, err := readBytes(br)
+	if err != nil {
+		return nil, err
+	}
+	game, err := readBytes(br)
+	if err != nil {
+		return nil, err
+	}
+	m.Core, m.Game = string(core), string(game)
+	if m.State, err = readBytes(br); err != nil {
+		return nil, err
+	}
+	if flags&flagSavestate == 0 {
+		m.State = nil
+	}
+
+	n, err := readCount(br, binary.Size(Frame{}))
+	if err != nil {
+		return nil, err
+	}
+	m.Frames = make([]Frame, n)
+	for i := range m.Frames {
+		if err := binary.Read(br, binary.BigEndian, &m.Frames[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if n, err = readCount(br, binary.Size(Hash{})); err != nil {
+		return nil, err
+	}
+	m.Hashes = make([]Hash, n)
+	for i := range m.Hashes {
+		if err := binary.Read(br, binary.BigEndian, &m.Hashes[i]); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func writeBytes(w io.Writer, b []byte) {
+	binary.Write(w, binary.BigEndian, uint32(len(b)))
+	w.Write(b)
+}
+
+// readCount reads a number of items of size bytes, and checks that they fit
+// in the rest of the data
+func readCount(r *bytes.Reader, size int) (int, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return 0, err
+	}
+	if uint64(n)*uint64(size) > uint64(r.Len()) {
+		return 0, errors.New("truncated movie file")
+	}
+	return int(n), nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readCount(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
@@ -0,0 +1,264 @@
+// Package movie records the input of the players frame by frame, to replay a
+// game session deterministically. A movie starts either from a power-on,
+// which resets the core, or from a savestate. The state of the core is hashed
+// periodically while recording, so that playback can detect when the
+// emulation diverges from the recording.
+package movie
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/libretro/ludo/input"
+	lr "github.com/libretro/ludo/libretro"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// HashInterval is the number of frames between two state hashes
+const HashInterval = 60
+
+// joypadButtons is the number of joypad buttons recorded per player
+const joypadButtons = int(lr.DeviceIDJoypadR3) + 1
+
+// Frame is the input of all the players for a frame. Mouse input is not
+// recorded.
+type Frame struct {
+	Joypad [input.MaxPlayers]uint16 // one bit per button
+	Analog input.AnalogStates
+}
+
+// Hash is the hash of the state of the core before a frame
+type Hash struct {
+	Frame uint32
+	Value uint64
+}
+
+// Movie is a recorded game session
+type Movie struct {
+	Core   string // name of the core library
+	Game   string // name of the game file
+	State  []byte // initial savestate, nil if the movie starts from a power-on
+	Frames []Frame
+	Hashes []Hash
+}
+
+// Modes
+const (
+	// Stopped means no movie is recorded or played
+	Stopped = iota
+	// Recording appends the input of each frame to the movie
+	Recording
+	// Playing replaces the input of the players with the movie
+	Playing
+)
+
+var (
+	current *Movie
+	mode    int
+	path    string // file of the movie being recorded or played
+	frame   int    // index of the next frame to record or play
+	desync  bool   // a desync was already reported
+)
+
+// capture returns the frame of the given input state
+func capture(s input.States, a input.AnalogStates) Frame {
+	var f Frame
+	for p := range s {
+		for id := 0; id < joypadButtons; id++ {
+			if s[p][id] != 0 {
+				f.Joypad[p] |= 1 << id
+			}
+		}
+	}
+	f.Analog = a
+	return f
+}
+
+// hashState returns the hash of the current state of the core
+func hashState() (uint64, error) {
+	b, err := state.Core.Serialize(state.Core.SerializeSize())
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64(), nil
+}
+
+// Mode returns whether a movie is being recorded or played
+func Mode() int {
+	return mode
+}
+
+// Position returns the number of frames recorded or played so far
+func Position() int {
+	return frame
+}
+
+// List returns the movies recorded for a game, most recent first
+func List(gamePath string) []string {
+	paths, _ := filepath.Glob(filepath.Join(settings.Current.MoviesDirectory, "*.lmv"))
+	name := utils.FileName(gamePath)
+	movies := []string{}
+	for _, p := range paths {
+		if strings.HasPrefix(filepath.Base(p), name+"@") {
+			movies = append(movies, p)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(movies)))
+	return movies
+}
+
+// Record starts recording a movie of the current game. If fromState is true,
+// the movie starts from the current state of the core, otherwise the core is
+// reset.
+func Record(fromState bool) error {
+	Stop()
+	m := &Movie{
+		Core: utils.FileName(state.CorePath),
+		Game: filepath.Base(state.GamePath),
+	}
+	if fromState {
+		s, err := state.Core.Serialize(state.Core.SerializeSize())
+		if err != nil {
+			return err
+		}
+		m.State = s
+	} else {
+		state.Core.Reset()
+	}
+	if err := os.MkdirAll(settings.Current.MoviesDirectory, os.ModePerm); err != nil {
+		return err
+	}
+	current = m
+	mode = Recording
+	path = filepath.Join(settings.Current.MoviesDirectory, utils.DatedName(state.GamePath)+".lmv")
+	frame = 0
+	return nil
+}
+
+// Play starts playing the movie at p
+func Play(p string) error {
+	Stop()
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m, err := Read(f)
+	if err != nil {
+		return err
+	}
+
+	if m.Core != utils.FileName(state.CorePath) {
+		ntf.DisplayAndLogf(ntf.Warning, "Movie", "Recorded with %s.", m.Core)
+	}
+	if m.Game != filepath.Base(state.GamePath) {
+		ntf.DisplayAndLogf(ntf.Warning, "Movie", "Recorded with %s.", m.Game)
+	}
+	if m.State != nil {
+		if err := state.Core.Unserialize(m.State, state.Core.SerializeSize()); err != nil {
+			return err
+		}
+	} else {
+		state.Core.Reset()
+	}
+
+	current = m
+	mode = Playing
+	path = p
+	frame = 0
+	desync = false
+	state.Core.SetInputState(State)
+	return nil
+}
+
+// Stop ends the recording or playback. A recorded movie is saved.
+func Stop() error {
+	defer func() {
+		current = nil
+		mode = Stopped
+	}()
+
+	switch mode {
+	case Playing:
+		state.Core.SetInputState(input.State)
+	case Recording:
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return current.Write(f)
+	}
+	return nil
+}
+
+// Process records or plays the input of the frame about to be emulated. It
+// is meant to be called once per frame, before running the core.
+func Process() {
+	switch mode {
+	case Recording:
+		if frame%HashInterval == 0 {
+			h, err := hashState()
+			if err == nil {
+				current.Hashes = append(current.Hashes, Hash{uint32(frame), h})
+			}
+		}
+		current.Frames = append(current.Frames, capture(input.NewState, input.NewAnalogState))
+		frame++
+	case Playing:
+		if frame >= len(current.Frames) {
+			Stop()
+			ntf.DisplayAndLog(ntf.Info, "Movie", "Playback finished.")
+			return
+		}
+		if frame%HashInterval == 0 && !desync {
+			check()
+		}
+		frame++
+	}
+}
+
+// check compares the state of the core with the recorded hash of the frame
+func check() {
+	for _, h := range current.Hashes {
+		if int(h.Frame) != frame {
+			continue
+		}
+		v, err := hashState()
+		if err == nil && v != h.Value {
+			desync = true
+			ntf.DisplayAndLogf(ntf.Warning, "Movie", "Desync detected at frame %d.", frame)
+		}
+		return
+	}
+}
+
+// State is the input state callback used during playback. It returns the
+// recorded input of the frame being emulated.
+func State(port uint, device uint32, index uint, id uint) int16 {
+	if current == nil || frame == 0 || port >= input.MaxPlayers {
+		return 0
+	}
+	f := current.Frames[frame-1]
+	switch device {
+	case lr.DeviceJoypad:
+		if id >= uint(joypadButtons) || index > 0 {
+			return 0
+		}
+		return int16(f.Joypad[port] >> id & 1)
+	case lr.DeviceAnalog:
+		if index > uint(lr.DeviceIndexAnalogRight) || id > uint(lr.DeviceIDAnalogY) {
+			return 0
+		}
+		return f.Analog[port][index][id]
+	}
+	return 0
+}
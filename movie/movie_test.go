@@ -0,0 +1,133 @@
+package movie
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/libretro/ludo/input"
+	lr "github.com/libretro/ludo/libretro"
+)
+
+func Test_Read(t *testing.T) {
+	t.Run("Roundtrips a movie starting from a savestate", func(t *testing.T) {
+		m := &Movie{
+			Core:   "snes9x_libretro",
+			Game:   "Super Mario World.sfc",
+			State:  []byte{1, 2, 3, 4},
+			Frames: []Frame{{Joypad: [input.MaxPlayers]uint16{1, 0x8000}}, {}},
+			Hashes: []Hash{{0, 0xDEADBEEF}},
+		}
+		m.Frames[1].Analog[0][lr.DeviceIndexAnalogLeft][lr.DeviceIDAnalogX] = -32767
+
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		got, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, m) {
+			t.Errorf("Read() = %+v, want %+v", got, m)
+		}
+	})
+
+	t.Run("Keeps the power-on marker", func(t *testing.T) {
+		m := &Movie{Frames: []Frame{}, Hashes: []Hash{}}
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		got, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if got.State != nil {
+			t.Errorf("Read().State = %v, want nil", got.State)
+		}
+	})
+
+	t.Run("Rejects counts larger than the file", func(t *testing.T) {
+		m := &Movie{Frames: []Frame{{}, {}}, Hashes: []Hash{}}
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		zr, _ := gzip.NewReader(&buf)
+		data, _ := io.ReadAll(zr)
+		// The frame count follows the magic, the flags and 3 empty byte strings
+		binary.BigEndian.PutUint32(data[len(magic)+1+3*4:], 0xffffffff)
+		buf.Reset()
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+
+		if _, err := Read(&buf); err == nil {
+			t.Errorf("Read() error = nil, want an error")
+		}
+	})
+
+	t.Run("Rejects files decompressing past the limit", func(t *testing.T) {
+		defer func(size int64) { maxSize = size }(maxSize)
+		maxSize = 1024
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(magic))
+		zw.Write(make([]byte, 4096))
+		zw.Close()
+
+		if _, err := Read(&buf); err == nil || err.Error() != "movie file too large" {
+			t.Errorf("Read() error = %v, want %v", err, "movie file too large")
+		}
+	})
+
+	t.Run("Rejects other files", func(t *testing.T) {
+		if _, err := Read(bytes.NewReader([]byte("not a movie"))); err == nil {
+			t.Errorf("Read() error = nil, want an error")
+		}
+	})
+}
+
+func Test_State(t *testing.T) {
+	var s input.States
+	var a input.AnalogStates
+	s[0][lr.DeviceIDJoypadA] = 1
+	s[1][lr.DeviceIDJoypadStart] = 1
+	s[0][input.ActionMenuToggle] = 1
+	a[1][lr.DeviceIndexAnalogRight][lr.DeviceIDAnalogY] = 1234
+
+	current = &Movie{Frames: []Frame{capture(s, a)}}
+	frame = 1
+	defer func() {
+		current = nil
+		frame = 0
+	}()
+
+	tests := []struct {
+		name   string
+		port   uint
+		device uint32
+		index  uint
+		id     uint
+		want   int16
+	}{
+		{"Pressed button", 0, lr.DeviceJoypad, 0, uint(lr.DeviceIDJoypadA), 1},
+		{"Released button", 0, lr.DeviceJoypad, 0, uint(lr.DeviceIDJoypadB), 0},
+		{"Button of the second player", 1, lr.DeviceJoypad, 0, uint(lr.DeviceIDJoypadStart), 1},
+		{"Hotkeys are not recorded", 0, lr.DeviceJoypad, 0, uint(input.ActionMenuToggle), 0},
+		{"Analog stick", 1, lr.DeviceAnalog, uint(lr.DeviceIndexAnalogRight), uint(lr.DeviceIDAnalogY), 1234},
+		{"Invalid port", input.MaxPlayers, lr.DeviceJoypad, 0, uint(lr.DeviceIDJoypadA), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := State(tt.port, tt.device, tt.index, tt.id); got != tt.want {
+				t.Errorf("State() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package netplay lets two players play the same game across machines. Peers
+// exchange the joypad input of their player every frame over UDP, and use
+// savestates to roll back and emulate frames again when an input arrives late.
+// Analog sticks are not synchronized.
+package netplay
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/libretro/ludo/input"
+	lr "github.com/libretro/ludo/libretro"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// Current is the running session, if any
+var Current *Session
+
+// coreEmulator drives the libretro core for a session
+type coreEmulator struct{}
+
+func (coreEmulator) Reset() {
+	state.Core.Reset()
+}
+
+func (coreEmulator) Serialize() ([]byte, error) {
+	return state.Core.Serialize(state.Core.SerializeSize())
+}
+
+func (coreEmulator) Unserialize(b []byte) error {
+	return state.Core.Unserialize(b, state.Core.SerializeSize())
+}
+
+func (coreEmulator) Run(inputs [Players]uint16, replay bool) {
+	for p, in := range inputs {
+		for id := uint32(0); id <= lr.DeviceIDJoypadR3; id++ {
+			input.NewState[p][id] = int16(in >> id & 1)
+		}
+	}
+	state.Replaying = replay
+	state.SkipVideo = replay
+	state.Core.Run()
+	state.Replaying = false
+	state.SkipVideo = false
+}
+
+// encode packs the joypad buttons of a player in a bitmask
+func encode(s input.States, p int) uint16 {
+	var in uint16
+	for id := uint32(0); id <= lr.DeviceIDJoypadR3; id++ {
+		if s[p][id] != 0 {
+			in |= 1 << id
+		}
+	}
+	return in
+}
+
+// identity identifies the running core, by its name and version, and the
+// game, by the CRC32 of its content
+func identity(content uint32) string {
+	si := state.Core.GetSystemInfo()
+	return fmt.Sprintf("%s %s %08x", si.LibraryName, si.LibraryVersion, content)
+}
+
+// Host starts a session and waits for a peer on the netplay port. content is
+// the CRC32 of the content of the game, peers have to run the same.
+func Host(content uint32) error {
+	Disconnect()
+	s, err := Listen(coreEmulator{}, fmt.Sprintf(":%d", settings.Current.NetplayPort))
+	if err != nil {
+		return err
+	}
+	s.Delay = settings.Current.NetplayDelay
+	s.Identity = identity(content)
+	Current = s
+	return nil
+}
+
+// Join starts a session with the host at addr. content is the CRC32 of the
+// content of the game, the host has to run the same.
+func Join(addr string, content uint32) error {
+	Disconnect()
+	s, err := Dial(coreEmulator{}, addr)
+	if err != nil {
+		return err
+	}
+	s.Delay = settings.Current.NetplayDelay
+	s.Identity = identity(content)
+	Current = s
+	return nil
+}
+
+// Disconnect ends the current session
+func Disconnect() {
+	if Current == nil {
+		return
+	}
+	if err := Current.Close(); err != nil {
+		log.Println("[Netplay]:", err)
+	}
+	Current = nil
+}
+
+// Active returns true if a session is running
+func Active() bool {
+	return Current != nil
+}
+
+// Run exchanges the input of the local player and emulates a frame, in place
+// of running the core directly. The local player uses the first joypad.
+func Run() {
+	wasConnected := Current.Connected()
+	_, err := Current.Step(encode(input.NewState, 0))
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Netplay", err.Error())
+		Disconnect()
+		return
+	}
+	if !wasConnected && Current.Connected() {
+		ntf.DisplayAndLogf(ntf.Success, "Netplay", "Connected, you are player %d.", Current.Local+1)
+	}
+}
@@ -0,0 +1,66 @@
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// magic identifies netplay packets and the protocol version
+const magic = 0x4C4E5032 // LNP2
+
+// packet kinds
+const (
+	packetHello   = iota + 1 // sent by the joining peer until it is welcomed
+	packetWelcome            // sent by the host in response to a hello
+	packetInput              // carries the unacknowledged inputs of the sender
+	packetQuit               // sent when a peer closes the session
+	packetReject             // sent by the host to a peer running another core or game
+)
+
+// maxInputsPerPacket caps the number of inputs sent at once
+const maxInputsPerPacket = 64
+
+// packet is a decoded netplay datagram. Input packets carry the inputs of
+// the sender starting at frame Start, and Ack, the number of frames of the
+// receiver's inputs the sender already has. Hello packets carry the Identity
+// of the sender, after the inputs.
+type packet struct {
+	Kind     byte
+	Ack      uint32
+	Start    uint32
+	Inputs   []uint16
+	Identity string
+}
+
+func (p packet) encode() []byte {
+	b := make([]byte, 0, 14+2*len(p.Inputs)+len(p.Identity))
+	b = binary.BigEndian.AppendUint32(b, magic)
+	b = append(b, p.Kind)
+	b = binary.BigEndian.AppendUint32(b, p.Ack)
+	b = binary.BigEndian.AppendUint32(b, p.Start)
+	b = append(b, byte(len(p.Inputs)))
+	for _, in := range p.Inputs {
+		b = binary.BigEndian.AppendUint16(b, in)
+	}
+	return append(b, p.Identity...)
+}
+
+func decode(b []byte) (packet, error) {
+	var p packet
+	if len(b) < 14 || binary.BigEndian.Uint32(b) != magic {
+		return p, errors.New("invalid packet")
+	}
+	p.Kind = b[4]
+	p.Ack = binary.BigEndian.Uint32(b[5:])
+	p.Start = binary.BigEndian.Uint32(b[9:])
+	n := int(b[13])
+	if len(b) < 14+2*n {
+		return p, errors.New("invalid packet length")
+	}
+	p.Inputs = make([]uint16, n)
+	for i := range p.Inputs {
+		p.Inputs[i] = binary.BigEndian.Uint16(b[14+2*i:])
+	}
+	p.Identity = string(b[14+2*n:])
+	return p, nil
+}
@@ -0,0 +1,365 @@
+package netplay
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+)
+
+// Players is the number of players in a session
+const Players = 2
+
+// maxPrediction is the number of frames a peer can emulate ahead of the last
+// input it received from the other peer. Past that, it waits.
+const maxPrediction = 8
+
+// timeout is the delay after which a silent peer is considered gone
+const timeout = 10 * time.Second
+
+// Emulator is what a session drives. It must be deterministic: running the
+// same inputs from the same state must give the same state.
+type Emulator interface {
+	Reset()
+	Serialize() ([]byte, error)
+	Unserialize([]byte) error
+	// Run emulates a frame. replay is true when the frame is emulated again
+	// after a rollback, in which case its audio and video can be skipped.
+	Run(inputs [Players]uint16, replay bool)
+}
+
+// Session is a two players netplay session over UDP. Each peer emulates the
+// game locally, predicting the input of the other peer by repeating its last
+// known input. When the real input arrives and differs from the prediction,
+// the peer rolls back to the state of that frame and emulates the following
+// frames again.
+type Session struct {
+	Local int // port of the local player, 0 for the host and 1 for the peer joining
+	Delay int // frames of delay added to the local input to reduce rollbacks
+	// Identity identifies the core and the game. The host rejects peers with
+	// another identity, as their emulation would desync.
+	Identity string
+
+	emu       Emulator
+	conn      *net.UDPConn
+	remote    *net.UDPAddr
+	packets   chan received
+	connected bool
+	lastSeen  time.Time
+
+	frame     uint32            // next frame to emulate
+	confirmed uint32            // number of frames for which the remote input is known
+	acked     uint32            // number of frames of local input the remote has
+	local     map[uint32]uint16 // local inputs by frame
+	inputs    map[uint32]uint16 // confirmed remote inputs by frame
+	used      map[uint32]uint16 // remote inputs used to emulate frames, possibly predicted
+	states    map[uint32][]byte // states before emulating frames
+	rollback  int64             // earliest frame to emulate again, -1 if none
+}
+
+type received struct {
+	packet
+	from *net.UDPAddr
+}
+
+func newSession(emu Emulator, conn *net.UDPConn, local int) *Session {
+	s := &Session{
+		Local:    local,
+		emu:      emu,
+		conn:     conn,
+		packets:  make(chan received, 256),
+		lastSeen: time.Now(),
+		local:    map[uint32]uint16{},
+		inputs:   map[uint32]uint16{},
+		used:     map[uint32]uint16{},
+		states:   map[uint32][]byte{},
+		rollback: -1,
+	}
+	go s.read()
+	return s
+}
+
+// Listen starts a session as the host, waiting for a peer on the given UDP
+// address
+func Listen(emu Emulator, addr string) (*Session, error) {
+	laddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(emu, conn, 0), nil
+}
+
+// Dial starts a session by joining the host at the given UDP address
+func Dial(emu Emulator, addr string) (*Session, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	s := newSession(emu, conn, 1)
+	s.remote = raddr
+	return s, nil
+}
+
+// Addr returns the local address of the session
+func (s *Session) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Connected returns true once both peers found each other
+func (s *Session) Connected() bool {
+	return s.connected
+}
+
+// Frame returns the number of frames emulated since the session started
+func (s *Session) Frame() uint32 {
+	return s.frame
+}
+
+// Confirmed returns the number of frames for which the remote input is known
+func (s *Session) Confirmed() uint32 {
+	return s.confirmed
+}
+
+// Close notifies the peer and closes the connection
+func (s *Session) Close() error {
+	if s.remote != nil {
+		s.sendPacket(packet{Kind: packetQuit})
+	}
+	return s.conn.Close()
+}
+
+func (s *Session) read() {
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			close(s.packets)
+			return
+		}
+		p, err := decode(buf[:n])
+		if err != nil {
+			continue
+		}
+		select {
+		case s.packets <- received{p, from}:
+		default: // drop the packet, the inputs will be sent again
+		}
+	}
+}
+
+func (s *Session) sendPacket(p packet) {
+	s.conn.WriteToUDP(p.encode(), s.remote)
+}
+
+// sendInputs sends the local inputs the remote doesn't have yet
+func (s *Session) sendInputs() {
+	end := s.frame + uint32(s.Delay) + 1
+	if end-s.acked > maxInputsPerPacket {
+		end = s.acked + maxInputsPerPacket
+	}
+	p := packet{Kind: packetInput, Ack: s.confirmed, Start: s.acked}
+	for f := s.acked; f < end; f++ {
+		p.Inputs = append(p.Inputs, s.local[f])
+	}
+	s.sendPacket(p)
+}
+
+func (s *Session) start() {
+	s.connected = true
+	s.emu.Reset()
+}
+
+// receive processes the pending packets
+func (s *Session) receive() error {
+	for {
+		var r received
+		var ok bool
+		select {
+		case r, ok = <-s.packets:
+			if !ok {
+				return errors.New("connection closed")
+			}
+		default:
+			if s.connected && time.Since(s.lastSeen) > timeout {
+				return errors.New("peer timed out")
+			}
+			return nil
+		}
+
+		if s.remote != nil && r.from.String() != s.remote.String() {
+			continue
+		}
+		s.lastSeen = time.Now()
+
+		switch r.Kind {
+		case packetHello:
+			if s.Local != 0 {
+				continue
+			}
+			if r.Identity != s.Identity {
+				log.Printf("[Netplay]: rejected %s, running %q instead of %q", r.from, r.Identity, s.Identity)
+				s.conn.WriteToUDP(packet{Kind: packetReject}.encode(), r.from)
+				continue
+			}
+			s.remote = r.from
+			s.sendPacket(packet{Kind: packetWelcome})
+			if !s.connected {
+				s.start()
+			}
+		case packetWelcome:
+			if !s.connected && s.Local != 0 {
+				s.start()
+			}
+		case packetInput:
+			if !s.connected {
+				if s.Local == 0 {
+					continue
+				}
+				// The welcome was lost, but the host already started
+				s.start()
+			}
+			s.receiveInputs(r.packet)
+		case packetReject:
+			if s.Local != 0 && !s.connected {
+				return errors.New("the host is running another core or game")
+			}
+		case packetQuit:
+			return errors.New("peer left")
+		}
+	}
+}
+
+func (s *Session) receiveInputs(p packet) {
+	if p.Ack > s.acked {
+		s.acked = p.Ack
+	}
+	for i, in := range p.Inputs {
+		f := p.Start + uint32(i)
+		if f != s.confirmed {
+			continue
+		}
+		s.inputs[f] = in
+		s.confirmed++
+		if used, ok := s.used[f]; ok && used != in && (s.rollback < 0 || int64(f) < s.rollback) {
+			s.rollback = int64(f)
+		}
+	}
+}
+
+// remoteInput returns the input of the remote player for a frame, or a
+// prediction if it is not known yet
+func (s *Session) remoteInput(f uint32) uint16 {
+	if in, ok := s.inputs[f]; ok {
+		return in
+	}
+	if s.confirmed > 0 {
+		return s.inputs[s.confirmed-1]
+	}
+	return 0
+}
+
+// advance emulates the next frame
+func (s *Session) advance(replay bool) error {
+	state, err := s.emu.Serialize()
+	if err != nil {
+		return err
+	}
+	s.states[s.frame] = state
+
+	var inputs [Players]uint16
+	inputs[s.Local] = s.local[s.frame]
+	remote := s.remoteInput(s.frame)
+	inputs[1-s.Local] = remote
+	s.used[s.frame] = remote
+
+	s.emu.Run(inputs, replay)
+	s.frame++
+	return nil
+}
+
+// resimulate rolls back to the first mispredicted frame and emulates the
+// following frames again with the corrected inputs
+func (s *Session) resimulate() error {
+	from := uint32(s.rollback)
+	to := s.frame
+	s.rollback = -1
+
+	if err := s.emu.Unserialize(s.states[from]); err != nil {
+		return err
+	}
+	s.frame = from
+	for s.frame < to {
+		if err := s.advance(true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune forgets the states and predictions that can't be rolled back to
+func (s *Session) prune() {
+	for f := range s.states {
+		if f < s.confirmed {
+			delete(s.states, f)
+			delete(s.used, f)
+		}
+	}
+	for f := range s.inputs {
+		if f+1 < s.confirmed && f < s.frame {
+			delete(s.inputs, f)
+		}
+	}
+	// Local inputs are kept until the remote has them and they can't be
+	// needed by a rollback anymore
+	for f := range s.local {
+		if f < s.acked && f < s.confirmed && f < s.frame {
+			delete(s.local, f)
+		}
+	}
+}
+
+// Step exchanges inputs with the remote peer and emulates a frame. local is
+// the input of the local player. It returns false if no frame was emulated,
+// because the peer hasn't connected yet or is too far behind.
+func (s *Session) Step(local uint16) (bool, error) {
+	if err := s.receive(); err != nil {
+		return false, err
+	}
+
+	if !s.connected {
+		if s.Local != 0 {
+			s.sendPacket(packet{Kind: packetHello, Identity: s.Identity})
+		}
+		return false, nil
+	}
+
+	f := s.frame + uint32(s.Delay)
+	if _, ok := s.local[f]; !ok {
+		s.local[f] = local
+	}
+	s.sendInputs()
+
+	if s.rollback >= 0 {
+		if err := s.resimulate(); err != nil {
+			return false, err
+		}
+	}
+
+	if s.frame >= s.confirmed+maxPrediction {
+		return false, nil
+	}
+
+	if err := s.advance(false); err != nil {
+		return false, err
+	}
+	s.prune()
+	return true, nil
+}
@@ -0,0 +1,173 @@
+package netplay
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeEmulator hashes its inputs into its state, and records the state
+// reached after each frame
+type fakeEmulator struct {
+	frame   uint32
+	hash    uint64
+	history map[uint32]uint64
+	replays int
+}
+
+func newFakeEmulator() *fakeEmulator {
+	return &fakeEmulator{history: map[uint32]uint64{}}
+}
+
+func (e *fakeEmulator) Reset() {
+	e.frame, e.hash = 0, 0
+}
+
+func (e *fakeEmulator) Serialize() ([]byte, error) {
+	b := binary.BigEndian.AppendUint32(nil, e.frame)
+	return binary.BigEndian.AppendUint64(b, e.hash), nil
+}
+
+func (e *fakeEmulator) Unserialize(b []byte) error {
+	e.frame = binary.BigEndian.Uint32(b)
+	e.hash = binary.BigEndian.Uint64(b[4:])
+	return nil
+}
+
+func (e *fakeEmulator) Run(inputs [Players]uint16, replay bool) {
+	e.hash = e.hash*31 + uint64(inputs[0])<<16 + uint64(inputs[1]) + 7
+	e.frame++
+	e.history[e.frame] = e.hash
+	if replay {
+		e.replays++
+	}
+}
+
+// expected computes the states reached with the given inputs
+func expected(inputs [Players][]uint16, frames int) map[uint32]uint64 {
+	e := newFakeEmulator()
+	for f := 0; f < frames; f++ {
+		e.Run([Players]uint16{inputs[0][f], inputs[1][f]}, false)
+	}
+	return e.history
+}
+
+func Test_Session(t *testing.T) {
+	t.Run("Two peers on loopback emulate the same frames", func(t *testing.T) {
+		emuA, emuB := newFakeEmulator(), newFakeEmulator()
+		host, err := Listen(emuA, "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer host.Close()
+		peer, err := Dial(emuB, host.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer peer.Close()
+		peer.Delay = 2
+
+		const frames = 300
+		var inputs [Players][]uint16
+		for f := 0; f < frames+maxPrediction+peer.Delay; f++ {
+			// Inputs change often, to force mispredictions
+			inputs[0] = append(inputs[0], uint16(f/3%4))
+			inputs[1] = append(inputs[1], uint16(f/5%7)<<4)
+		}
+		// The input of the first frames of the delayed peer is never read
+		inputs[1][0], inputs[1][1] = 0, 0
+
+		deadline := time.Now().Add(10 * time.Second)
+		done := func(s *Session) bool { return s.Confirmed() >= frames && s.Frame() >= frames }
+		for !(done(host) && done(peer)) && time.Now().Before(deadline) {
+			// The host runs faster than the peer and has to predict its inputs
+			for i := 0; i < 2; i++ {
+				if _, err := host.Step(inputs[0][host.Frame()]); err != nil {
+					t.Fatalf("Step() error = %v", err)
+				}
+			}
+			if _, err := peer.Step(inputs[1][int(peer.Frame())+peer.Delay]); err != nil {
+				t.Fatalf("Step() error = %v", err)
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+		if !host.Connected() || !peer.Connected() {
+			t.Fatalf("Connected() = %v, %v, want true, true", host.Connected(), peer.Connected())
+		}
+
+		// Both peers have confirmed the inputs of the first frames, their
+		// states must match each other, whatever rollbacks happened
+		want := expected(inputs, frames)
+		for f := uint32(1); f <= frames; f++ {
+			if emuA.history[f] != want[f] || emuB.history[f] != want[f] {
+				t.Fatalf("frame %d: host state = %x, peer state = %x, want %x", f, emuA.history[f], emuB.history[f], want[f])
+			}
+		}
+		if emuA.replays+emuB.replays == 0 {
+			t.Errorf("replays = 0, want some rollbacks")
+		}
+	})
+}
+
+func Test_Session_identity(t *testing.T) {
+	t.Run("The host rejects a peer running another game", func(t *testing.T) {
+		host, err := Listen(newFakeEmulator(), "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer host.Close()
+		host.Identity = "core 1.0 0000cafe"
+		peer, err := Dial(newFakeEmulator(), host.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer peer.Close()
+		peer.Identity = "core 1.0 0000beef"
+
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := host.Step(0); err != nil {
+				t.Fatalf("host Step() error = %v", err)
+			}
+			if _, err := peer.Step(0); err != nil {
+				if host.Connected() {
+					t.Errorf("host Connected() = true, want false")
+				}
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Errorf("peer Step() error = nil, want an error")
+	})
+}
+
+func Test_packet(t *testing.T) {
+	t.Run("Encodes and decodes input packets", func(t *testing.T) {
+		p := packet{Kind: packetInput, Ack: 12, Start: 10, Inputs: []uint16{1, 0x8001, 3}}
+		got, err := decode(p.encode())
+		if err != nil {
+			t.Fatalf("decode() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("decode() = %v, want %v", got, p)
+		}
+	})
+
+	t.Run("Encodes and decodes the identity of hello packets", func(t *testing.T) {
+		p := packet{Kind: packetHello, Inputs: []uint16{}, Identity: "Snes9x 1.62 1234abcd"}
+		got, err := decode(p.encode())
+		if err != nil {
+			t.Fatalf("decode() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("decode() = %v, want %v", got, p)
+		}
+	})
+
+	t.Run("Rejects foreign datagrams", func(t *testing.T) {
+		if _, err := decode([]byte("hello world, this is not netplay")); err == nil {
+			t.Errorf("decode() error = %v, want an error", err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+// Package pacing controls the speed of the emulation. At normal speed, frames
+// are paced by the vsync of the display. In fast-forward and slow motion, the
+// vsync is disabled and the main loop sleeps to run the core at a multiple of
+// its native frame rate.
+package pacing
+
+import (
+	"time"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// maxLate is the number of frames the emulation can fall behind before the
+// limiter stops trying to catch up
+const maxLate = 4
+
+// Limiter computes how long to wait between frames to reach a target rate
+type Limiter struct {
+	next time.Time // when the next frame is due
+}
+
+// Delay returns how long to wait at now before running the next frame at
+// fps frames per second multiplied by ratio. A ratio of 0 means no limit.
+func (l *Limiter) Delay(now time.Time, fps, ratio float64) time.Duration {
+	if fps <= 0 || ratio <= 0 {
+		l.Reset()
+		return 0
+	}
+	period := time.Duration(float64(time.Second) / (fps * ratio))
+	if l.next.IsZero() || now.Sub(l.next) > maxLate*period {
+		l.next = now
+	}
+	l.next = l.next.Add(period)
+	if d := l.next.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Reset forgets the previous frames, like after a pause
+func (l *Limiter) Reset() {
+	l.next = time.Time{}
+}
+
+var (
+	fps     float64
+	limiter Limiter
+)
+
+// Init sets the native frame rate of the core
+func Init(f float64) {
+	fps = f
+	limiter.Reset()
+}
+
+// Ratio returns the speed of the emulation relative to the native frame rate
+// of the core. It is 1 at normal speed and 0 in unlimited fast-forward.
+func Ratio() float64 {
+	switch {
+	case state.SlowMotion:
+		return float64(settings.Current.SlowMotionRatio)
+	case state.FastForward:
+		return float64(settings.Current.FastForwardRatio)
+	}
+	return 1
+}
+
+// Wait sleeps until the next frame is due. It doesn't wait at normal speed,
+// where the vsync paces the frames, nor in unlimited fast-forward.
+func Wait() {
+	ratio := Ratio()
+	if ratio == 1 {
+		limiter.Reset()
+		return
+	}
+	time.Sleep(limiter.Delay(time.Now(), fps, ratio))
+}
@@ -0,0 +1,70 @@
+package pacing
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Limiter_Delay(t *testing.T) {
+	start := time.Unix(0, 0)
+	frame := time.Second / 50
+
+	t.Run("Waits a frame period after the first frame", func(t *testing.T) {
+		var l Limiter
+		if got := l.Delay(start, 50, 1); got != frame {
+			t.Errorf("Delay() = %v, want %v", got, frame)
+		}
+	})
+
+	t.Run("Applies the ratio to the frame period", func(t *testing.T) {
+		tests := []struct {
+			ratio float64
+			want  time.Duration
+		}{
+			{0.25, frame * 4},
+			{0.5, frame * 2},
+			{2, frame / 2},
+			{4, frame / 4},
+		}
+		for _, tt := range tests {
+			var l Limiter
+			if got := l.Delay(start, 50, tt.ratio); got != tt.want {
+				t.Errorf("Delay(%v) = %v, want %v", tt.ratio, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Subtracts the time spent running the frame", func(t *testing.T) {
+		var l Limiter
+		l.Delay(start, 50, 1)
+		now := start.Add(frame + 5*time.Millisecond)
+		if got := l.Delay(now, 50, 1); got != frame-5*time.Millisecond {
+			t.Errorf("Delay() = %v, want %v", got, frame-5*time.Millisecond)
+		}
+	})
+
+	t.Run("Catches up when slightly late", func(t *testing.T) {
+		var l Limiter
+		l.Delay(start, 50, 1)
+		now := start.Add(3 * frame)
+		if got := l.Delay(now, 50, 1); got != 0 {
+			t.Errorf("Delay() = %v, want %v", got, 0)
+		}
+	})
+
+	t.Run("Gives up catching up when far behind", func(t *testing.T) {
+		var l Limiter
+		l.Delay(start, 50, 1)
+		now := start.Add(time.Second)
+		if got := l.Delay(now, 50, 1); got != frame {
+			t.Errorf("Delay() = %v, want %v", got, frame)
+		}
+	})
+
+	t.Run("Doesn't wait without limit", func(t *testing.T) {
+		var l Limiter
+		if got := l.Delay(start, 50, 0); got != 0 {
+			t.Errorf("Delay() = %v, want %v", got, 0)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package patch
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// BPS actions
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// bpsDecode reads a variable length number from the patch, in the same
+// encoding as UPS. Numbers can't take more than 8 bytes, to not overflow.
+func bpsDecode(patch []byte, offset *int) (int, error) {
+	data := 0
+	shift := 1
+	for i := 0; i < 8; i++ {
+		if *offset >= len(patch) {
+			return 0, errors.New("unexpected end of patch")
+		}
+		x := patch[*offset]
+		*offset++
+		data += int(x&0x7f) * shift
+		if x&0x80 != 0 {
+			return data, nil
+		}
+		shift <<= 7
+		data += shift
+	}
+	return 0, errors.New("invalid patch")
+}
+
+// bpsSigned decodes a relative offset, the sign is stored in the lowest bit
+func bpsSigned(data int) int {
+	if data&1 != 0 {
+		return -(data >> 1)
+	}
+	return data >> 1
+}
+
+func applyBPS(patch, source []byte) (*[]byte, error) {
+	if len(patch) < 19 {
+		return nil, errors.New("patch too small")
+	}
+
+	if string(patch[0:4]) != "BPS1" {
+		return nil, errors.New("invalid patch header")
+	}
+
+	footer := patch[len(patch)-12:]
+	sourceChecksum := binary.LittleEndian.Uint32(footer[0:4])
+	targetChecksum := binary.LittleEndian.Uint32(footer[4:8])
+	patchChecksum := binary.LittleEndian.Uint32(footer[8:12])
+
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != patchChecksum {
+		return nil, errors.New("invalid patch")
+	}
+
+	offset := 4
+	sourceSize, err := bpsDecode(patch, &offset)
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := bpsDecode(patch, &offset)
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := bpsDecode(patch, &offset)
+	if err != nil {
+		return nil, err
+	}
+	if metadataSize > len(patch)-12-offset {
+		return nil, errors.New("invalid patch")
+	}
+	offset += metadataSize
+
+	if len(source) != sourceSize || crc32.ChecksumIEEE(source) != sourceChecksum {
+		return nil, errors.New("invalid source")
+	}
+
+	target := make([]byte, 0, capacity(targetSize, source, patch))
+	sourceRelativeOffset := 0
+	targetRelativeOffset := 0
+
+	for offset < len(patch)-12 {
+		data, err := bpsDecode(patch, &offset)
+		if err != nil {
+			return nil, err
+		}
+		command := data & 3
+		length := (data >> 2) + 1
+
+		outputOffset := len(target)
+		if length > targetSize-outputOffset {
+			return nil, errors.New("invalid target")
+		}
+
+		switch command {
+		case bpsSourceRead:
+			if outputOffset > len(source) || length > len(source)-outputOffset {
+				return nil, errors.New("invalid source")
+			}
+			target = append(target, source[outputOffset:outputOffset+length]...)
+		case bpsTargetRead:
+			if length > len(patch)-12-offset {
+				return nil, errors.New("invalid patch")
+			}
+			target = append(target, patch[offset:offset+length]...)
+			offset += length
+		case bpsSourceCopy:
+			data, err := bpsDecode(patch, &offset)
+			if err != nil {
+				return nil, err
+			}
+			sourceRelativeOffset += bpsSigned(data)
+			if sourceRelativeOffset < 0 || sourceRelativeOffset > len(source) || length > len(source)-sourceRelativeOffset {
+				return nil, errors.New("invalid source")
+			}
+			target = append(target, source[sourceRelativeOffset:sourceRelativeOffset+length]...)
+			sourceRelativeOffset += length
+		case bpsTargetCopy:
+			data, err := bpsDecode(patch, &offset)
+			if err != nil {
+				return nil, err
+			}
+			targetRelativeOffset += bpsSigned(data)
+			if targetRelativeOffset < 0 || targetRelativeOffset >= outputOffset {
+				return nil, errors.New("invalid target")
+			}
+			// The regions can overlap, to encode repeated patterns, so copy
+			// byte per byte
+			for ; length > 0; length-- {
+				target = append(target, target[targetRelativeOffset])
+				targetRelativeOffset++
+			}
+		}
+	}
+
+	if len(target) != targetSize || crc32.ChecksumIEEE(target) != targetChecksum {
+		return nil, errors.New("invalid target")
+	}
+
+	return &target, nil
+}
@@ -0,0 +1,119 @@
+package patch
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+// bpsEncode encodes a number in the BPS variable length format
+func bpsEncode(data int) []byte {
+	out := []byte{}
+	for {
+		x := byte(data & 0x7f)
+		data >>= 7
+		if data == 0 {
+			return append(out, 0x80|x)
+		}
+		out = append(out, x)
+		data--
+	}
+}
+
+// makeBPS builds a BPS patch from a list of already encoded actions
+func makeBPS(source, target []byte, actions ...[]byte) []byte {
+	p := []byte("BPS1")
+	p = append(p, bpsEncode(len(source))...)
+	p = append(p, bpsEncode(len(target))...)
+	p = append(p, bpsEncode(0)...)
+	for _, a := range actions {
+		p = append(p, a...)
+	}
+	p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(source))
+	p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(target))
+	p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(p))
+	return p
+}
+
+func bpsAction(command, length int, args ...byte) []byte {
+	return append(bpsEncode((length-1)<<2|command), args...)
+}
+
+func Test_applyBPS(t *testing.T) {
+	source := []byte("Hello World! This is a test ROM.")
+	target := []byte("Hello Ludo!! This is a test ROM.M.M.M.M.")
+
+	patch := makeBPS(source, target,
+		bpsAction(bpsSourceRead, 6),
+		bpsAction(bpsTargetRead, 6, []byte("Ludo!!")...),
+		bpsAction(bpsSourceCopy, 20, bpsEncode(12<<1)...),
+		bpsAction(bpsTargetCopy, 8, bpsEncode(30<<1)...),
+	)
+
+	t.Run("Can apply a valid BPS patch", func(t *testing.T) {
+		got, err := applyBPS(patch, source)
+		if err != nil {
+			t.Fatalf("applyBPS() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, target) {
+			t.Errorf("applyBPS() = %s, want %s", *got, target)
+		}
+	})
+
+	t.Run("Can detect a short patch", func(t *testing.T) {
+		_, err := applyBPS([]byte("BPS1"), source)
+		if err == nil || err.Error() != "patch too small" {
+			t.Errorf("applyBPS() = %v, want %v", err, "patch too small")
+		}
+	})
+
+	t.Run("Can detect a patch with a wrong header", func(t *testing.T) {
+		wrong := append([]byte("UPS1"), patch[4:]...)
+		_, err := applyBPS(wrong, source)
+		if err == nil || err.Error() != "invalid patch header" {
+			t.Errorf("applyBPS() = %v, want %v", err, "invalid patch header")
+		}
+	})
+
+	t.Run("Can detect a corrupted patch", func(t *testing.T) {
+		corrupted := append([]byte{}, patch...)
+		corrupted[10] ^= 0xff
+		_, err := applyBPS(corrupted, source)
+		if err == nil || err.Error() != "invalid patch" {
+			t.Errorf("applyBPS() = %v, want %v", err, "invalid patch")
+		}
+	})
+
+	t.Run("Rejects a huge declared target without allocating it", func(t *testing.T) {
+		p := []byte("BPS1")
+		p = append(p, bpsEncode(len(source))...)
+		p = append(p, bpsEncode(1<<55)...)
+		p = append(p, bpsEncode(0)...)
+		p = append(p, bpsAction(bpsSourceRead, 6)...)
+		p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(source))
+		p = binary.LittleEndian.AppendUint32(p, 0)
+		p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(p))
+		_, err := applyBPS(p, source)
+		if err == nil || err.Error() != "invalid target" {
+			t.Errorf("applyBPS() = %v, want %v", err, "invalid target")
+		}
+	})
+
+	t.Run("Rejects a source copy out of range", func(t *testing.T) {
+		p := makeBPS(source, target, bpsAction(bpsSourceCopy, 4, bpsEncode(1<<54)...))
+		_, err := applyBPS(p, source)
+		if err == nil || err.Error() != "invalid source" {
+			t.Errorf("applyBPS() = %v, want %v", err, "invalid source")
+		}
+	})
+
+	t.Run("Can detect a wrong source", func(t *testing.T) {
+		wrong := append([]byte{}, source...)
+		wrong[0] = 'J'
+		_, err := applyBPS(patch, wrong)
+		if err == nil || err.Error() != "invalid source" {
+			t.Errorf("applyBPS() = %v, want %v", err, "invalid source")
+		}
+	})
+}
@@ -1,46 +1,228 @@
-// Package patch allows softpatching ROMs based on the presence of a patch file
+// Package patch allows softpatching ROMs based on the presence of patch files
 // next to the ROM. This is useful to apply fan translations without altering
-// No-Intro ROMs. Softpatching only works for cores where NeedFullPath is false.
+// No-Intro ROMs. For cores where NeedFullPath is true, the core package writes
+// the patched image to a cache directory and passes that path to the core.
+//
+// Several patches can be stacked by numbering them, like game.1.ips and
+// game.2.bps. They are applied in order, on top of an unnumbered game.ips if
+// any. The list of patches, their order and whether they are enabled can also
+// be described in a game.patches.toml manifest placed next to the ROM.
 package patch
 
 import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/pelletier/go-toml"
 )
 
-// Try to apply different patches located next to the game
-// Currently only .ups is supported
-func Try(gamePath string, bytes []byte) (*[]byte, error) {
-	upsFile := strings.TrimSuffix(gamePath, filepath.Ext(gamePath)) + ".ups"
-	if _, err := os.Stat(upsFile); !os.IsNotExist(err) {
-		pbytes, err := os.ReadFile(upsFile)
-		if err != nil {
-			return nil, err
+// formats lists the supported patch extensions and their decoders, by order
+// of priority
+var formats = []struct {
+	ext   string
+	apply func(patch, source []byte) (*[]byte, error)
+}{
+	{".ups", applyUPS},
+	{".ips", applyIPS},
+	{".bps", applyBPS},
+	{".xdelta", applyVCDIFF},
+	{".vcdiff", applyVCDIFF},
+}
+
+// Patch is a patch file to apply to a game
+type Patch struct {
+	File    string `toml:"file"`            // name of the patch file, relative to the game directory
+	Enabled bool   `toml:"enabled"`         // disabled patches are skipped
+	CRC32   string `toml:"crc32,omitempty"` // expected checksum of the data once this patch is applied
+}
+
+// Manifest lists the patches of a game, in the order they are applied
+type Manifest struct {
+	Patches []Patch `toml:"patch"`
+}
+
+// ManifestPath returns the path of the patch manifest of a game
+func ManifestPath(gamePath string) string {
+	return strings.TrimSuffix(gamePath, filepath.Ext(gamePath)) + ".patches.toml"
+}
+
+func formatOf(file string) int {
+	ext := strings.ToLower(filepath.Ext(file))
+	for i, format := range formats {
+		if format.ext == ext {
+			return i
 		}
+	}
+	return -1
+}
 
-		patched, err := applyUPS(pbytes, bytes)
-		if err != nil {
-			return nil, err
+// Detect lists the patch files located next to the game, in the order they
+// should be applied. Only the unnumbered patch of highest priority is kept,
+// numbered patches follow by increasing number.
+func Detect(gamePath string) []Patch {
+	base := filepath.Base(strings.TrimSuffix(gamePath, filepath.Ext(gamePath)))
+	entries, err := os.ReadDir(filepath.Dir(gamePath))
+	if err != nil {
+		return nil
+	}
+
+	type candidate struct {
+		file   string
+		number int
+		format int
+	}
+	candidates := []candidate{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, base+".") {
+			continue
+		}
+		format := formatOf(name)
+		if format < 0 {
+			continue
+		}
+		middle := strings.TrimSuffix(strings.TrimPrefix(name, base), filepath.Ext(name))
+		number := 0
+		if middle != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(middle, "."))
+			if err != nil || n <= 0 {
+				continue
+			}
+			number = n
+		}
+		candidates = append(candidates, candidate{name, number, format})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].number != candidates[j].number {
+			return candidates[i].number < candidates[j].number
 		}
+		return candidates[i].format < candidates[j].format
+	})
 
-		return patched, nil
+	patches := []Patch{}
+	for i, c := range candidates {
+		if c.number == 0 && i > 0 {
+			continue
+		}
+		patches = append(patches, Patch{File: c.file, Enabled: true})
+	}
+	return patches
+}
+
+// List returns the patches of a game. If the game has a manifest, it is used
+// as is. Otherwise, the patches are detected next to the game.
+func List(gamePath string) ([]Patch, error) {
+	b, err := os.ReadFile(ManifestPath(gamePath))
+	if os.IsNotExist(err) {
+		return Detect(gamePath), nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	ipsFile := strings.TrimSuffix(gamePath, filepath.Ext(gamePath)) + ".ips"
-	if _, err := os.Stat(ipsFile); !os.IsNotExist(err) {
-		pbytes, err := os.ReadFile(ipsFile)
+	var m Manifest
+	err = toml.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+	return m.Patches, nil
+}
+
+// SaveList writes the list of patches to the manifest of a game
+func SaveList(gamePath string, patches []Patch) error {
+	b, err := toml.Marshal(Manifest{Patches: patches})
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Create(ManifestPath(gamePath))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	_, err = io.Copy(fd, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return fd.Sync()
+}
+
+// HasEnabled returns true if at least one patch of the list is enabled
+func HasEnabled(patches []Patch) bool {
+	for _, p := range patches {
+		if p.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply applies the enabled patches in sequence. When a patch declares the
+// checksum of its output, it is verified before applying the next one. It
+// returns nil if no patch was applied.
+func Apply(gamePath string, data []byte, patches []Patch) (*[]byte, error) {
+	var patched *[]byte
+	for _, p := range patches {
+		if !p.Enabled {
+			continue
+		}
+
+		format := formatOf(p.File)
+		if format < 0 {
+			return nil, fmt.Errorf("%s: unsupported patch format", p.File)
+		}
+
+		pbytes, err := os.ReadFile(filepath.Join(filepath.Dir(gamePath), p.File))
 		if err != nil {
 			return nil, err
 		}
 
-		patched, err := applyIPS(pbytes, bytes)
+		patched, err = formats[format].apply(pbytes, data)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%s: %w", p.File, err)
+		}
+		data = *patched
+
+		if p.CRC32 != "" {
+			sum := fmt.Sprintf("%08x", crc32.ChecksumIEEE(data))
+			if !strings.EqualFold(sum, p.CRC32) {
+				return nil, fmt.Errorf("%s: checksum mismatch, got %s, want %s", p.File, sum, p.CRC32)
+			}
 		}
+	}
+	return patched, nil
+}
 
-		return patched, nil
+// capacity returns the size to allocate for an output declared by a patch.
+// It is bounded by the size of the inputs, so that a corrupted patch can't
+// exhaust the memory, and the output grows past it if needed.
+func capacity(declared int, inputs ...[]byte) int {
+	n := 0
+	for _, in := range inputs {
+		n += len(in)
+	}
+	if declared < n {
+		return declared
 	}
+	return n
+}
 
-	return nil, nil
+// Try to apply the patches located next to the game
+// Supported formats are .ups, .ips, .bps and .xdelta
+func Try(gamePath string, bytes []byte) (*[]byte, error) {
+	patches, err := List(gamePath)
+	if err != nil {
+		return nil, err
+	}
+	return Apply(gamePath, bytes, patches)
 }
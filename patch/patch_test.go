@@ -0,0 +1,156 @@
+package patch
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// makeIPS builds an IPS patch writing data at address
+func makeIPS(address int, data []byte) []byte {
+	p := []byte("PATCH")
+	p = append(p, byte(address>>16), byte(address>>8), byte(address))
+	p = append(p, byte(len(data)>>8), byte(len(data)))
+	p = append(p, data...)
+	return append(p, []byte("EOF")...)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_Detect(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{
+		"Game.sfc":         {},
+		"Game.ips":         {},
+		"Game.ups":         {},
+		"Game.2.bps":       {},
+		"Game.10.xdelta":   {},
+		"Game.1.ips":       {},
+		"Game.txt":         {},
+		"Game.beta.ips":    {},
+		"Game (Beta).ips":  {},
+		"Other Game.1.ips": {},
+	})
+
+	t.Run("Lists stacked patches in order", func(t *testing.T) {
+		got := Detect(filepath.Join(dir, "Game.sfc"))
+		want := []Patch{
+			{File: "Game.ups", Enabled: true},
+			{File: "Game.1.ips", Enabled: true},
+			{File: "Game.2.bps", Enabled: true},
+			{File: "Game.10.xdelta", Enabled: true},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Detect() = %v, want %v", got, want)
+		}
+	})
+}
+
+func Test_Apply(t *testing.T) {
+	dir := t.TempDir()
+	gamePath := filepath.Join(dir, "Game.sfc")
+	source := []byte("AAAAAAAAAAAAAAAA")
+	step1 := []byte("ABBBAAAAAAAAAAAA")
+	step2 := []byte("ABBBAAAACCCCAAAA")
+
+	writeFiles(t, dir, map[string][]byte{
+		"Game.sfc":   source,
+		"Game.1.ips": makeIPS(1, []byte("BBB")),
+		"Game.2.bps": makeBPS(step1, step2,
+			bpsAction(bpsSourceRead, 8),
+			bpsAction(bpsTargetRead, 4, []byte("CCCC")...),
+			bpsAction(bpsSourceRead, 4),
+		),
+	})
+
+	t.Run("Applies stacked patches in sequence", func(t *testing.T) {
+		got, err := Try(gamePath, source)
+		if err != nil {
+			t.Fatalf("Try() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, step2) {
+			t.Errorf("Try() = %s, want %s", *got, step2)
+		}
+	})
+
+	t.Run("Skips disabled patches", func(t *testing.T) {
+		got, err := Apply(gamePath, source, []Patch{
+			{File: "Game.1.ips", Enabled: true},
+			{File: "Game.2.bps", Enabled: false},
+		})
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, step1) {
+			t.Errorf("Apply() = %s, want %s", *got, step1)
+		}
+	})
+
+	t.Run("Returns nil when no patch is enabled", func(t *testing.T) {
+		got, err := Apply(gamePath, source, []Patch{{File: "Game.1.ips"}})
+		if got != nil || err != nil {
+			t.Errorf("Apply() = %v, %v, want nil, nil", got, err)
+		}
+	})
+
+	t.Run("Verifies checksums between steps", func(t *testing.T) {
+		_, err := Apply(gamePath, source, []Patch{
+			{File: "Game.1.ips", Enabled: true, CRC32: fmt.Sprintf("%08X", crc32.ChecksumIEEE(step1))},
+			{File: "Game.2.bps", Enabled: true},
+		})
+		if err != nil {
+			t.Errorf("Apply() error = %v", err)
+		}
+
+		_, err = Apply(gamePath, source, []Patch{
+			{File: "Game.1.ips", Enabled: true, CRC32: "deadbeef"},
+			{File: "Game.2.bps", Enabled: true},
+		})
+		if err == nil {
+			t.Errorf("Apply() error = %v, want a checksum mismatch", err)
+		}
+	})
+
+	t.Run("Fails if the stack is applied in the wrong order", func(t *testing.T) {
+		_, err := Apply(gamePath, source, []Patch{
+			{File: "Game.2.bps", Enabled: true},
+			{File: "Game.1.ips", Enabled: true},
+		})
+		if err == nil {
+			t.Errorf("Apply() error = %v, want an error", err)
+		}
+	})
+
+	t.Run("Uses the manifest when there is one", func(t *testing.T) {
+		patches := []Patch{
+			{File: "Game.1.ips", Enabled: true},
+			{File: "Game.2.bps", Enabled: false},
+		}
+		if err := SaveList(gamePath, patches); err != nil {
+			t.Fatalf("SaveList() error = %v", err)
+		}
+		got, err := List(gamePath)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, patches) {
+			t.Errorf("List() = %v, want %v", got, patches)
+		}
+		patched, err := Try(gamePath, source)
+		if err != nil {
+			t.Fatalf("Try() error = %v", err)
+		}
+		if !reflect.DeepEqual(*patched, step1) {
+			t.Errorf("Try() = %s, want %s", *patched, step1)
+		}
+	})
+}
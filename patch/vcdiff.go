@@ -0,0 +1,379 @@
+package patch
+
+import (
+	"errors"
+	"hash/adler32"
+	"math"
+)
+
+// VCDIFF (RFC 3284) is the delta format produced by xdelta3. Secondary
+// compression and custom code tables are not supported, patches have to be
+// created with xdelta3 -S none.
+
+// Header and window indicators
+const (
+	vcdDecompress = 0x01
+	vcdCodetable  = 0x02
+	vcdAppheader  = 0x04 // xdelta3 extension
+
+	vcdSource  = 0x01
+	vcdTarget  = 0x02
+	vcdAdler32 = 0x04 // xdelta3 extension
+)
+
+// Instruction types
+const (
+	vcdNoop = iota
+	vcdAdd
+	vcdRun
+	vcdCopy
+)
+
+// Address cache sizes of the default code table
+const (
+	vcdNearSize = 4
+	vcdSameSize = 3
+)
+
+type vcdInstruction struct {
+	kind byte
+	size int
+	mode int
+}
+
+// vcdCodeTable is the default code table described in section 5.6 of the RFC.
+// Each entry is a pair of instructions.
+var vcdCodeTable = buildVCDCodeTable()
+
+func buildVCDCodeTable() [256][2]vcdInstruction {
+	var table [256][2]vcdInstruction
+	i := 0
+
+	table[i][0] = vcdInstruction{kind: vcdRun}
+	i++
+
+	for size := 0; size <= 17; size++ {
+		table[i][0] = vcdInstruction{kind: vcdAdd, size: size}
+		i++
+	}
+
+	for mode := 0; mode <= 8; mode++ {
+		table[i][0] = vcdInstruction{kind: vcdCopy, mode: mode}
+		i++
+		for size := 4; size <= 18; size++ {
+			table[i][0] = vcdInstruction{kind: vcdCopy, size: size, mode: mode}
+			i++
+		}
+	}
+
+	for mode := 0; mode <= 5; mode++ {
+		for addSize := 1; addSize <= 4; addSize++ {
+			for copySize := 4; copySize <= 6; copySize++ {
+				table[i][0] = vcdInstruction{kind: vcdAdd, size: addSize}
+				table[i][1] = vcdInstruction{kind: vcdCopy, size: copySize, mode: mode}
+				i++
+			}
+		}
+	}
+
+	for mode := 6; mode <= 8; mode++ {
+		for addSize := 1; addSize <= 4; addSize++ {
+			table[i][0] = vcdInstruction{kind: vcdAdd, size: addSize}
+			table[i][1] = vcdInstruction{kind: vcdCopy, size: 4, mode: mode}
+			i++
+		}
+	}
+
+	for mode := 0; mode <= 8; mode++ {
+		table[i][0] = vcdInstruction{kind: vcdCopy, size: 4, mode: mode}
+		table[i][1] = vcdInstruction{kind: vcdAdd, size: 1}
+		i++
+	}
+
+	return table
+}
+
+// vcdReader reads bytes and integers from a section of the patch
+type vcdReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *vcdReader) byte() (byte, error) {
+	if r.offset >= len(r.data) {
+		return 0, errors.New("unexpected end of patch")
+	}
+	b := r.data[r.offset]
+	r.offset++
+	return b, nil
+}
+
+// integer reads a big endian base 128 number, as described in section 2 of
+// the RFC
+func (r *vcdReader) integer() (int, error) {
+	n := 0
+	for i := 0; i < 9; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxInt>>7 {
+			return 0, errors.New("invalid integer")
+		}
+		n = n<<7 | int(b&0x7f)
+		if b&0x80 == 0 {
+			return n, nil
+		}
+	}
+	return 0, errors.New("invalid integer")
+}
+
+func (r *vcdReader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.offset {
+		return nil, errors.New("unexpected end of patch")
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b, nil
+}
+
+// vcdAddressCache implements the near and same caches of section 5.1
+type vcdAddressCache struct {
+	near     [vcdNearSize]int
+	nextSlot int
+	same     [vcdSameSize * 256]int
+}
+
+func (c *vcdAddressCache) update(addr int) {
+	c.near[c.nextSlot] = addr
+	c.nextSlot = (c.nextSlot + 1) % vcdNearSize
+	c.same[addr%(vcdSameSize*256)] = addr
+}
+
+func (c *vcdAddressCache) decode(addrs *vcdReader, here, mode int) (int, error) {
+	var addr int
+	switch {
+	case mode == 0: // VCD_SELF
+		a, err := addrs.integer()
+		if err != nil {
+			return 0, err
+		}
+		addr = a
+	case mode == 1: // VCD_HERE
+		a, err := addrs.integer()
+		if err != nil {
+			return 0, err
+		}
+		addr = here - a
+	case mode-2 < vcdNearSize:
+		a, err := addrs.integer()
+		if err != nil {
+			return 0, err
+		}
+		addr = c.near[mode-2] + a
+	case mode-2-vcdNearSize < vcdSameSize:
+		b, err := addrs.byte()
+		if err != nil {
+			return 0, err
+		}
+		addr = c.same[(mode-2-vcdNearSize)*256+int(b)]
+	default:
+		return 0, errors.New("invalid address mode")
+	}
+
+	if addr < 0 || addr >= here {
+		return 0, errors.New("invalid address")
+	}
+
+	c.update(addr)
+	return addr, nil
+}
+
+func applyVCDIFF(patch, source []byte) (*[]byte, error) {
+	if len(patch) < 5 {
+		return nil, errors.New("patch too small")
+	}
+
+	if patch[0] != 0xD6 || patch[1] != 0xC3 || patch[2] != 0xC4 || patch[3] != 0x00 {
+		return nil, errors.New("invalid patch header")
+	}
+
+	r := &vcdReader{data: patch, offset: 4}
+	indicator, _ := r.byte()
+
+	if indicator&vcdDecompress != 0 {
+		// The secondary compressor id is only a problem if a window uses it
+		if _, err := r.byte(); err != nil {
+			return nil, err
+		}
+	}
+	if indicator&vcdCodetable != 0 {
+		return nil, errors.New("unsupported custom code table")
+	}
+	if indicator&vcdAppheader != 0 {
+		n, err := r.integer()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.bytes(n); err != nil {
+			return nil, err
+		}
+	}
+
+	target := []byte{}
+	for r.offset < len(r.data) {
+		var err error
+		target, err = vcdWindow(r, source, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &target, nil
+}
+
+// vcdWindow decodes a single window and appends the result to target
+func vcdWindow(r *vcdReader, source, target []byte) ([]byte, error) {
+	winIndicator, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	var segment []byte
+	if winIndicator&(vcdSource|vcdTarget) != 0 {
+		length, err := r.integer()
+		if err != nil {
+			return nil, err
+		}
+		position, err := r.integer()
+		if err != nil {
+			return nil, err
+		}
+		from := source
+		if winIndicator&vcdTarget != 0 {
+			from = target
+		}
+		if position > len(from) || length > len(from)-position {
+			return nil, errors.New("invalid source")
+		}
+		segment = from[position : position+length]
+	}
+
+	if _, err := r.integer(); err != nil { // length of the delta encoding
+		return nil, err
+	}
+	windowLength, err := r.integer()
+	if err != nil {
+		return nil, err
+	}
+	deltaIndicator, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if deltaIndicator != 0 {
+		return nil, errors.New("unsupported secondary compression")
+	}
+
+	dataLength, err := r.integer()
+	if err != nil {
+		return nil, err
+	}
+	instLength, err := r.integer()
+	if err != nil {
+		return nil, err
+	}
+	addrLength, err := r.integer()
+	if err != nil {
+		return nil, err
+	}
+
+	var checksum []byte
+	if winIndicator&vcdAdler32 != 0 {
+		if checksum, err = r.bytes(4); err != nil {
+			return nil, err
+		}
+	}
+
+	dataSection, err := r.bytes(dataLength)
+	if err != nil {
+		return nil, err
+	}
+	instSection, err := r.bytes(instLength)
+	if err != nil {
+		return nil, err
+	}
+	addrSection, err := r.bytes(addrLength)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &vcdReader{data: dataSection}
+	insts := &vcdReader{data: instSection}
+	addrs := &vcdReader{data: addrSection}
+	cache := &vcdAddressCache{}
+	window := make([]byte, 0, capacity(windowLength, segment, r.data))
+
+	for insts.offset < len(insts.data) {
+		code, _ := insts.byte()
+		for _, inst := range vcdCodeTable[code] {
+			if inst.kind == vcdNoop {
+				continue
+			}
+
+			size := inst.size
+			if size == 0 {
+				if size, err = insts.integer(); err != nil {
+					return nil, err
+				}
+			}
+			if size > windowLength-len(window) {
+				return nil, errors.New("invalid target")
+			}
+
+			switch inst.kind {
+			case vcdAdd:
+				b, err := data.bytes(size)
+				if err != nil {
+					return nil, err
+				}
+				window = append(window, b...)
+			case vcdRun:
+				b, err := data.byte()
+				if err != nil {
+					return nil, err
+				}
+				for i := 0; i < size; i++ {
+					window = append(window, b)
+				}
+			case vcdCopy:
+				here := len(segment) + len(window)
+				addr, err := cache.decode(addrs, here, inst.mode)
+				if err != nil {
+					return nil, err
+				}
+				// Addresses past the source segment point into the window
+				// itself, and can overlap with the bytes being produced
+				for i := 0; i < size; i++ {
+					if addr+i < len(segment) {
+						window = append(window, segment[addr+i])
+					} else {
+						window = append(window, window[addr+i-len(segment)])
+					}
+				}
+			}
+		}
+	}
+
+	if len(window) != windowLength {
+		return nil, errors.New("invalid target")
+	}
+
+	if checksum != nil {
+		sum := uint32(checksum[0])<<24 | uint32(checksum[1])<<16 | uint32(checksum[2])<<8 | uint32(checksum[3])
+		if adler32.Checksum(window) != sum {
+			return nil, errors.New("invalid target")
+		}
+	}
+
+	return append(target, window...), nil
+}
@@ -0,0 +1,168 @@
+package patch
+
+import (
+	"encoding/binary"
+	"hash/adler32"
+	"reflect"
+	"testing"
+)
+
+// vcdInteger encodes a number in the VCDIFF big endian base 128 format
+func vcdInteger(n int) []byte {
+	out := []byte{byte(n & 0x7f)}
+	for n >>= 7; n > 0; n >>= 7 {
+		out = append([]byte{byte(n&0x7f) | 0x80}, out...)
+	}
+	return out
+}
+
+// makeVCDIFF builds a single window VCDIFF patch using the whole source as
+// source segment
+func makeVCDIFF(source, target []byte, winIndicator, deltaIndicator byte, data, inst, addr []byte) []byte {
+	delta := []byte{}
+	delta = append(delta, vcdInteger(len(target))...)
+	delta = append(delta, deltaIndicator)
+	delta = append(delta, vcdInteger(len(data))...)
+	delta = append(delta, vcdInteger(len(inst))...)
+	delta = append(delta, vcdInteger(len(addr))...)
+	if winIndicator&vcdAdler32 != 0 {
+		delta = binary.BigEndian.AppendUint32(delta, adler32.Checksum(target))
+	}
+	delta = append(delta, data...)
+	delta = append(delta, inst...)
+	delta = append(delta, addr...)
+
+	p := []byte{0xD6, 0xC3, 0xC4, 0x00, 0x00}
+	p = append(p, winIndicator|vcdSource)
+	p = append(p, vcdInteger(len(source))...)
+	p = append(p, vcdInteger(0)...)
+	p = append(p, vcdInteger(len(delta))...)
+	return append(p, delta...)
+}
+
+func Test_applyVCDIFF(t *testing.T) {
+	source := []byte("The quick brown fox jumps over the lazy dog")
+	target := []byte("The quick red fox jumps over the lazy dogdogdog!!!!")
+
+	data := []byte("red!")
+	inst := []byte{
+		26,     // COPY 10 bytes, mode SELF
+		4,      // ADD 3 bytes
+		19, 28, // COPY 28 bytes, mode SELF
+		38,   // COPY 6 bytes, mode HERE, overlapping the output
+		0, 4, // RUN 4 bytes
+	}
+	addr := []byte{0, 15, 3}
+
+	t.Run("Can apply a valid VCDIFF patch", func(t *testing.T) {
+		patch := makeVCDIFF(source, target, 0, 0, data, inst, addr)
+		got, err := applyVCDIFF(patch, source)
+		if err != nil {
+			t.Fatalf("applyVCDIFF() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, target) {
+			t.Errorf("applyVCDIFF() = %s, want %s", *got, target)
+		}
+	})
+
+	t.Run("Verifies the xdelta3 window checksum", func(t *testing.T) {
+		patch := makeVCDIFF(source, target, vcdAdler32, 0, data, inst, addr)
+		got, err := applyVCDIFF(patch, source)
+		if err != nil {
+			t.Fatalf("applyVCDIFF() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, target) {
+			t.Errorf("applyVCDIFF() = %s, want %s", *got, target)
+		}
+
+		wrong := append([]byte{}, source...)
+		wrong[4] = 'Q'
+		_, err = applyVCDIFF(patch, wrong)
+		if err == nil || err.Error() != "invalid target" {
+			t.Errorf("applyVCDIFF() = %v, want %v", err, "invalid target")
+		}
+	})
+
+	t.Run("Can detect a patch with a wrong header", func(t *testing.T) {
+		patch := makeVCDIFF(source, target, 0, 0, data, inst, addr)
+		patch[0] = 'X'
+		_, err := applyVCDIFF(patch, source)
+		if err == nil || err.Error() != "invalid patch header" {
+			t.Errorf("applyVCDIFF() = %v, want %v", err, "invalid patch header")
+		}
+	})
+
+	t.Run("Rejects secondary compression", func(t *testing.T) {
+		patch := makeVCDIFF(source, target, 0, 1, data, inst, addr)
+		_, err := applyVCDIFF(patch, source)
+		if err == nil || err.Error() != "unsupported secondary compression" {
+			t.Errorf("applyVCDIFF() = %v, want %v", err, "unsupported secondary compression")
+		}
+	})
+
+	t.Run("Can detect a truncated patch", func(t *testing.T) {
+		patch := makeVCDIFF(source, target, 0, 0, data, inst, addr)
+		_, err := applyVCDIFF(patch[:len(patch)-2], source)
+		if err == nil {
+			t.Errorf("applyVCDIFF() = %v, want an error", err)
+		}
+	})
+
+	t.Run("Rejects a source segment overflowing the source", func(t *testing.T) {
+		patch := []byte{0xD6, 0xC3, 0xC4, 0x00, 0x00, vcdSource}
+		patch = append(patch, vcdInteger(1<<62)...) // length
+		patch = append(patch, vcdInteger(1<<62)...) // position
+		patch = append(patch, vcdInteger(0)...)
+		_, err := applyVCDIFF(patch, source)
+		if err == nil || err.Error() != "invalid source" {
+			t.Errorf("applyVCDIFF() = %v, want %v", err, "invalid source")
+		}
+	})
+
+	t.Run("Rejects a window bigger than declared", func(t *testing.T) {
+		huge := makeVCDIFF(source, make([]byte, 8), 0, 0, []byte{'!'}, []byte{0, 9}, nil)
+		_, err := applyVCDIFF(huge, source)
+		if err == nil || err.Error() != "invalid target" {
+			t.Errorf("applyVCDIFF() = %v, want %v", err, "invalid target")
+		}
+	})
+}
+
+func Test_capacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared int
+		want     int
+	}{
+		{name: "Allocates the declared size", declared: 4, want: 4},
+		{name: "Bounds the declared size by the inputs", declared: 1 << 62, want: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := capacity(tt.declared, []byte("abc"), []byte("def")); got != tt.want {
+				t.Errorf("capacity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vcdCodeTable(t *testing.T) {
+	t.Run("Matches the RFC 3284 default code table", func(t *testing.T) {
+		for i, want := range map[int][2]vcdInstruction{
+			0:   {{kind: vcdRun}},
+			1:   {{kind: vcdAdd}},
+			18:  {{kind: vcdAdd, size: 17}},
+			19:  {{kind: vcdCopy}},
+			162: {{kind: vcdCopy, size: 18, mode: 8}},
+			163: {{kind: vcdAdd, size: 1}, {kind: vcdCopy, size: 4}},
+			234: {{kind: vcdAdd, size: 4}, {kind: vcdCopy, size: 6, mode: 5}},
+			235: {{kind: vcdAdd, size: 1}, {kind: vcdCopy, size: 4, mode: 6}},
+			247: {{kind: vcdCopy, size: 4}, {kind: vcdAdd, size: 1}},
+			255: {{kind: vcdCopy, size: 4, mode: 8}, {kind: vcdAdd, size: 1}},
+		} {
+			if got := vcdCodeTable[i]; got != want {
+				t.Errorf("vcdCodeTable[%d] = %v, want %v", i, got, want)
+			}
+		}
+	})
+}
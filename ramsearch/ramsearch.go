@@ -0,0 +1,203 @@
+// Package ramsearch implements a memory search to discover cheats. A search
+// starts from a snapshot of the emulated memory, then candidates are filtered
+// by comparing their current value to a constant or to their value in the
+// previous snapshot, until only a few addresses remain.
+package ramsearch
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Region is a contiguous area of the emulated memory
+type Region struct {
+	Address uint32 // address of the first byte, as seen by the emulated system
+	Data    []byte
+}
+
+// Op is a comparison used to filter the candidates
+type Op int
+
+const (
+	// Equal keeps the values equal to the reference
+	Equal Op = iota
+	// NotEqual keeps the values different from the reference
+	NotEqual
+	// Greater keeps the values greater than the reference
+	Greater
+	// Less keeps the values less than the reference
+	Less
+)
+
+// Result is a candidate address of a search
+type Result struct {
+	Address  uint32
+	Value    uint32
+	Previous uint32
+}
+
+// Search is an ongoing memory search. It holds a copy of the memory at the
+// time of the last filter and a bitset of the remaining candidates for each
+// region.
+type Search struct {
+	Size      int // size of the searched values in bytes: 1, 2 or 4
+	BigEndian bool
+
+	addresses  []uint32
+	snapshot   [][]byte
+	candidates [][]uint64
+	count      int
+}
+
+// New starts a search where every address of the regions is a candidate
+func New(regions []Region, size int, bigEndian bool) *Search {
+	s := &Search{Size: size, BigEndian: bigEndian}
+	s.addresses = make([]uint32, len(regions))
+	s.snapshot = make([][]byte, len(regions))
+	s.candidates = make([][]uint64, len(regions))
+	for i, r := range regions {
+		s.addresses[i] = r.Address
+		s.snapshot[i] = append([]byte{}, r.Data...)
+		n := len(r.Data) - size + 1
+		if n < 0 {
+			n = 0
+		}
+		set := make([]uint64, (n+63)/64)
+		for j := 0; j < n; j++ {
+			set[j/64] |= 1 << (j % 64)
+		}
+		s.candidates[i] = set
+		s.count += n
+	}
+	return s
+}
+
+// Count returns the number of remaining candidates
+func (s *Search) Count() int {
+	return s.count
+}
+
+func compare(op Op, a, b uint32) bool {
+	switch op {
+	case Equal:
+		return a == b
+	case NotEqual:
+		return a != b
+	case Greater:
+		return a > b
+	case Less:
+		return a < b
+	}
+	return false
+}
+
+// decode reads a value of size bytes at the beginning of data
+func decode(data []byte, size int, bigEndian bool) uint32 {
+	var v uint32
+	for i := 0; i < size; i++ {
+		if bigEndian {
+			v = v<<8 | uint32(data[i])
+		} else {
+			v |= uint32(data[i]) << (8 * i)
+		}
+	}
+	return v
+}
+
+// filter keeps the candidates for which keep returns true, then takes a new
+// snapshot of the memory
+func (s *Search) filter(regions []Region, keep func(value, previous uint32) bool) error {
+	if len(regions) != len(s.snapshot) {
+		return errors.New("memory layout changed")
+	}
+	for i, r := range regions {
+		if len(r.Data) != len(s.snapshot[i]) {
+			return errors.New("memory layout changed")
+		}
+	}
+
+	s.count = 0
+	for i, r := range regions {
+		for w, word := range s.candidates[i] {
+			for word != 0 {
+				b := bits.TrailingZeros64(word)
+				word &^= 1 << b
+				j := w*64 + b
+				value := decode(r.Data[j:], s.Size, s.BigEndian)
+				previous := decode(s.snapshot[i][j:], s.Size, s.BigEndian)
+				if keep(value, previous) {
+					s.count++
+				} else {
+					s.candidates[i][w] &^= 1 << b
+				}
+			}
+		}
+		copy(s.snapshot[i], r.Data)
+	}
+	return nil
+}
+
+// FilterValue keeps the candidates whose current value compares to value
+func (s *Search) FilterValue(regions []Region, op Op, value uint32) error {
+	return s.filter(regions, func(v, _ uint32) bool {
+		return compare(op, v, value)
+	})
+}
+
+// FilterPrevious keeps the candidates whose current value compares to their
+// value in the previous snapshot. NotEqual finds the values that changed,
+// Equal the ones that didn't.
+func (s *Search) FilterPrevious(regions []Region, op Op) error {
+	return s.filter(regions, func(v, previous uint32) bool {
+		return compare(op, v, previous)
+	})
+}
+
+// Results returns up to max candidates with their values in the memory and in
+// the last snapshot. The current values are read from regions if they match the
+// layout of the search.
+func (s *Search) Results(regions []Region, max int) []Result {
+	results := []Result{}
+	for i, set := range s.candidates {
+		for w, word := range set {
+			for word != 0 {
+				if len(results) >= max {
+					return results
+				}
+				b := bits.TrailingZeros64(word)
+				word &^= 1 << b
+				j := w*64 + b
+				r := Result{
+					Address:  s.addresses[i] + uint32(j),
+					Previous: decode(s.snapshot[i][j:], s.Size, s.BigEndian),
+				}
+				if i < len(regions) && j+s.Size <= len(regions[i].Data) {
+					r.Value = decode(regions[i].Data[j:], s.Size, s.BigEndian)
+				}
+				results = append(results, r)
+			}
+		}
+	}
+	return results
+}
+
+// Watch is an address displayed in the watch overlay
+type Watch struct {
+	Address   uint32
+	Size      int
+	BigEndian bool
+}
+
+// Read returns the current value of a watched address
+func (w Watch) Read(regions []Region) (uint32, bool) {
+	for _, r := range regions {
+		if w.Address < r.Address {
+			continue
+		}
+		offset := int(w.Address - r.Address)
+		if offset+w.Size <= len(r.Data) {
+			return decode(r.Data[offset:], w.Size, w.BigEndian), true
+		}
+	}
+	return 0, false
+}
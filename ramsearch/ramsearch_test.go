@@ -0,0 +1,126 @@
+package ramsearch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func addresses(results []Result) []uint32 {
+	a := []uint32{}
+	for _, r := range results {
+		a = append(a, r.Address)
+	}
+	return a
+}
+
+func Test_Search(t *testing.T) {
+	t.Run("Narrows down a changing value", func(t *testing.T) {
+		wram := []byte{3, 3, 0, 7, 3, 1, 0, 0}
+		hram := []byte{3, 9}
+		regions := []Region{{Address: 0xC000, Data: wram}, {Address: 0xFF80, Data: hram}}
+
+		s := New(regions, 1, false)
+		if s.Count() != 10 {
+			t.Errorf("Count() = %d, want %d", s.Count(), 10)
+		}
+
+		if err := s.FilterValue(regions, Equal, 3); err != nil {
+			t.Fatalf("FilterValue() error = %v", err)
+		}
+		got := addresses(s.Results(regions, 100))
+		want := []uint32{0xC000, 0xC001, 0xC004, 0xFF80}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Results() = %x, want %x", got, want)
+		}
+
+		// Lose a life
+		wram[1], wram[4], hram[0] = 2, 2, 2
+		if err := s.FilterPrevious(regions, Less); err != nil {
+			t.Fatalf("FilterPrevious() error = %v", err)
+		}
+		got = addresses(s.Results(regions, 100))
+		want = []uint32{0xC001, 0xC004, 0xFF80}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Results() = %x, want %x", got, want)
+		}
+
+		// Nothing happens
+		hram[0] = 5
+		if err := s.FilterPrevious(regions, Equal); err != nil {
+			t.Fatalf("FilterPrevious() error = %v", err)
+		}
+		results := s.Results(regions, 100)
+		wantResults := []Result{{0xC001, 2, 2}, {0xC004, 2, 2}}
+		if !reflect.DeepEqual(results, wantResults) {
+			t.Errorf("Results() = %v, want %v", results, wantResults)
+		}
+
+		wram[4] = 4
+		if err := s.FilterPrevious(regions, NotEqual); err != nil {
+			t.Fatalf("FilterPrevious() error = %v", err)
+		}
+		if s.Count() != 1 {
+			t.Errorf("Count() = %d, want %d", s.Count(), 1)
+		}
+	})
+
+	t.Run("Compares multibyte values", func(t *testing.T) {
+		ram := []byte{0x00, 0x01, 0x02, 0x01, 0x00}
+		regions := []Region{{Data: ram}}
+
+		le := New(regions, 2, false)
+		le.FilterValue(regions, Greater, 0x0200)
+		if got := addresses(le.Results(regions, 100)); !reflect.DeepEqual(got, []uint32{1}) {
+			t.Errorf("Results() = %x, want %x", got, []uint32{1})
+		}
+
+		be := New(regions, 2, true)
+		be.FilterValue(regions, Equal, 0x0102)
+		if got := addresses(be.Results(regions, 100)); !reflect.DeepEqual(got, []uint32{1}) {
+			t.Errorf("Results() = %x, want %x", got, []uint32{1})
+		}
+	})
+
+	t.Run("Limits the number of results", func(t *testing.T) {
+		regions := []Region{{Data: make([]byte, 200)}}
+		s := New(regions, 1, false)
+		if got := len(s.Results(regions, 50)); got != 50 {
+			t.Errorf("len(Results()) = %d, want %d", got, 50)
+		}
+	})
+
+	t.Run("Detects a change of memory layout", func(t *testing.T) {
+		s := New([]Region{{Data: make([]byte, 16)}}, 1, false)
+		err := s.FilterPrevious([]Region{{Data: make([]byte, 8)}}, Equal)
+		if err == nil {
+			t.Errorf("FilterPrevious() error = %v, want an error", err)
+		}
+	})
+}
+
+func Test_Watch_Read(t *testing.T) {
+	regions := []Region{
+		{Address: 0x0000, Data: []byte{1, 2, 3, 4}},
+		{Address: 0x8000, Data: []byte{0x34, 0x12}},
+	}
+	tests := []struct {
+		name  string
+		watch Watch
+		want  uint32
+		ok    bool
+	}{
+		{"Reads a byte", Watch{Address: 2, Size: 1}, 3, true},
+		{"Reads a little endian word", Watch{Address: 0x8000, Size: 2}, 0x1234, true},
+		{"Reads a big endian word", Watch{Address: 0x8000, Size: 2, BigEndian: true}, 0x3412, true},
+		{"Fails outside of the regions", Watch{Address: 0x4000, Size: 1}, 0, false},
+		{"Fails across the end of a region", Watch{Address: 3, Size: 2}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.watch.Read(regions)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Read() = %#x, %v, want %#x, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
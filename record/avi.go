@@ -0,0 +1,252 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// AVI flags
+const (
+	aviHasIndex      = 0x10
+	aviIsInterleaved = 0x100
+	aviKeyFrame      = 0x10
+)
+
+// aviMaxSize is the size AVI 1.0 files are kept under, as many readers take
+// their 32 bits sizes and offsets as signed
+const aviMaxSize = math.MaxInt32
+
+// ErrAVIFull is returned when a chunk would make the file exceed the size
+// limit of AVI 1.0 files. The file is still valid once closed.
+var ErrAVIFull = errors.New("the AVI file reached its 2GB size limit")
+
+// aviIndexEntry locates a chunk of the movi list
+type aviIndexEntry struct {
+	id     string
+	offset uint32 // from the movi fourcc
+	size   uint32
+}
+
+// AVI muxes MJPEG video and stereo 16 bits PCM audio in an AVI file. The
+// frame counts and sizes of the headers are written by Close, along with the
+// index. As an AVI 1.0 file, it is limited to about 2GB, see ErrAVIFull.
+type AVI struct {
+	w      io.WriteSeeker
+	limit  int64 // maximum size of the file
+	pos    int64 // write position
+	movi   int64 // position of the movi fourcc
+	index  []aviIndexEntry
+	frames uint32 // video frames written
+	blocks uint32 // audio frames written
+	width  int
+	height int
+
+	// positions of the fields patched by Close
+	riffSize, moviSize, totalFrames, videoLength, audioLength int64
+}
+
+// NewAVI writes the headers of an AVI file to w. The video runs at fps frames
+// per second, the audio at rate frames per second.
+func NewAVI(w io.WriteSeeker, width, height int, fps float64, rate int) (*AVI, error) {
+	a := &AVI{w: w, limit: aviMaxSize, width: width, height: height}
+	le := binary.LittleEndian
+	var h []byte
+	u16 := func(v int) { h = le.AppendUint16(h, uint16(v)) }
+	u32 := func(v int) { h = le.AppendUint32(h, uint32(v)) }
+	fourcc := func(s string) { h = append(h, s...) }
+	mark := func() int64 { return int64(len(h)) }
+
+	fourcc("RIFF")
+	a.riffSize = mark()
+	u32(0)
+	fourcc("AVI ")
+
+	fourcc("LIST")
+	hdrlSize := mark()
+	u32(0)
+	fourcc("hdrl")
+
+	fourcc("avih")
+	u32(56)
+	u32(int(math.Round(1e6 / fps))) // microseconds per frame
+	u32(width*height*3*int(fps) + rate*4)
+	u32(0) // padding granularity
+	u32(aviHasIndex | aviIsInterleaved)
+	a.totalFrames = mark()
+	u32(0)
+	u32(0) // initial frames
+	u32(2) // streams
+	u32(width * height * 3)
+	u32(width)
+	u32(height)
+	u32(0)
+	u32(0)
+	u32(0)
+	u32(0)
+
+	fourcc("LIST")
+	u32(4 + 8 + 56 + 8 + 40)
+	fourcc("strl")
+	fourcc("strh")
+	u32(56)
+	fourcc("vids")
+	fourcc("MJPG")
+	u32(0) // flags
+	u16(0) // priority
+	u16(0) // language
+	u32(0) // initial frames
+	u32(1000)
+	u32(int(math.Round(fps * 1000)))
+	u32(0) // start
+	a.videoLength = mark()
+	u32(0)
+	u32(width * height * 3)
+	u32(-1) // quality
+	u32(0)  // sample size, variable
+	u16(0)
+	u16(0)
+	u16(width)
+	u16(height)
+	fourcc("strf")
+	u32(40)
+	u32(40)
+	u32(width)
+	u32(height)
+	u16(1)  // planes
+	u16(24) // bits per pixel
+	fourcc("MJPG")
+	u32(width * height * 3)
+	u32(0)
+	u32(0)
+	u32(0)
+	u32(0)
+
+	fourcc("LIST")
+	u32(4 + 8 + 56 + 8 + 16)
+	fourcc("strl")
+	fourcc("strh")
+	u32(56)
+	fourcc("auds")
+	u32(0) // handler
+	u32(0) // flags
+	u16(0) // priority
+	u16(0) // language
+	u32(0) // initial frames
+	u32(4) // scale, bytes per frame
+	u32(rate * 4)
+	u32(0) // start
+	a.audioLength = mark()
+	u32(0)
+	u32(rate * 4)
+	u32(-1) // quality
+	u32(4)  // sample size
+	u16(0)
+	u16(0)
+	u16(0)
+	u16(0)
+	fourcc("strf")
+	u32(16)
+	u16(1) // PCM
+	u16(2) // channels
+	u32(rate)
+	u32(rate * 4)
+	u16(4)  // bytes per frame
+	u16(16) // bits per sample
+
+	le.PutUint32(h[hdrlSize:], uint32(mark()-hdrlSize-4))
+
+	fourcc("LIST")
+	a.moviSize = mark()
+	u32(0)
+	a.movi = mark()
+	fourcc("movi")
+
+	if _, err := w.Write(h); err != nil {
+		return nil, err
+	}
+	a.pos = int64(len(h))
+	return a, nil
+}
+
+// chunk writes a chunk to the movi list and indexes it. It refuses chunks
+// that wouldn't leave room for the index under the size limit.
+func (a *AVI) chunk(id string, data []byte) error {
+	b := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(data)))
+	b = append(b, data...)
+	// Chunks are padded to an even size
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	if a.pos+int64(len(b))+8+16*int64(len(a.index)+1) > a.limit {
+		return ErrAVIFull
+	}
+	a.index = append(a.index, aviIndexEntry{id, uint32(a.pos - a.movi), uint32(len(data))})
+	if _, err := a.w.Write(b); err != nil {
+		return err
+	}
+	a.pos += int64(len(b))
+	return nil
+}
+
+// WriteFrame appends a JPEG image to the video stream
+func (a *AVI) WriteFrame(jpeg []byte) error {
+	if err := a.chunk("00dc", jpeg); err != nil {
+		return err
+	}
+	a.frames++
+	return nil
+}
+
+// WriteAudio appends stereo 16 bits little endian samples to the audio stream
+func (a *AVI) WriteAudio(pcm []byte) error {
+	if len(pcm) == 0 {
+		return nil
+	}
+	if err := a.chunk("01wb", pcm); err != nil {
+		return err
+	}
+	a.blocks += uint32(len(pcm) / 4)
+	return nil
+}
+
+// Close writes the index and completes the headers. It doesn't close the
+// underlying writer.
+func (a *AVI) Close() error {
+	moviEnd := a.pos
+	idx := append([]byte("idx1"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(idx[4:], uint32(len(a.index)*16))
+	for _, e := range a.index {
+		idx = append(idx, e.id...)
+		idx = binary.LittleEndian.AppendUint32(idx, aviKeyFrame)
+		idx = binary.LittleEndian.AppendUint32(idx, e.offset)
+		idx = binary.LittleEndian.AppendUint32(idx, e.size)
+	}
+	if _, err := a.w.Write(idx); err != nil {
+		return err
+	}
+	a.pos += int64(len(idx))
+
+	patches := []struct {
+		offset int64
+		value  uint32
+	}{
+		{a.riffSize, uint32(a.pos - 8)},
+		{a.moviSize, uint32(moviEnd - a.movi)},
+		{a.totalFrames, a.frames},
+		{a.videoLength, a.frames},
+		{a.audioLength, a.blocks},
+	}
+	for _, p := range patches {
+		if _, err := a.w.Seek(p.offset, io.SeekStart); err != nil {
+			return err
+		}
+		if err := binary.Write(a.w, binary.LittleEndian, p.value); err != nil {
+			return err
+		}
+	}
+	_, err := a.w.Seek(0, io.SeekEnd)
+	return err
+}
@@ -0,0 +1,140 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// riffChunks returns the chunks of a RIFF list by fourcc, lists by their type
+func riffChunks(data []byte) map[string][]byte {
+	chunks := map[string][]byte{}
+	for len(data) >= 8 {
+		id := string(data[:4])
+		size := int(binary.LittleEndian.Uint32(data[4:]))
+		body := data[8 : 8+size]
+		if id == "LIST" {
+			id = string(body[:4])
+		}
+		if _, ok := chunks[id]; !ok {
+			chunks[id] = body
+		}
+		data = data[8+size+size%2:]
+	}
+	return chunks
+}
+
+func Test_AVI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.avi")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAVI(f, 32, 16, 60, 48000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame bytes.Buffer
+	jpeg.Encode(&frame, image.NewRGBA(image.Rect(0, 0, 32, 16)), nil)
+	for i := 0; i < 3; i++ {
+		a.WriteFrame(frame.Bytes())
+		a.WriteAudio(make([]byte, 800*4))
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	data, _ := os.ReadFile(path)
+	le := binary.LittleEndian
+
+	t.Run("Writes a RIFF AVI file", func(t *testing.T) {
+		if string(data[:4]) != "RIFF" || string(data[8:12]) != "AVI " {
+			t.Fatalf("header = %q, want RIFF AVI", data[:12])
+		}
+		if got := int(le.Uint32(data[4:])); got != len(data)-8 {
+			t.Errorf("RIFF size = %d, want %d", got, len(data)-8)
+		}
+	})
+
+	chunks := riffChunks(data[12:])
+	hdrl := riffChunks(chunks["hdrl"][4:])
+	movi := chunks["movi"]
+
+	t.Run("Counts the frames in the headers", func(t *testing.T) {
+		avih := hdrl["avih"]
+		if got := le.Uint32(avih[16:]); got != 3 {
+			t.Errorf("total frames = %d, want %d", got, 3)
+		}
+		if w, h := le.Uint32(avih[32:]), le.Uint32(avih[36:]); w != 32 || h != 16 {
+			t.Errorf("size = %dx%d, want %dx%d", w, h, 32, 16)
+		}
+		strl := riffChunks(hdrl["strl"][4:])
+		if got := le.Uint32(strl["strh"][32:]); got != 3 {
+			t.Errorf("video length = %d, want %d", got, 3)
+		}
+	})
+
+	t.Run("Indexes the chunks of the movi list", func(t *testing.T) {
+		idx := chunks["idx1"]
+		if len(idx) != 6*16 {
+			t.Fatalf("index = %d entries, want %d", len(idx)/16, 6)
+		}
+		for i := 0; i < 6; i++ {
+			e := idx[i*16:]
+			id := string(e[:4])
+			offset := le.Uint32(e[8:])
+			size := le.Uint32(e[12:])
+			if string(movi[offset:offset+4]) != id || le.Uint32(movi[offset+4:]) != size {
+				t.Errorf("index entry %d = %s at %d, doesn't match the movi list", i, id, offset)
+			}
+		}
+		first := le.Uint32(idx[8:])
+		img, err := jpeg.Decode(bytes.NewReader(movi[first+8:]))
+		if err != nil || img.Bounds().Dx() != 32 {
+			t.Errorf("first frame = %v, %v, want a 32 pixels wide JPEG", img, err)
+		}
+	})
+}
+
+func Test_AVI_limit(t *testing.T) {
+	t.Run("Refuses chunks past the size limit and stays valid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.avi")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		a, err := NewAVI(f, 32, 16, 60, 48000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.limit = 16 * 1024
+
+		frames := 0
+		for ; frames < 100; frames++ {
+			if err := a.WriteFrame(make([]byte, 1000)); err != nil {
+				if err != ErrAVIFull {
+					t.Fatalf("WriteFrame() error = %v, want %v", err, ErrAVIFull)
+				}
+				break
+			}
+		}
+		if frames == 0 || frames == 100 {
+			t.Fatalf("frames = %d, want the limit to stop the recording", frames)
+		}
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(path)
+		if int64(len(data)) > a.limit {
+			t.Errorf("size = %d, want at most %d", len(data), a.limit)
+		}
+		if got := len(riffChunks(data[12:])["idx1"]) / 16; got != frames {
+			t.Errorf("index = %d entries, want %d", got, frames)
+		}
+	})
+}
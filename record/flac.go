@@ -0,0 +1,300 @@
+package record
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"hash"
+	"io"
+)
+
+// flacBlockSize is the number of frames per FLAC frame
+const flacBlockSize = 4096
+
+// flacMaxOrder is the highest order of the fixed predictors
+const flacMaxOrder = 4
+
+// FLAC encodes stereo 16 bits PCM to a FLAC file. Each channel is predicted
+// with the best of the fixed polynomial predictors, and the residual is Rice
+// coded. The number of samples and the MD5 signature of the audio are written
+// by Close.
+type FLAC struct {
+	w       io.WriteSeeker
+	rate    int
+	pending []int32 // interleaved samples not encoded yet
+	frames  uint64  // number of FLAC frames written
+	total   uint64  // number of stereo frames written
+	md5     hash.Hash
+}
+
+// NewFLAC writes the header of a FLAC file of the given sample rate to w
+func NewFLAC(w io.WriteSeeker, rate int) (*FLAC, error) {
+	e := &FLAC{w: w, rate: rate, md5: md5.New()}
+	if _, err := w.Write(append([]byte("fLaC"), e.streamInfo()...)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// streamInfo returns the STREAMINFO metadata block, which is the only one
+func (e *FLAC) streamInfo() []byte {
+	var b bitWriter
+	b.write(1, 1)              // last metadata block
+	b.write(0, 7)              // STREAMINFO
+	b.write(34, 24)            // length
+	b.write(flacBlockSize, 16) // minimum block size
+	b.write(flacBlockSize, 16) // maximum block size
+	b.write(0, 24)             // minimum frame size, unknown
+	b.write(0, 24)             // maximum frame size, unknown
+	b.write(uint64(e.rate), 20)
+	b.write(2-1, 3)  // channels
+	b.write(16-1, 5) // bits per sample
+	b.write(e.total, 36)
+	for _, c := range e.md5.Sum(nil) {
+		b.write(uint64(c), 8)
+	}
+	return b.bytes()
+}
+
+// Write appends stereo 16 bits little endian samples
+func (e *FLAC) Write(pcm []byte) error {
+	e.md5.Write(pcm)
+	for i := 0; i+1 < len(pcm); i += 2 {
+		e.pending = append(e.pending, int32(int16(binary.LittleEndian.Uint16(pcm[i:]))))
+	}
+	for len(e.pending) >= flacBlockSize*2 {
+		if err := e.encode(e.pending[:flacBlockSize*2]); err != nil {
+			return err
+		}
+		e.pending = e.pending[flacBlockSize*2:]
+	}
+	return nil
+}
+
+// Close encodes the remaining samples and completes the STREAMINFO block. It
+// doesn't close the underlying writer.
+func (e *FLAC) Close() error {
+	if len(e.pending) >= 2 {
+		if err := e.encode(e.pending[:len(e.pending)&^1]); err != nil {
+			return err
+		}
+	}
+	e.pending = nil
+	if _, err := e.w.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(e.streamInfo()); err != nil {
+		return err
+	}
+	_, err := e.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+// encode writes a FLAC frame of interleaved stereo samples
+func (e *FLAC) encode(samples []int32) error {
+	n := len(samples) / 2
+
+	var b bitWriter
+	b.write(0x3FFE, 14) // sync code
+	b.write(0, 1)       // reserved
+	b.write(0, 1)       // fixed block size
+	b.write(7, 4)       // block size in 16 bits at the end of the header
+	b.write(0, 4)       // sample rate of STREAMINFO
+	b.write(1, 4)       // left and right channels
+	b.write(4, 3)       // 16 bits per sample
+	b.write(0, 1)       // reserved
+	b.writeUTF8(e.frames)
+	b.write(uint64(n-1), 16)
+	b.write(uint64(crc8(b.bytes())), 8)
+
+	channel := make([]int32, n)
+	for c := 0; c < 2; c++ {
+		for i := range channel {
+			channel[i] = samples[i*2+c]
+		}
+		b.subframe(channel)
+	}
+	b.align()
+	frame := b.bytes()
+	frame = binary.BigEndian.AppendUint16(frame, crc16(frame))
+
+	if _, err := e.w.Write(frame); err != nil {
+		return err
+	}
+	e.frames++
+	e.total += uint64(n)
+	return nil
+}
+
+// residual computes the residual of the fixed predictor of the given order
+func residual(dst []int64, s []int32, order int) []int64 {
+	dst = dst[:0]
+	for i := order; i < len(s); i++ {
+		var r int64
+		switch order {
+		case 0:
+			r = int64(s[i])
+		case 1:
+			r = int64(s[i]) - int64(s[i-1])
+		case 2:
+			r = int64(s[i]) - 2*int64(s[i-1]) + int64(s[i-2])
+		case 3:
+			r = int64(s[i]) - 3*int64(s[i-1]) + 3*int64(s[i-2]) - int64(s[i-3])
+		case 4:
+			r = int64(s[i]) - 4*int64(s[i-1]) + 6*int64(s[i-2]) - 4*int64(s[i-3]) + int64(s[i-4])
+		}
+		dst = append(dst, r)
+	}
+	return dst
+}
+
+// fold maps signed residuals to unsigned values for Rice coding
+func fold(r int64) uint64 {
+	return uint64(r<<1) ^ uint64(r>>63)
+}
+
+// riceCost returns the best Rice parameter for a residual and its size in bits
+func riceCost(res []int64) (int, int) {
+	best, bestBits := 0, -1
+	for k := 0; k <= 14; k++ {
+		bits := len(res) * (k + 1)
+		for _, r := range res {
+			bits += int(fold(r) >> uint(k))
+		}
+		if bestBits < 0 || bits < bestBits {
+			best, bestBits = k, bits
+		}
+	}
+	return best, bestBits
+}
+
+// subframe writes a channel with the cheapest fixed predictor, or verbatim if
+// prediction doesn't help
+func (b *bitWriter) subframe(s []int32) {
+	order, param, cost := -1, 0, len(s)*16
+	var res []int64
+	for o := 0; o <= flacMaxOrder && o < len(s); o++ {
+		res = residual(res, s, o)
+		k, bits := riceCost(res)
+		bits += o*16 + 10 // warm-up samples and residual header
+		if bits < cost {
+			order, param, cost = o, k, bits
+		}
+	}
+
+	b.write(0, 1) // padding
+	if order < 0 {
+		b.write(1, 6) // verbatim
+		b.write(0, 1) // no wasted bits
+		for _, v := range s {
+			b.write(uint64(uint16(v)), 16)
+		}
+		return
+	}
+	b.write(uint64(8|order), 6) // fixed predictor
+	b.write(0, 1)               // no wasted bits
+	for _, v := range s[:order] {
+		b.write(uint64(uint16(v)), 16)
+	}
+	b.write(0, 2) // Rice coding with 4 bits parameters
+	b.write(0, 4) // a single partition
+	b.write(uint64(param), 4)
+	for _, r := range residual(res, s, order) {
+		u := fold(r)
+		b.writeUnary(u >> uint(param))
+		b.write(u&(1<<uint(param)-1), uint(param))
+	}
+}
+
+// bitWriter packs values MSB first
+type bitWriter struct {
+	buf []byte
+	cur byte // byte being filled
+	n   uint // bits used in cur
+}
+
+// write appends the n low bits of v
+func (b *bitWriter) write(v uint64, n uint) {
+	for n > 0 {
+		take := 8 - b.n
+		if take > n {
+			take = n
+		}
+		bits := byte(v>>(n-take)) & (1<<take - 1)
+		b.cur |= bits << (8 - b.n - take)
+		b.n += take
+		n -= take
+		if b.n == 8 {
+			b.buf = append(b.buf, b.cur)
+			b.cur, b.n = 0, 0
+		}
+	}
+}
+
+// writeUnary appends q zeros followed by a one
+func (b *bitWriter) writeUnary(q uint64) {
+	for ; q >= 32; q -= 32 {
+		b.write(0, 32)
+	}
+	b.write(1, uint(q)+1)
+}
+
+// writeUTF8 appends a frame number with the UTF-8 like coding of FLAC
+func (b *bitWriter) writeUTF8(v uint64) {
+	if v < 0x80 {
+		b.write(v, 8)
+		return
+	}
+	n := uint(2) // number of bytes
+	for v >= 1<<(5*n+1) {
+		n++
+	}
+	b.write((1<<n-1)<<1, n+1)
+	b.write(v>>(6*(n-1)), 8-n-1)
+	for i := int(n) - 2; i >= 0; i-- {
+		b.write(0x80|(v>>(6*uint(i))&0x3F), 8)
+	}
+}
+
+// align pads with zeros to the next byte
+func (b *bitWriter) align() {
+	if b.n > 0 {
+		b.write(0, 8-b.n)
+	}
+}
+
+// bytes returns the complete bytes written so far
+func (b *bitWriter) bytes() []byte {
+	return b.buf
+}
+
+// crc8 is the CRC of FLAC frame headers, polynomial x^8 + x^2 + x + 1
+func crc8(data []byte) byte {
+	var crc byte
+	for _, d := range data {
+		crc ^= d
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// crc16 is the CRC of FLAC frames, polynomial x^16 + x^15 + x^2 + 1
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, d := range data {
+		crc ^= uint16(d) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,234 @@
+package record
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bitReader reads values MSB first
+type bitReader struct {
+	buf []byte
+	pos int // in bits
+}
+
+func (r *bitReader) read(n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		bit := r.buf[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) signed(n int) int64 {
+	return int64(r.read(n)<<(64-n)) >> (64 - n)
+}
+
+// decodeFLAC decodes the subset of FLAC written by the encoder. It returns the
+// STREAMINFO fields and the interleaved samples.
+func decodeFLAC(data []byte) (rate int, total uint64, sum []byte, samples []int16, err error) {
+	if string(data[:4]) != "fLaC" {
+		return 0, 0, nil, nil, errors.New("bad magic")
+	}
+	r := &bitReader{buf: data, pos: 32}
+	r.read(1 + 7 + 24 + 16 + 16 + 24 + 24)
+	rate = int(r.read(20))
+	if r.read(3) != 1 || r.read(5) != 15 {
+		return 0, 0, nil, nil, errors.New("not stereo 16 bits")
+	}
+	total = r.read(36)
+	sum = data[r.pos/8 : r.pos/8+16]
+	r.pos += 128
+
+	for frame := uint64(0); r.pos/8 < len(data); frame++ {
+		start := r.pos / 8
+		if r.read(14) != 0x3FFE {
+			return 0, 0, nil, nil, errors.New("bad sync code")
+		}
+		r.read(1 + 1 + 4 + 4 + 4 + 3 + 1)
+		first := r.read(8)
+		num := first
+		if first >= 0x80 {
+			n := 0
+			for first<<n&0x80 != 0 {
+				n++
+			}
+			num = first & (0xFF >> (n + 1))
+			for i := 1; i < n; i++ {
+				num = num<<6 | r.read(8)&0x3F
+			}
+		}
+		if num != frame {
+			return 0, 0, nil, nil, errors.New("bad frame number")
+		}
+		n := int(r.read(16)) + 1
+		if crc8(data[start:r.pos/8]) != byte(r.read(8)) {
+			return 0, 0, nil, nil, errors.New("bad header CRC")
+		}
+
+		channels := [2][]int64{}
+		for c := range channels {
+			r.read(1)
+			kind := r.read(6)
+			r.read(1)
+			s := make([]int64, 0, n)
+			if kind == 1 {
+				for i := 0; i < n; i++ {
+					s = append(s, r.signed(16))
+				}
+				channels[c] = s
+				continue
+			}
+			order := int(kind & 7)
+			for i := 0; i < order; i++ {
+				s = append(s, r.signed(16))
+			}
+			r.read(2 + 4)
+			k := int(r.read(4))
+			for i := order; i < n; i++ {
+				q := 0
+				for r.read(1) == 0 {
+					q++
+				}
+				u := uint64(q)<<k | r.read(k)
+				res := int64(u>>1) ^ -int64(u&1)
+				var p int64
+				switch order {
+				case 1:
+					p = s[i-1]
+				case 2:
+					p = 2*s[i-1] - s[i-2]
+				case 3:
+					p = 3*s[i-1] - 3*s[i-2] + s[i-3]
+				case 4:
+					p = 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+				}
+				s = append(s, p+res)
+			}
+			channels[c] = s
+		}
+		if r.pos%8 != 0 {
+			r.pos += 8 - r.pos%8
+		}
+		if crc16(data[start:r.pos/8]) != uint16(r.read(16)) {
+			return 0, 0, nil, nil, errors.New("bad frame CRC")
+		}
+		for i := 0; i < n; i++ {
+			samples = append(samples, int16(channels[0][i]), int16(channels[1][i]))
+		}
+	}
+	return rate, total, sum, samples, nil
+}
+
+func Test_FLAC(t *testing.T) {
+	// Noise is not predictable and ends up verbatim, a sine is predicted
+	tests := []struct {
+		name   string
+		frames int
+		sample func(i int) int16
+	}{
+		{"Encode a sine", 10000, func(i int) int16 {
+			return int16(20000 * math.Sin(float64(i)/20))
+		}},
+		{"Encode noise", 5000, func(i int) int16 {
+			return int16(rand.Intn(65536) - 32768)
+		}},
+		{"Encode silence", 100, func(i int) int16 { return 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := []byte{}
+			want := []int16{}
+			for i := 0; i < tt.frames; i++ {
+				l, r := tt.sample(i), tt.sample(i+7)
+				want = append(want, l, r)
+				pcm = binary.LittleEndian.AppendUint16(pcm, uint16(l))
+				pcm = binary.LittleEndian.AppendUint16(pcm, uint16(r))
+			}
+
+			path := filepath.Join(t.TempDir(), "test.flac")
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := NewFLAC(f, 44100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Feed the encoder in batches like the audio callbacks do
+			for i := 0; i < len(pcm); i += 1600 {
+				end := i + 1600
+				if end > len(pcm) {
+					end = len(pcm)
+				}
+				if err := e.Write(pcm[i:end]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := e.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			data, _ := os.ReadFile(path)
+			rate, total, sum, got, err := decodeFLAC(data)
+			if err != nil {
+				t.Fatalf("decodeFLAC() error = %v", err)
+			}
+			if rate != 44100 || total != uint64(tt.frames) {
+				t.Errorf("STREAMINFO = %d Hz %d frames, want %d Hz %d frames", rate, total, 44100, tt.frames)
+			}
+			if s := md5.Sum(pcm); !bytes.Equal(sum, s[:]) {
+				t.Errorf("MD5 = %x, want %x", sum, s)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("decoded %d samples, want %d", len(got), len(want))
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("sample %d = %d, want %d", i, got[i], want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("Compresses predictable audio", func(t *testing.T) {
+		var b bitWriter
+		s := make([]int32, flacBlockSize)
+		for i := range s {
+			s[i] = int32(20000 * math.Sin(float64(i)/20))
+		}
+		b.subframe(s)
+		if len(b.bytes()) > flacBlockSize {
+			t.Errorf("subframe() = %d bytes, want at most %d", len(b.bytes()), flacBlockSize)
+		}
+	})
+}
+
+func Test_bitWriter_writeUTF8(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0xC2, 0x80}},
+		{0x7FF, []byte{0xDF, 0xBF}},
+		{0x800, []byte{0xE0, 0xA0, 0x80}},
+		{0x10000, []byte{0xF0, 0x90, 0x80, 0x80}},
+	}
+	for _, tt := range tests {
+		var b bitWriter
+		b.writeUTF8(tt.v)
+		if !bytes.Equal(b.bytes(), tt.want) {
+			t.Errorf("writeUTF8(%X) = %X, want %X", tt.v, b.bytes(), tt.want)
+		}
+	}
+}
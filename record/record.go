@@ -0,0 +1,231 @@
+// Package record records the gameplay to files, for music rips, bug reports
+// and clips. The audio is captured from the audio callbacks of the core,
+// before any resampling, and encoded to WAV or FLAC. The video is captured at
+// the native resolution of the core once per emulated frame, and muxed with
+// the audio in an MJPEG AVI file.
+package record
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+)
+
+// jpegQuality is the quality of the frames of the video recordings
+const jpegQuality = 90
+
+// encoder encodes stereo 16 bits PCM to a file
+type encoder interface {
+	Write(pcm []byte) error
+	Close() error
+}
+
+var (
+	vid video.Renderer
+
+	audioFile    *os.File
+	audioEncoder encoder
+
+	videoFile  *os.File
+	videoAVI   *AVI
+	videoAudio []byte      // audio of the frame being emulated
+	canvas     *image.RGBA // frame at the size of the recording
+	jpegBuf    bytes.Buffer
+)
+
+// Init is there mainly for dependency injection.
+// Call Init before calling other functions of this package.
+func Init(v video.Renderer) {
+	vid = v
+}
+
+// datedPath returns a path in the recordings directory named after the game
+// and the date, and creates the directory
+func datedPath(ext string) (string, error) {
+	dir := settings.Current.RecordingsDirectory
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, utils.DatedName(state.GamePath)+ext), nil
+}
+
+// AudioRecording returns true while the audio is recorded
+func AudioRecording() bool {
+	return audioEncoder != nil
+}
+
+// StartAudio starts recording the audio of the game in the recordings
+// directory, in the format set in the settings
+func StartAudio() error {
+	if err := StopAudio(); err != nil {
+		return err
+	}
+	if state.Core == nil || !state.CoreRunning {
+		return errors.New("no game running")
+	}
+	rate := int(state.Core.GetSystemAVInfo().Timing.SampleRate)
+
+	ext := ".wav"
+	if settings.Current.AudioRecordingFormat == "FLAC" {
+		ext = ".flac"
+	}
+	path, err := datedPath(ext)
+	if err != nil {
+		return err
+	}
+	audioFile, err = os.Create(path)
+	if err == nil {
+		if ext == ".flac" {
+			audioEncoder, err = NewFLAC(audioFile, rate)
+		} else {
+			audioEncoder, err = NewWAV(audioFile, rate)
+		}
+	}
+	if err != nil {
+		if audioFile != nil {
+			audioFile.Close()
+		}
+		audioFile, audioEncoder = nil, nil
+		return err
+	}
+	return nil
+}
+
+// StopAudio ends the recording of the audio and completes the file
+func StopAudio() error {
+	if audioEncoder == nil {
+		return nil
+	}
+	err := audioEncoder.Close()
+	if cerr := audioFile.Close(); err == nil {
+		err = cerr
+	}
+	audioFile, audioEncoder = nil, nil
+	return err
+}
+
+// Audio appends stereo 16 bits PCM of the core to the recordings, if any
+func Audio(pcm []byte) {
+	if videoAVI != nil {
+		videoAudio = append(videoAudio, pcm...)
+	}
+	if audioEncoder == nil {
+		return
+	}
+	if err := audioEncoder.Write(pcm); err != nil {
+		StopAudio()
+		ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+	}
+}
+
+// VideoRecording returns true while the video is recorded
+func VideoRecording() bool {
+	return videoAVI != nil
+}
+
+// StartVideo starts recording the video and the audio of the game to an AVI
+// file at path, or in the recordings directory if path is empty. The size of
+// the video is the size of the last frame of the core.
+func StartVideo(path string) error {
+	if err := StopVideo(); err != nil {
+		return err
+	}
+	if state.Core == nil || !state.CoreRunning {
+		return errors.New("no game running")
+	}
+	avi := state.Core.GetSystemAVInfo()
+	if avi.Timing.FPS <= 0 {
+		return errors.New("unknown frame rate")
+	}
+
+	size := image.Rect(0, 0, int(avi.Geometry.BaseWidth), int(avi.Geometry.BaseHeight))
+	if frame := vid.Frame(); frame != nil {
+		size = frame.Bounds()
+	}
+	if size.Empty() {
+		return errors.New("unknown video size")
+	}
+
+	if path == "" {
+		var err error
+		if path, err = datedPath(".avi"); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	a, err := NewAVI(f, size.Dx(), size.Dy(), avi.Timing.FPS, int(avi.Timing.SampleRate))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	videoFile, videoAVI = f, a
+	videoAudio = videoAudio[:0]
+	canvas = image.NewRGBA(size)
+	return nil
+}
+
+// StopVideo ends the recording of the video and completes the file
+func StopVideo() error {
+	if videoAVI == nil {
+		return nil
+	}
+	err := videoAVI.WriteAudio(videoAudio)
+	if cerr := videoAVI.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := videoFile.Close(); err == nil {
+		err = cerr
+	}
+	videoFile, videoAVI, canvas = nil, nil, nil
+	return err
+}
+
+// Process appends the last frame of the core and the audio of the frame to
+// the video recording, if any. It is meant to be called once per emulated
+// frame, so that frames duped by the core are recorded too. Frames of another
+// size than the recording are cropped or padded.
+func Process() {
+	if videoAVI == nil {
+		return
+	}
+	if frame := vid.Frame(); frame != nil {
+		if frame.Bounds() != canvas.Bounds() {
+			draw.Draw(canvas, canvas.Bounds(), image.Black, image.Point{}, draw.Src)
+		}
+		draw.Draw(canvas, canvas.Bounds(), frame, image.Point{}, draw.Src)
+	}
+	jpegBuf.Reset()
+	err := jpeg.Encode(&jpegBuf, canvas, &jpeg.Options{Quality: jpegQuality})
+	if err == nil {
+		err = videoAVI.WriteFrame(jpegBuf.Bytes())
+	}
+	if err == nil {
+		err = videoAVI.WriteAudio(videoAudio)
+	}
+	videoAudio = videoAudio[:0]
+	if errors.Is(err, ErrAVIFull) {
+		if err := StopVideo(); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Warning, "Record", "Recording stopped, the AVI file reached its 2GB size limit.")
+		return
+	}
+	if err != nil {
+		StopVideo()
+		ntf.DisplayAndLog(ntf.Error, "Record", err.Error())
+	}
+}
@@ -0,0 +1,63 @@
+package record
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// wavHeaderSize is the size of the RIFF header preceding the samples
+const wavHeaderSize = 44
+
+// WAV encodes stereo 16 bits PCM to a WAV file. The sizes in the header are
+// only known once the recording is over, they are written by Close.
+type WAV struct {
+	w    io.WriteSeeker
+	size uint32 // bytes of samples written
+}
+
+// NewWAV writes the header of a WAV file of the given sample rate to w
+func NewWAV(w io.WriteSeeker, rate int) (*WAV, error) {
+	h := make([]byte, 0, wavHeaderSize)
+	h = append(h, "RIFF"...)
+	h = binary.LittleEndian.AppendUint32(h, 0) // patched by Close
+	h = append(h, "WAVEfmt "...)
+	h = binary.LittleEndian.AppendUint32(h, 16) // size of the fmt chunk
+	h = binary.LittleEndian.AppendUint16(h, 1)  // PCM
+	h = binary.LittleEndian.AppendUint16(h, 2)  // channels
+	h = binary.LittleEndian.AppendUint32(h, uint32(rate))
+	h = binary.LittleEndian.AppendUint32(h, uint32(rate)*4) // bytes per second
+	h = binary.LittleEndian.AppendUint16(h, 4)              // bytes per frame
+	h = binary.LittleEndian.AppendUint16(h, 16)             // bits per sample
+	h = append(h, "data"...)
+	h = binary.LittleEndian.AppendUint32(h, 0) // patched by Close
+	if _, err := w.Write(h); err != nil {
+		return nil, err
+	}
+	return &WAV{w: w}, nil
+}
+
+// Write appends stereo 16 bits little endian samples
+func (e *WAV) Write(pcm []byte) error {
+	n, err := e.w.Write(pcm)
+	e.size += uint32(n)
+	return err
+}
+
+// Close writes the sizes of the header. It doesn't close the underlying
+// writer.
+func (e *WAV) Close() error {
+	patch := func(offset int64, v uint32) error {
+		if _, err := e.w.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		return binary.Write(e.w, binary.LittleEndian, v)
+	}
+	if err := patch(4, wavHeaderSize-8+e.size); err != nil {
+		return err
+	}
+	if err := patch(40, e.size); err != nil {
+		return err
+	}
+	_, err := e.w.Seek(0, io.SeekEnd)
+	return err
+}
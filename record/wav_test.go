@@ -0,0 +1,54 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WAV(t *testing.T) {
+	t.Run("Writes the sizes in the header on close", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wav")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := NewWAV(f, 32000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pcm := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+		e.Write(pcm)
+		e.Write(pcm)
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		data, _ := os.ReadFile(path)
+		if len(data) != wavHeaderSize+16 {
+			t.Fatalf("len = %d, want %d", len(data), wavHeaderSize+16)
+		}
+		le := binary.LittleEndian
+		checks := []struct {
+			name string
+			got  uint32
+			want uint32
+		}{
+			{"RIFF size", le.Uint32(data[4:]), 36 + 16},
+			{"sample rate", le.Uint32(data[24:]), 32000},
+			{"byte rate", le.Uint32(data[28:]), 128000},
+			{"data size", le.Uint32(data[40:]), 16},
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("%s = %d, want %d", c.name, c.got, c.want)
+			}
+		}
+		if !bytes.Equal(data[wavHeaderSize:], append(pcm, pcm...)) {
+			t.Errorf("samples = %v, want %v", data[wavHeaderSize:], append(pcm, pcm...))
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package rewind
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Buffer is a bounded ring of serialized states. Only the most recent state is
+// kept in full, older states are stored as compressed deltas against their
+// successor. When the deltas exceed the capacity, the oldest ones are dropped.
+type Buffer struct {
+	capacity int      // maximum number of bytes used by the deltas
+	used     int      // number of bytes currently used by the deltas
+	deltas   [][]byte // ring of deltas, from oldest to newest
+	head     int      // index of the oldest delta in the ring
+	count    int      // number of deltas in the ring
+	current  []byte   // the most recent state, uncompressed
+	scratch  []byte   // reused buffer to encode deltas
+}
+
+// NewBuffer creates a rewind buffer that uses at most capacity bytes to store
+// deltas.
+func NewBuffer(capacity int) *Buffer {
+	return &Buffer{
+		capacity: capacity,
+		deltas:   make([][]byte, 64),
+	}
+}
+
+// Len returns the number of states that can be restored
+func (b *Buffer) Len() int {
+	if b.current == nil {
+		return 0
+	}
+	return b.count + 1
+}
+
+// Used returns the number of bytes used by the deltas
+func (b *Buffer) Used() int {
+	return b.used
+}
+
+// Clear drops all the stored states
+func (b *Buffer) Clear() {
+	for i := range b.deltas {
+		b.deltas[i] = nil
+	}
+	b.head = 0
+	b.count = 0
+	b.used = 0
+	b.current = nil
+}
+
+// Push stores a new state. The state is copied, so the caller can reuse it.
+// Pushing a state of a different size than the previous one clears the buffer,
+// since deltas can only be computed between states of the same size.
+func (b *Buffer) Push(state []byte) {
+	if b.current != nil && len(b.current) != len(state) {
+		b.Clear()
+	}
+
+	if b.current == nil {
+		b.current = append([]byte{}, state...)
+		return
+	}
+
+	b.scratch = encodeDelta(b.scratch[:0], b.current, state)
+	delta := append([]byte{}, b.scratch...)
+	copy(b.current, state)
+
+	if len(delta) > b.capacity {
+		// A single delta doesn't fit, history is lost but we keep the new state
+		b.Clear()
+		b.current = append([]byte{}, state...)
+		return
+	}
+
+	for b.used+len(delta) > b.capacity && b.count > 0 {
+		b.dropOldest()
+	}
+
+	if b.count == len(b.deltas) {
+		b.grow()
+	}
+	b.deltas[(b.head+b.count)%len(b.deltas)] = delta
+	b.count++
+	b.used += len(delta)
+}
+
+// Pop returns the most recent state and removes it from the buffer. The
+// previous state becomes the most recent one. It returns false when the buffer
+// is empty.
+func (b *Buffer) Pop() ([]byte, bool) {
+	if b.current == nil {
+		return nil, false
+	}
+
+	state := append([]byte{}, b.current...)
+
+	if b.count == 0 {
+		b.current = nil
+		return state, true
+	}
+
+	i := (b.head + b.count - 1) % len(b.deltas)
+	delta := b.deltas[i]
+	b.deltas[i] = nil
+	b.count--
+	b.used -= len(delta)
+
+	if err := applyDelta(b.current, delta); err != nil {
+		// Should never happen as we encoded the delta ourselves
+		b.Clear()
+	}
+
+	return state, true
+}
+
+func (b *Buffer) dropOldest() {
+	b.used -= len(b.deltas[b.head])
+	b.deltas[b.head] = nil
+	b.head = (b.head + 1) % len(b.deltas)
+	b.count--
+}
+
+// grow doubles the number of slots in the ring while keeping the order
+func (b *Buffer) grow() {
+	deltas := make([][]byte, len(b.deltas)*2)
+	for i := 0; i < b.count; i++ {
+		deltas[i] = b.deltas[(b.head+i)%len(b.deltas)]
+	}
+	b.deltas = deltas
+	b.head = 0
+}
+
+// encodeDelta XORs a and b and run-length encodes the zero bytes of the result.
+// The output is a sequence of chunks made of the number of unchanged bytes to
+// skip, the number of changed bytes, and the changed bytes XORed. a and b must
+// have the same length.
+func encodeDelta(dst, a, b []byte) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	i := 0
+	for i < len(a) {
+		start := i
+		for i < len(a) && a[i] == b[i] {
+			i++
+		}
+		skip := i - start
+
+		start = i
+		for i < len(a) {
+			if a[i] == b[i] {
+				// Small gaps are cheaper to store as literals than as a new chunk
+				same := 0
+				for i+same < len(a) && a[i+same] == b[i+same] && same < 4 {
+					same++
+				}
+				if same == 4 || i+same == len(a) {
+					break
+				}
+				i += same
+				continue
+			}
+			i++
+		}
+		length := i - start
+
+		if length == 0 {
+			break
+		}
+
+		dst = append(dst, tmp[:binary.PutUvarint(tmp[:], uint64(skip))]...)
+		dst = append(dst, tmp[:binary.PutUvarint(tmp[:], uint64(length))]...)
+		for j := start; j < i; j++ {
+			dst = append(dst, a[j]^b[j])
+		}
+	}
+	return dst
+}
+
+// applyDelta XORs a delta produced by encodeDelta into state, in place.
+// Since XOR is symmetric, applying the delta of a and b to b gives a.
+func applyDelta(state, delta []byte) error {
+	offset := 0
+	for len(delta) > 0 {
+		skip, n := binary.Uvarint(delta)
+		if n <= 0 {
+			return errors.New("invalid delta")
+		}
+		delta = delta[n:]
+
+		length, n := binary.Uvarint(delta)
+		if n <= 0 || uint64(len(delta)-n) < length {
+			return errors.New("invalid delta")
+		}
+		delta = delta[n:]
+
+		offset += int(skip)
+		if offset+int(length) > len(state) {
+			return errors.New("delta out of bounds")
+		}
+		for j := 0; j < int(length); j++ {
+			state[offset+j] ^= delta[j]
+		}
+		offset += int(length)
+		delta = delta[length:]
+	}
+	return nil
+}
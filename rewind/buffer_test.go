@@ -0,0 +1,139 @@
+package rewind
+
+import (
+	"bytes"
+	"testing"
+)
+
+func makeState(size int, seed byte) []byte {
+	s := make([]byte, size)
+	for i := range s {
+		s[i] = byte(i)
+	}
+	// Only a few bytes change between frames, like in a real core
+	s[10] = seed
+	s[size/2] = seed * 3
+	s[size-1] = seed + 7
+	return s
+}
+
+func Test_encodeDelta(t *testing.T) {
+	t.Run("Roundtrips between two states", func(t *testing.T) {
+		a := makeState(1024, 1)
+		b := makeState(1024, 2)
+		delta := encodeDelta(nil, a, b)
+		got := append([]byte{}, b...)
+		if err := applyDelta(got, delta); err != nil {
+			t.Fatalf("applyDelta() error = %v", err)
+		}
+		if !bytes.Equal(got, a) {
+			t.Errorf("applyDelta() = %v, want %v", got, a)
+		}
+	})
+
+	t.Run("Compresses unchanged bytes", func(t *testing.T) {
+		a := makeState(4096, 1)
+		b := makeState(4096, 2)
+		delta := encodeDelta(nil, a, b)
+		if len(delta) > 16 {
+			t.Errorf("len(delta) = %v, want <= %v", len(delta), 16)
+		}
+	})
+
+	t.Run("Identical states produce an empty delta", func(t *testing.T) {
+		a := makeState(256, 1)
+		delta := encodeDelta(nil, a, a)
+		if len(delta) != 0 {
+			t.Errorf("len(delta) = %v, want %v", len(delta), 0)
+		}
+	})
+
+	t.Run("Detects a truncated delta", func(t *testing.T) {
+		a := makeState(256, 1)
+		b := makeState(256, 2)
+		delta := encodeDelta(nil, a, b)
+		err := applyDelta(b, delta[:len(delta)-1])
+		if err == nil {
+			t.Errorf("applyDelta() error = %v, want an error", err)
+		}
+	})
+}
+
+func TestBuffer(t *testing.T) {
+	t.Run("Pops states in reverse order", func(t *testing.T) {
+		b := NewBuffer(1024 * 1024)
+		for i := 0; i < 10; i++ {
+			b.Push(makeState(512, byte(i)))
+		}
+		if b.Len() != 10 {
+			t.Fatalf("Len() = %v, want %v", b.Len(), 10)
+		}
+		for i := 9; i >= 0; i-- {
+			got, ok := b.Pop()
+			if !ok {
+				t.Fatalf("Pop() ok = %v, want %v", ok, true)
+			}
+			if want := makeState(512, byte(i)); !bytes.Equal(got, want) {
+				t.Errorf("Pop() = %v, want %v", got, want)
+			}
+		}
+		if _, ok := b.Pop(); ok {
+			t.Errorf("Pop() ok = %v, want %v", ok, false)
+		}
+	})
+
+	t.Run("Grows past the initial number of slots", func(t *testing.T) {
+		b := NewBuffer(1024 * 1024)
+		for i := 0; i < 200; i++ {
+			b.Push(makeState(512, byte(i)))
+		}
+		if b.Len() != 200 {
+			t.Errorf("Len() = %v, want %v", b.Len(), 200)
+		}
+		got, _ := b.Pop()
+		if want := makeState(512, 199); !bytes.Equal(got, want) {
+			t.Errorf("Pop() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Drops the oldest states when full", func(t *testing.T) {
+		b := NewBuffer(64)
+		for i := 0; i < 100; i++ {
+			b.Push(makeState(512, byte(i)))
+		}
+		if b.Used() > 64 {
+			t.Errorf("Used() = %v, want <= %v", b.Used(), 64)
+		}
+		n := b.Len()
+		if n >= 100 || n < 2 {
+			t.Fatalf("Len() = %v, want between 2 and 99", n)
+		}
+		for i := 99; i > 99-n; i-- {
+			got, _ := b.Pop()
+			if want := makeState(512, byte(i)); !bytes.Equal(got, want) {
+				t.Errorf("Pop() = %v, want %v", got, want)
+			}
+		}
+	})
+
+	t.Run("Clears the history when the state size changes", func(t *testing.T) {
+		b := NewBuffer(1024 * 1024)
+		b.Push(makeState(512, 1))
+		b.Push(makeState(512, 2))
+		b.Push(makeState(256, 3))
+		if b.Len() != 1 {
+			t.Errorf("Len() = %v, want %v", b.Len(), 1)
+		}
+	})
+
+	t.Run("Does not keep a reference to the pushed state", func(t *testing.T) {
+		b := NewBuffer(1024 * 1024)
+		s := makeState(512, 1)
+		b.Push(s)
+		s[0] = 42
+		got, _ := b.Pop()
+		if got[0] == 42 {
+			t.Errorf("Pop()[0] = %v, want %v", got[0], 0)
+		}
+	})
+}
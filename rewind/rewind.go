@@ -0,0 +1,67 @@
+// Package rewind periodically captures the state of the running core in a
+// bounded memory buffer, and restores those states one by one while the rewind
+// hotkey is held, allowing the player to step back in time.
+package rewind
+
+import (
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+var (
+	buffer *Buffer
+	frame  int
+)
+
+// Init allocates the rewind buffer. It should be called each time a game is
+// loaded, as states of different games can't be mixed.
+func Init() {
+	buffer = NewBuffer(settings.Current.RewindBufferSize * 1024 * 1024)
+	frame = 0
+}
+
+// Capture serializes the state of the core every RewindGranularity frames and
+// pushes it to the rewind buffer. It is meant to be called once per frame.
+func Capture() error {
+	if !settings.Current.Rewind || buffer == nil {
+		return nil
+	}
+
+	frame++
+	if settings.Current.RewindGranularity > 1 && frame%settings.Current.RewindGranularity != 0 {
+		return nil
+	}
+
+	s := state.Core.SerializeSize()
+	if s == 0 {
+		return nil
+	}
+	bytes, err := state.Core.Serialize(s)
+	if err != nil {
+		return err
+	}
+	buffer.Push(bytes)
+	return nil
+}
+
+// Step restores the most recent captured state. It returns false when there
+// is no more state to rewind to.
+func Step() (bool, error) {
+	if !settings.Current.Rewind || buffer == nil {
+		return false, nil
+	}
+
+	bytes, ok := buffer.Pop()
+	if !ok {
+		return false, nil
+	}
+
+	// Keep the state we're rewinding to, so that holding the hotkey at the
+	// beginning of the history doesn't lose it
+	if buffer.Len() == 0 {
+		buffer.Push(bytes)
+	}
+
+	err := state.Core.Unserialize(bytes, state.Core.SerializeSize())
+	return err == nil, err
+}
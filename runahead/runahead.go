@@ -0,0 +1,236 @@
+// Package runahead hides the input latency of the emulated games. Every frame,
+// the state of the core is saved, a few frames are emulated ahead with the
+// current input and only the last one is presented, then the state is
+// restored. A game that takes a few frames to react to an input appears to
+// react on the next frame.
+//
+// Instead of restoring the state of the core, the frames ahead can be emulated
+// by a second instance of the core, which avoids audio glitches with cores
+// that don't restore their audio state.
+package runahead
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/libretro"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// MaxFrames is the maximum number of frames to run ahead
+const MaxFrames = 4
+
+var (
+	coreName      string            // settings key of the current core
+	gameInfo      libretro.GameInfo // used to load the game in the second instance
+	frame         int               // frames emulated since the game was loaded
+	failed        bool              // run-ahead failed and is disabled until the next game
+	secondary     *libretro.Core
+	secondaryPath string // copy of the core library loaded by the second instance
+)
+
+// Init prepares run-ahead for a game that was just loaded with gi
+func Init(gi libretro.GameInfo) {
+	Deinit()
+	coreName = utils.FileName(state.CorePath)
+	gameInfo = gi
+	frame = 0
+	failed = false
+	if Frames() > 0 && state.Core.SerializationQuirks&libretro.SerializationQuirkIncomplete != 0 {
+		log.Println("[Run-Ahead]: The savestates of the core are incomplete, run-ahead is disabled")
+	}
+}
+
+// Deinit unloads the second instance, if any
+func Deinit() {
+	if secondary == nil {
+		return
+	}
+	secondary.UnloadGame()
+	secondary.Close()
+	if err := os.Remove(secondaryPath); err != nil {
+		log.Println("[Run-Ahead]:", err)
+	}
+	secondary = nil
+	secondaryPath = ""
+}
+
+// Frames returns the number of frames to run ahead for the current core
+func Frames() int {
+	return settings.Current.RunAheadFrames[coreName]
+}
+
+// SetFrames sets the number of frames to run ahead for the current core
+func SetFrames(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if n > MaxFrames {
+		n = MaxFrames
+	}
+	if settings.Current.RunAheadFrames == nil {
+		settings.Current.RunAheadFrames = map[string]int{}
+	}
+	settings.Current.RunAheadFrames[coreName] = n
+	failed = false
+	settings.Save()
+}
+
+// SecondInstance returns true if the frames ahead are emulated by a second
+// instance of the current core
+func SecondInstance() bool {
+	return settings.Current.RunAheadSecondInstance[coreName]
+}
+
+// SetSecondInstance enables or disables the second instance for the current
+// core
+func SetSecondInstance(enable bool) {
+	if settings.Current.RunAheadSecondInstance == nil {
+		settings.Current.RunAheadSecondInstance = map[string]bool{}
+	}
+	settings.Current.RunAheadSecondInstance[coreName] = enable
+	if !enable {
+		Deinit()
+	}
+	failed = false
+	settings.Save()
+}
+
+// Run emulates a frame, running ahead if it is enabled for the current core.
+// It is meant to be called in place of running the core.
+func Run() {
+	quirks := state.Core.SerializationQuirks
+	n := Frames()
+	frame++
+	if n == 0 || failed ||
+		quirks&libretro.SerializationQuirkIncomplete != 0 ||
+		// States can only be saved after the first frame
+		(quirks&libretro.SerializationQuirkMustInitialize != 0 && frame == 1) {
+		state.Core.Run()
+		return
+	}
+
+	// The second instance would need its own hardware context
+	var err error
+	if SecondInstance() && quirks&libretro.SerializationQuirkSingleSession == 0 && state.Core.HWRenderCallback == nil {
+		err = runSecondary(n)
+	} else {
+		err = runSingle(n)
+	}
+	if err != nil {
+		failed = true
+		Deinit()
+		ntf.DisplayAndLog(ntf.Error, "Run-Ahead", "Disabled: "+err.Error())
+	}
+}
+
+// runFrame runs a frame of a core, dropping its audio or video
+func runFrame(c *libretro.Core, audio, video bool) {
+	state.Replaying = !audio
+	state.SkipVideo = !video
+	c.Run()
+	state.Replaying = false
+	state.SkipVideo = false
+}
+
+// runSingle runs the frames ahead with the core itself, and restores its state
+func runSingle(n int) error {
+	runFrame(state.Core, true, false)
+	s, err := state.Core.Serialize(state.Core.SerializeSize())
+	if err != nil {
+		return err
+	}
+	for i := 1; i < n; i++ {
+		runFrame(state.Core, false, false)
+	}
+	runFrame(state.Core, false, true)
+	return state.Core.Unserialize(s, state.Core.SerializeSize())
+}
+
+// runSecondary runs the frames ahead with the second instance, starting from
+// the state of the core
+func runSecondary(n int) error {
+	if secondary == nil {
+		if err := loadSecondary(); err != nil {
+			return err
+		}
+	}
+	runFrame(state.Core, true, false)
+	s, err := state.Core.Serialize(state.Core.SerializeSize())
+	if err != nil {
+		return err
+	}
+	if err := secondary.Unserialize(s, secondary.SerializeSize()); err != nil {
+		return err
+	}
+	for i := 1; i < n; i++ {
+		runFrame(secondary, false, false)
+	}
+	runFrame(secondary, false, true)
+	return nil
+}
+
+// copyLibrary copies the core library to a temporary file. Opening the same
+// library twice would return the already loaded one, sharing its globals.
+func copyLibrary(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "ludo-runahead-*"+filepath.Ext(path))
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// loadSecondary loads the second instance of the core and the current game
+func loadSecondary() error {
+	path, err := copyLibrary(state.CorePath)
+	if err != nil {
+		return err
+	}
+	c, err := libretro.Load(path)
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	// The environment callback configures state.Core, point it to the second
+	// instance while it initializes
+	primary := state.Core
+	state.Core = c
+	defer func() { state.Core = primary }()
+
+	c.ShareCallbacks()
+	c.Init()
+	if !c.LoadGame(gameInfo) {
+		c.Close()
+		os.Remove(path)
+		return errors.New("the second instance failed to load the game")
+	}
+	for port := uint(0); port < 5; port++ {
+		c.SetControllerPortDevice(port, libretro.DeviceJoypad)
+	}
+	if c.SerializationQuirks&libretro.SerializationQuirkMustInitialize != 0 {
+		runFrame(c, false, false)
+	}
+
+	secondary = c
+	secondaryPath = path
+	return nil
+}
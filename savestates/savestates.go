@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 )
@@ -34,5 +35,8 @@ func Load(path string) error {
 		return err
 	}
 	err = state.Core.Unserialize(bytes, s)
+	if err == nil {
+		achievements.Reset()
+	}
 	return err
 }
@@ -0,0 +1,208 @@
+package script
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	lr "github.com/libretro/ludo/libretro"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// buttons maps the button names of scripts to joypad IDs
+var buttons = map[string]uint32{
+	"b":      lr.DeviceIDJoypadB,
+	"y":      lr.DeviceIDJoypadY,
+	"select": lr.DeviceIDJoypadSelect,
+	"start":  lr.DeviceIDJoypadStart,
+	"up":     lr.DeviceIDJoypadUp,
+	"down":   lr.DeviceIDJoypadDown,
+	"left":   lr.DeviceIDJoypadLeft,
+	"right":  lr.DeviceIDJoypadRight,
+	"a":      lr.DeviceIDJoypadA,
+	"x":      lr.DeviceIDJoypadX,
+	"l":      lr.DeviceIDJoypadL,
+	"r":      lr.DeviceIDJoypadR,
+	"l2":     lr.DeviceIDJoypadL2,
+	"r2":     lr.DeviceIDJoypadR2,
+	"l3":     lr.DeviceIDJoypadL3,
+	"r3":     lr.DeviceIDJoypadR3,
+}
+
+// Lua is a script written in Lua. The script runs as a coroutine, from its
+// first line until it ends, and acts on the game through the functions of the
+// ludo table:
+//
+//	ludo.frame([n])                   let n frames run, 1 by default
+//	ludo.framecount()                 number of frames since the script started
+//	ludo.peek(address)                read a byte of the memory, nil if unmapped
+//	ludo.poke(address, value)         write a byte of the memory
+//	ludo.press(port, button, ...)     hold buttons until released
+//	ludo.release(port, [button, ...]) release buttons, or all of them
+//	ludo.savestate(slot)              save the state to a memory slot
+//	ludo.loadstate(slot)              load the state from a memory slot
+//	ludo.screenshot(path)             write the last frame to a PNG file
+//	ludo.log(...)                     print a message
+//
+// Ports start at 0, buttons are named a, b, x, y, l, r, l2, r2, l3, r3,
+// start, select, up, down, left and right. The script fails when it raises an
+// error, for example with assert.
+type Lua struct {
+	state  *lua.LState
+	thread *lua.LState
+	main   *lua.LFunction
+	ctx    Context
+	pause  int // frames left to run before resuming the script
+}
+
+// NewLua compiles a Lua script. name is used in error messages.
+func NewLua(r io.Reader, name string) (*Lua, error) {
+	s := &Lua{state: lua.NewState()}
+	main, err := s.state.Load(r, name)
+	if err != nil {
+		s.state.Close()
+		return nil, err
+	}
+	s.main = main
+	s.thread, _ = s.state.NewThread()
+	s.state.SetGlobal("ludo", s.state.SetFuncs(s.state.NewTable(), map[string]lua.LGFunction{
+		"frame":      s.frame,
+		"framecount": s.framecount,
+		"peek":       s.peek,
+		"poke":       s.poke,
+		"press":      s.press,
+		"release":    s.release,
+		"savestate":  s.savestate,
+		"loadstate":  s.loadstate,
+		"screenshot": s.screenshot,
+		"log":        s.log,
+	}))
+	return s, nil
+}
+
+// Update resumes the script until it lets a frame run
+func (s *Lua) Update(ctx Context) (bool, error) {
+	if s.pause > 0 {
+		s.pause--
+		if s.pause > 0 {
+			return true, nil
+		}
+	}
+
+	s.ctx = ctx
+	st, err, _ := s.state.Resume(s.thread, s.main)
+	switch st {
+	case lua.ResumeYield:
+		return true, nil
+	case lua.ResumeError:
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			return false, errors.New(apiErr.Object.String())
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// Close frees the interpreter
+func (s *Lua) Close() error {
+	s.state.Close()
+	return nil
+}
+
+func (s *Lua) frame(L *lua.LState) int {
+	n := L.OptInt(1, 1)
+	if n < 1 {
+		L.ArgError(1, "at least one frame expected")
+	}
+	s.pause = n
+	return L.Yield()
+}
+
+func (s *Lua) framecount(L *lua.LState) int {
+	L.Push(lua.LNumber(s.ctx.Frame()))
+	return 1
+}
+
+func (s *Lua) peek(L *lua.LState) int {
+	v, ok := s.ctx.Peek(uint32(L.CheckInt64(1)))
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	L.Push(lua.LNumber(v))
+	return 1
+}
+
+func (s *Lua) poke(L *lua.LState) int {
+	address := uint32(L.CheckInt64(1))
+	if !s.ctx.Poke(address, byte(L.CheckInt(2))) {
+		L.RaiseError("address 0x%X is not writable", address)
+	}
+	return 0
+}
+
+// setButtons holds or releases the buttons named from the second argument.
+// Releasing without names releases all the buttons.
+func (s *Lua) setButtons(L *lua.LState, pressed bool) {
+	port := L.CheckInt(1)
+	names := []string{}
+	for i := 2; i <= L.GetTop(); i++ {
+		names = append(names, L.CheckString(i))
+	}
+	if len(names) == 0 {
+		if pressed {
+			L.ArgError(2, "button expected")
+		}
+		for name := range buttons {
+			names = append(names, name)
+		}
+	}
+	for i, name := range names {
+		id, ok := buttons[strings.ToLower(name)]
+		if !ok {
+			L.ArgError(i+2, "unknown button "+name)
+		}
+		s.ctx.SetButton(port, id, pressed)
+	}
+}
+
+func (s *Lua) press(L *lua.LState) int {
+	s.setButtons(L, true)
+	return 0
+}
+
+func (s *Lua) release(L *lua.LState) int {
+	s.setButtons(L, false)
+	return 0
+}
+
+func (s *Lua) savestate(L *lua.LState) int {
+	if err := s.ctx.SaveState(L.CheckString(1)); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (s *Lua) loadstate(L *lua.LState) int {
+	if err := s.ctx.LoadState(L.CheckString(1)); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (s *Lua) screenshot(L *lua.LState) int {
+	if err := s.ctx.Screenshot(L.CheckString(1)); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (s *Lua) log(L *lua.LState) int {
+	parts := []string{}
+	for i := 1; i <= L.GetTop(); i++ {
+		parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+	}
+	s.ctx.Log(strings.Join(parts, " "))
+	return 0
+}
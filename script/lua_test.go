@@ -0,0 +1,190 @@
+package script
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	lr "github.com/libretro/ludo/libretro"
+)
+
+// fakeContext records what a script does
+type fakeContext struct {
+	frame   int
+	memory  map[uint32]byte
+	pressed map[uint32]bool
+	logs    []string
+	saved   []string
+	loaded  []string
+}
+
+func newFakeContext() *fakeContext {
+	return &fakeContext{memory: map[uint32]byte{}, pressed: map[uint32]bool{}}
+}
+
+func (c *fakeContext) Frame() int { return c.frame }
+
+func (c *fakeContext) Peek(address uint32) (byte, bool) {
+	v, ok := c.memory[address]
+	return v, ok
+}
+
+func (c *fakeContext) Poke(address uint32, value byte) bool {
+	c.memory[address] = value
+	return true
+}
+
+func (c *fakeContext) SetButton(port int, button uint32, pressed bool) {
+	c.pressed[button] = pressed
+}
+
+func (c *fakeContext) SaveState(slot string) error {
+	c.saved = append(c.saved, slot)
+	return nil
+}
+
+func (c *fakeContext) LoadState(slot string) error {
+	for _, s := range c.saved {
+		if s == slot {
+			c.loaded = append(c.loaded, slot)
+			return nil
+		}
+	}
+	return errors.New("empty slot " + slot)
+}
+
+func (c *fakeContext) Screenshot(path string) error { return nil }
+
+func (c *fakeContext) Log(msg string) { c.logs = append(c.logs, msg) }
+
+// run compiles a script and updates it until it ends, simulating frames. It
+// returns the number of frames that ran.
+func run(t *testing.T, source string, ctx *fakeContext, step func(*fakeContext)) (int, error) {
+	s, err := NewLua(strings.NewReader(source), "test.lua")
+	if err != nil {
+		t.Fatalf("NewLua() error = %v", err)
+	}
+	defer s.Close()
+	for ctx.frame < 1000 {
+		running, err := s.Update(ctx)
+		if err != nil || !running {
+			return ctx.frame, err
+		}
+		if step != nil {
+			step(ctx)
+		}
+		ctx.frame++
+	}
+	t.Fatalf("the script never ended")
+	return 0, nil
+}
+
+func Test_NewLua(t *testing.T) {
+	t.Run("Reports syntax errors", func(t *testing.T) {
+		if _, err := NewLua(strings.NewReader("ludo.frame("), "test.lua"); err == nil {
+			t.Errorf("NewLua() error = nil, want an error")
+		}
+	})
+}
+
+func Test_Lua_Update(t *testing.T) {
+	t.Run("Lets frames run", func(t *testing.T) {
+		ctx := newFakeContext()
+		frames, err := run(t, "ludo.frame()\nludo.frame(3)\nludo.log('done', ludo.framecount())", ctx, nil)
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if frames != 4 {
+			t.Errorf("frames = %d, want %d", frames, 4)
+		}
+		if !reflect.DeepEqual(ctx.logs, []string{"done 4"}) {
+			t.Errorf("logs = %v, want %v", ctx.logs, []string{"done 4"})
+		}
+	})
+
+	t.Run("Holds and releases buttons", func(t *testing.T) {
+		ctx := newFakeContext()
+		var history []map[uint32]bool
+		_, err := run(t, "ludo.press(0, 'a', 'B')\nludo.frame()\nludo.release(0, 'a')\nludo.frame()\nludo.release(0)", ctx, func(c *fakeContext) {
+			history = append(history, map[uint32]bool{
+				lr.DeviceIDJoypadA: c.pressed[lr.DeviceIDJoypadA],
+				lr.DeviceIDJoypadB: c.pressed[lr.DeviceIDJoypadB],
+			})
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		want := []map[uint32]bool{
+			{lr.DeviceIDJoypadA: true, lr.DeviceIDJoypadB: true},
+			{lr.DeviceIDJoypadA: false, lr.DeviceIDJoypadB: true},
+		}
+		if !reflect.DeepEqual(history, want) {
+			t.Errorf("history = %v, want %v", history, want)
+		}
+		if ctx.pressed[lr.DeviceIDJoypadB] {
+			t.Errorf("B is still pressed after releasing all the buttons")
+		}
+	})
+
+	t.Run("Waits for a memory value", func(t *testing.T) {
+		ctx := newFakeContext()
+		ctx.memory[0x10] = 0
+		frames, err := run(t, "while ludo.peek(0x10) < 5 do ludo.frame() end\nassert(ludo.peek(0x10) == 5)", ctx, func(c *fakeContext) { c.memory[0x10]++ })
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if frames != 5 {
+			t.Errorf("frames = %d, want %d", frames, 5)
+		}
+	})
+
+	t.Run("Writes the memory", func(t *testing.T) {
+		ctx := newFakeContext()
+		if _, err := run(t, "ludo.poke(0x7E0019, 2)", ctx, nil); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if ctx.memory[0x7E0019] != 2 {
+			t.Errorf("memory[0x7E0019] = %d, want %d", ctx.memory[0x7E0019], 2)
+		}
+	})
+
+	t.Run("Fails an assertion", func(t *testing.T) {
+		ctx := newFakeContext()
+		_, err := run(t, "ludo.poke(0x10, 3)\n\nassert(ludo.peek(0x10) ~= 3, 'still 3')\nludo.log('unreachable')", ctx, nil)
+		if err == nil || err.Error() != "test.lua:3: still 3" {
+			t.Errorf("Update() error = %v, want a failed assertion", err)
+		}
+		if len(ctx.logs) != 0 {
+			t.Errorf("logs = %v, want none", ctx.logs)
+		}
+	})
+
+	t.Run("Rejects unknown buttons", func(t *testing.T) {
+		ctx := newFakeContext()
+		if _, err := run(t, "ludo.press(0, 'z')", ctx, nil); err == nil || !strings.Contains(err.Error(), "unknown button z") {
+			t.Errorf("Update() error = %v, want an unknown button", err)
+		}
+	})
+
+	t.Run("Saves and loads states", func(t *testing.T) {
+		ctx := newFakeContext()
+		_, err := run(t, "ludo.savestate('start')\nludo.loadstate('start')\nludo.loadstate('other')", ctx, nil)
+		if err == nil || !strings.HasSuffix(err.Error(), "empty slot other") {
+			t.Errorf("Update() error = %v, want an empty slot", err)
+		}
+		if !reflect.DeepEqual(ctx.loaded, []string{"start"}) {
+			t.Errorf("loaded = %v, want %v", ctx.loaded, []string{"start"})
+		}
+	})
+
+	t.Run("Ends when returning", func(t *testing.T) {
+		ctx := newFakeContext()
+		if _, err := run(t, "do return end\nludo.log('unreachable')", ctx, nil); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if len(ctx.logs) != 0 {
+			t.Errorf("logs = %v, want none", ctx.logs)
+		}
+	})
+}
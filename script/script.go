@@ -0,0 +1,197 @@
+// Package script automates games for bots and regression checks. Scripts run
+// inside the main loop: they are updated once per frame before the core runs,
+// and can inject input, read and write the emulated memory, save and load
+// states and take screenshots through a Context.
+//
+// Scripts are either written in Lua and loaded from files, see Lua, or Go
+// values implementing Script.
+package script
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/achievements"
+	"github.com/libretro/ludo/cheats"
+	"github.com/libretro/ludo/input"
+	lr "github.com/libretro/ludo/libretro"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/video"
+)
+
+// Context is what scripts act on
+type Context interface {
+	// Frame returns the number of frames since the script started
+	Frame() int
+	// Peek reads a byte of the emulated memory
+	Peek(address uint32) (byte, bool)
+	// Poke writes a byte of the emulated memory
+	Poke(address uint32, value byte) bool
+	// SetButton holds or releases a joypad button of a player
+	SetButton(port int, button uint32, pressed bool)
+	// SaveState saves the state of the core to a memory slot
+	SaveState(slot string) error
+	// LoadState loads the state of the core from a memory slot
+	LoadState(slot string) error
+	// Screenshot writes the last frame to a PNG file
+	Screenshot(path string) error
+	// Log prints a message
+	Log(msg string)
+}
+
+// Script is an automation running along the game
+type Script interface {
+	// Update is called once per frame, before the core runs. It returns false
+	// once the script is done.
+	Update(ctx Context) (bool, error)
+}
+
+// Func adapts a function to the Script interface
+type Func func(ctx Context) (bool, error)
+
+// Update calls f
+func (f Func) Update(ctx Context) (bool, error) {
+	return f(ctx)
+}
+
+// coreContext is the Context of the running core
+type coreContext struct {
+	frame   int
+	buttons [input.MaxPlayers][lr.DeviceIDJoypadR3 + 1]bool
+	slots   map[string][]byte
+}
+
+func (c *coreContext) Frame() int {
+	return c.frame
+}
+
+func (c *coreContext) Peek(address uint32) (byte, bool) {
+	return cheats.CoreMemory().Peek(address)
+}
+
+func (c *coreContext) Poke(address uint32, value byte) bool {
+	return cheats.CoreMemory().Poke(address, value)
+}
+
+func (c *coreContext) SetButton(port int, button uint32, pressed bool) {
+	if port < 0 || port >= input.MaxPlayers || button > lr.DeviceIDJoypadR3 {
+		return
+	}
+	c.buttons[port][button] = pressed
+}
+
+func (c *coreContext) SaveState(slot string) error {
+	s, err := state.Core.Serialize(state.Core.SerializeSize())
+	if err != nil {
+		return err
+	}
+	c.slots[slot] = s
+	return nil
+}
+
+func (c *coreContext) LoadState(slot string) error {
+	if c.slots[slot] == nil {
+		return errors.New("empty slot " + slot)
+	}
+	if err := state.Core.Unserialize(c.slots[slot], state.Core.SerializeSize()); err != nil {
+		return err
+	}
+	achievements.Reset()
+	return nil
+}
+
+func (c *coreContext) Screenshot(path string) error {
+	return vid.SaveFrame(path)
+}
+
+func (c *coreContext) Log(msg string) {
+	log.Println("[Script]:", msg)
+}
+
+var (
+	vid     video.Renderer
+	current Script
+	ctx     *coreContext
+	lastErr error
+)
+
+// Init is there mainly for dependency injection.
+// Call Init before calling other functions of this package.
+func Init(v video.Renderer) {
+	vid = v
+}
+
+// Load compiles the Lua script at path and starts it
+func Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s, err := NewLua(f, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	Start(s)
+	return nil
+}
+
+// Start runs a script from the next frame, replacing the running one
+func Start(s Script) {
+	Stop()
+	current = s
+	ctx = &coreContext{slots: map[string][]byte{}}
+	lastErr = nil
+}
+
+// Stop ends the running script. Scripts implementing io.Closer are closed.
+func Stop() {
+	if c, ok := current.(io.Closer); ok {
+		c.Close()
+	}
+	current = nil
+	ctx = nil
+}
+
+// Running returns true while a script runs
+func Running() bool {
+	return current != nil
+}
+
+// Err returns the error that ended the last script, if any
+func Err() error {
+	return lastErr
+}
+
+// Process updates the running script and injects the buttons it holds into
+// the input state. It is meant to be called once per frame, after polling the
+// input and before running the core.
+func Process() {
+	if current == nil {
+		return
+	}
+	running, err := current.Update(ctx)
+	if err != nil {
+		lastErr = err
+		Stop()
+		ntf.DisplayAndLog(ntf.Error, "Script", err.Error())
+		return
+	}
+	if !running {
+		Stop()
+		ntf.DisplayAndLog(ntf.Info, "Script", "Done.")
+		return
+	}
+	for p := range ctx.buttons {
+		for id, pressed := range ctx.buttons[p] {
+			if pressed {
+				input.NewState[p][id] = 1
+			}
+		}
+	}
+	ctx.frame++
+}
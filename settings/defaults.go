@@ -12,12 +12,33 @@ func defaultSettings() Settings {
 		VideoFullscreen:   false,
 		VideoMonitorIndex: 0,
 		VideoFilter:       "Pixel Perfect",
+		VideoShader:       "None",
+		VideoOverlay:      true,
 		VideoDarkMode:     false,
 		VideoTheme:        "Default",
 		MapAxisToDPad:     false,
+		Rewind:            false,
+		RewindGranularity: 1,
+		RewindBufferSize:  20,
+		Achievements:      false,
+		AchievementsHost:  "https://retroachievements.org",
+		NetplayPort:       55435,
+		NetplayDelay:      0,
 		AudioVolume:       0.5,
+		FastForwardRatio:  0,
+		SlowMotionRatio:   0.5,
 		MenuAudioVolume:   0.25,
 		ShowHiddenFiles:   false,
+
+		AudioDriver:          "OpenAL",
+		AudioEQFrequency:     1000,
+		AudioRecordingFormat: "WAV",
+
+		RunAheadFrames:         map[string]int{},
+		RunAheadSecondInstance: map[string]bool{},
+		ShaderParameters:       map[string]map[string]float32{},
+		Viewport:               Viewport{AspectRatio: "Core"},
+		CoreViewports:          map[string]Viewport{},
 		CoreForPlaylist: map[string]string{
 			"Atari - 2600":                                   "stella2014_libretro",
 			"Atari - 5200":                                   "atari800_libretro",
@@ -68,8 +89,14 @@ func defaultSettings() Settings {
 		SavestatesDirectory:  filepath.Join(xdg.DataHome, "ludo", "savestates"),
 		SavefilesDirectory:   filepath.Join(xdg.DataHome, "ludo", "savefiles"),
 		ScreenshotsDirectory: filepath.Join(xdg.DataHome, "ludo", "screenshots"),
+		CheatsDirectory:      filepath.Join(xdg.DataHome, "ludo", "cheats"),
+		MoviesDirectory:      filepath.Join(xdg.DataHome, "ludo", "movies"),
+		ScriptsDirectory:     filepath.Join(xdg.DataHome, "ludo", "scripts"),
+		RecordingsDirectory:  filepath.Join(xdg.DataHome, "ludo", "recordings"),
 		SystemDirectory:      filepath.Join(xdg.DataHome, "ludo", "system"),
 		PlaylistsDirectory:   filepath.Join(xdg.DataHome, "ludo", "playlists"),
 		ThumbnailsDirectory:  filepath.Join(xdg.DataHome, "ludo", "thumbnails"),
+		ShadersDirectory:     filepath.Join(xdg.DataHome, "ludo", "shaders"),
+		OverlaysDirectory:    filepath.Join(xdg.DataHome, "ludo", "overlays"),
 	}
 }
@@ -24,18 +24,51 @@ type Settings struct {
 	VideoFullscreen   bool   `hide:"ludos" toml:"video_fullscreen" label:"Video Fullscreen" fmt:"%t" widget:"switch"`
 	VideoMonitorIndex int    `toml:"video_monitor_index" label:"Video Monitor Index" fmt:"%d"`
 	VideoFilter       string `toml:"video_filter" label:"Video Filter" fmt:"<%s>"`
+	VideoShader       string `toml:"video_shader" label:"Video Shader" fmt:"<%s>"`
+	VideoOverlay      bool   `toml:"video_overlay" label:"Video Overlay" fmt:"%t" widget:"switch"`
 	VideoDarkMode     bool   `toml:"video_dark_mode" label:"Video Dark Mode" fmt:"%t" widget:"switch"`
 	VideoTheme 		   string `toml:"video_theme" label:"Video Theme" fmt:"<%s>"`
 
-	AudioVolume float32 `toml:"audio_volume" label:"Audio Volume" fmt:"%.1f" widget:"range"`
+	AudioVolume          float32 `toml:"audio_volume" label:"Audio Volume" fmt:"%.1f" widget:"range"`
+	AudioDriver          string  `toml:"audio_driver" label:"Audio Driver" fmt:"<%s>"`
+	AudioEQFrequency     int     `toml:"audio_eq_frequency" label:"Audio EQ Frequency" fmt:"%d Hz"`
+	AudioEQGain          float32 `toml:"audio_eq_gain" label:"Audio EQ Gain" fmt:"%+g dB"`
+	AudioBassBoost       float32 `toml:"audio_bass_boost" label:"Audio Bass Boost" fmt:"%+g dB"`
+	AudioLowPass         int     `toml:"audio_low_pass" label:"Audio Low-Pass (0 = off)" fmt:"%d Hz"`
+	AudioReverb          float32 `toml:"audio_reverb" label:"Audio Reverb" fmt:"%.1f" widget:"range"`
+	AudioRecordingFormat string  `toml:"audio_recording_format" label:"Audio Recording Format" fmt:"<%s>"`
+
+	FastForwardRatio float32 `toml:"fast_forward_ratio" label:"Fast-Forward Ratio (0 = max)" fmt:"%gx"`
+	SlowMotionRatio  float32 `toml:"slow_motion_ratio" label:"Slow Motion Ratio" fmt:"%gx"`
 
 	MenuAudioVolume float32 `toml:"menu_audio_volume" label:"Menu Audio Volume" fmt:"%.1f" widget:"range"`
 	ShowHiddenFiles bool    `toml:"menu_showhiddenfiles" label:"Show Hidden Files" fmt:"%t" widget:"switch"`
 
 	MapAxisToDPad bool `toml:"input_map_axis_to_dpad" label:"Map Sticks To DPad" fmt:"%t" widget:"switch"`
 
+	Rewind            bool `toml:"rewind" label:"Rewind" fmt:"%t" widget:"switch"`
+	RewindGranularity int  `toml:"rewind_granularity" label:"Rewind Granularity" fmt:"%d"`
+	RewindBufferSize  int  `toml:"rewind_buffer_size" label:"Rewind Buffer Size (MB)" fmt:"%d"`
+
+	Achievements      bool   `toml:"achievements" label:"Achievements" fmt:"%t" widget:"switch"`
+	AchievementsHost  string `hide:"always" toml:"achievements_host"`
+	AchievementsUser  string `hide:"always" toml:"achievements_user"`
+	AchievementsToken string `hide:"always" toml:"achievements_token"`
+
+	NetplayPort  int    `toml:"netplay_port" label:"Netplay Port" fmt:"%d"`
+	NetplayDelay int    `toml:"netplay_delay" label:"Netplay Input Delay (frames)" fmt:"%d"`
+	NetplayHost  string `hide:"always" toml:"netplay_host"`
+
+	RunAheadFrames         map[string]int  `hide:"always" toml:"run_ahead_frames"`
+	RunAheadSecondInstance map[string]bool `hide:"always" toml:"run_ahead_second_instance"`
+
 	CoreForPlaylist map[string]string `hide:"always" toml:"core_for_playlist"`
 
+	ShaderParameters map[string]map[string]float32 `hide:"always" toml:"shader_parameters"`
+
+	Viewport      Viewport            `hide:"always" toml:"viewport"`
+	CoreViewports map[string]Viewport `hide:"always" toml:"core_viewports"`
+
 	FileDirectory        string `hide:"ludos" toml:"files_dir" label:"Files Directory" fmt:"%s" widget:"dir"`
 	CoresDirectory       string `hide:"ludos" toml:"cores_dir" label:"Cores Directory" fmt:"%s" widget:"dir"`
 	AssetsDirectory      string `hide:"ludos" toml:"assets_dir" label:"Assets Directory" fmt:"%s" widget:"dir"`
@@ -43,15 +76,39 @@ type Settings struct {
 	SavestatesDirectory  string `hide:"ludos" toml:"savestates_dir" label:"Savestates Directory" fmt:"%s" widget:"dir"`
 	SavefilesDirectory   string `hide:"ludos" toml:"savefiles_dir" label:"Savefiles Directory" fmt:"%s" widget:"dir"`
 	ScreenshotsDirectory string `hide:"ludos" toml:"screenshots_dir" label:"Screenshots Directory" fmt:"%s" widget:"dir"`
+	CheatsDirectory      string `hide:"ludos" toml:"cheats_dir" label:"Cheats Directory" fmt:"%s" widget:"dir"`
+	MoviesDirectory      string `hide:"ludos" toml:"movies_dir" label:"Movies Directory" fmt:"%s" widget:"dir"`
+	ScriptsDirectory     string `hide:"ludos" toml:"scripts_dir" label:"Scripts Directory" fmt:"%s" widget:"dir"`
+	RecordingsDirectory  string `hide:"ludos" toml:"recordings_dir" label:"Recordings Directory" fmt:"%s" widget:"dir"`
 	SystemDirectory      string `hide:"ludos" toml:"system_dir" label:"System Directory" fmt:"%s" widget:"dir"`
 	PlaylistsDirectory   string `hide:"ludos" toml:"playlists_dir" label:"Playlists Directory" fmt:"%s" widget:"dir"`
 	ThumbnailsDirectory  string `hide:"ludos" toml:"thumbnail_dir" label:"Thumbnails Directory" fmt:"%s" widget:"dir"`
+	ShadersDirectory     string `hide:"ludos" toml:"shaders_dir" label:"Shaders Directory" fmt:"%s" widget:"dir"`
+	OverlaysDirectory    string `hide:"ludos" toml:"overlays_dir" label:"Overlays Directory" fmt:"%s" widget:"dir"`
 
 	SSHService       bool `hide:"app" toml:"ssh_service" label:"SSH" widget:"switch" service:"sshd.service" path:"/storage/.cache/services/sshd.conf"`
 	SambaService     bool `hide:"app" toml:"samba_service" label:"Samba" widget:"switch" service:"smbd.service" path:"/storage/.cache/services/samba.conf"`
 	BluetoothService bool `hide:"app" toml:"bluetooth_service" label:"Bluetooth" widget:"switch" service:"bluetooth.service" path:"/storage/.cache/services/bluez.conf"`
 }
 
+// Viewport sets how the game is placed in the window
+type Viewport struct {
+	AspectRatio  string `toml:"aspect_ratio"` // Core, 4:3, 16:9, 1:1 PAR or Custom
+	IntegerScale bool   `toml:"integer_scale"`
+
+	// Rectangle of the Custom aspect ratio, in pixels of the window
+	CustomX      int `toml:"custom_x"`
+	CustomY      int `toml:"custom_y"`
+	CustomWidth  int `toml:"custom_width"`
+	CustomHeight int `toml:"custom_height"`
+
+	// Overscan to hide, in pixels of the game
+	CropTop    int `toml:"crop_top"`
+	CropBottom int `toml:"crop_bottom"`
+	CropLeft   int `toml:"crop_left"`
+	CropRight  int `toml:"crop_right"`
+}
+
 // Current stores the current settings at runtime
 var Current Settings
 
@@ -31,5 +31,32 @@ var DB dat.DB
 // LudOS is whether run Ludo as a unix desktop environment
 var LudOS bool
 
-// FastForward will run the core as fast as possible
+// FastForward will run the core faster, at the fast-forward ratio set in the
+// settings
 var FastForward bool
+
+// SlowMotion will run the core slower, at the slow motion ratio set in the
+// settings. It takes precedence over FastForward.
+var SlowMotion bool
+
+// Paused stops running the core while keeping the last frame on screen
+var Paused bool
+
+// FrameAdvance runs a single frame while paused. It is reset once the frame
+// ran.
+var FrameAdvance bool
+
+// Rewinding is true while the core is stepping back in time
+var Rewinding bool
+
+// Replaying is true while the core emulates frames again, like after a netplay
+// rollback. The audio of those frames is dropped.
+var Replaying bool
+
+// SkipVideo is true while the core emulates frames that must not be presented,
+// like the hidden frames of run-ahead. Their video is dropped.
+var SkipVideo bool
+
+// Headless is true when running without window, audio or input, for automated
+// testing of cores
+var Headless bool
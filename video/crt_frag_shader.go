@@ -20,12 +20,18 @@ uniform vec2 InputSize;
 uniform sampler2D Texture;
 COMPAT_VARYING vec2 fragTexCoord;
 
-#define BLURSCALEX 0.45
-#define LOWLUMSCAN 5.0
-#define HILUMSCAN 10.0
-#define BRIGHTBOOST 1.25
-#define MASK_DARK 0.25
-#define MASK_FADE 0.8
+#pragma parameter BLURSCALEX "Blur Amount X-Axis" 0.45 0.0 1.0 0.05
+#pragma parameter LOWLUMSCAN "Scanline Darkness - Low" 5.0 0.0 10.0 0.5
+#pragma parameter HILUMSCAN "Scanline Darkness - High" 10.0 0.0 50.0 1.0
+#pragma parameter BRIGHTBOOST "Dark Pixel Brightness Boost" 1.25 0.5 1.5 0.05
+#pragma parameter MASK_DARK "Mask Effect Amount" 0.25 0.0 1.0 0.05
+#pragma parameter MASK_FADE "Mask/Scanline Fade" 0.8 0.0 1.0 0.05
+uniform float BLURSCALEX;
+uniform float LOWLUMSCAN;
+uniform float HILUMSCAN;
+uniform float BRIGHTBOOST;
+uniform float MASK_DARK;
+uniform float MASK_FADE;
 
 #define Source Texture
 #define vTexCoord fragTexCoord.xy
@@ -43,6 +43,8 @@ type Font struct {
 	color       Color
 	atlasWidth  float32
 	atlasHeight float32
+	atlas       *image.RGBA // glyphs in white on black, kept for software rendering
+	sw          *Software   // set when the font draws in a software canvas
 }
 
 type point [4]float32
@@ -56,6 +58,47 @@ func max(a, b float32) float32 {
 
 // LoadTrueTypeFont builds a set of textures based on a ttf files gylphs
 func LoadTrueTypeFont(program uint32, r io.Reader, scale int32, low, high rune, dir Direction) (*Font, error) {
+	f, err := newAtlas(r, scale, low, high)
+	if err != nil {
+		return nil, err
+	}
+	f.program = program // Set shader program
+
+	// Generate texture
+	gl.GenTextures(1, &f.textureID)
+	gl.BindTexture(gl.TEXTURE_2D, f.textureID)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(f.atlas.Rect.Dx()), int32(f.atlas.Rect.Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(f.atlas.Pix))
+
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	// Configure VAO/VBO for texture quads
+	genVertexArrays(1, &f.vao)
+	gl.GenBuffers(1, &f.vbo)
+	bindVertexArray(f.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, f.vbo)
+
+	vertAttrib := uint32(gl.GetAttribLocation(f.program, gl.Str("vert\x00")))
+	gl.EnableVertexAttribArray(vertAttrib)
+	gl.VertexAttribPointerWithOffset(vertAttrib, 2, gl.FLOAT, false, 4*4, 0)
+
+	texCoordAttrib := uint32(gl.GetAttribLocation(f.program, gl.Str("vertTexCoord\x00")))
+	gl.EnableVertexAttribArray(texCoordAttrib)
+	gl.VertexAttribPointerWithOffset(texCoordAttrib, 2, gl.FLOAT, false, 4*4, 2*4)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	bindVertexArray(0)
+
+	return f, nil
+}
+
+// newAtlas rasterizes the glyphs of a ttf file in an image, without uploading
+// it to the GPU
+func newAtlas(r io.Reader, scale int32, low, high rune) (*Font, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
@@ -70,7 +113,6 @@ func LoadTrueTypeFont(program uint32, r io.Reader, scale int32, low, high rune,
 	// Make Font stuct type
 	f := new(Font)
 	f.fontChar = make([]*character, 0, high-low+1)
-	f.program = program                       // Set shader program
 	f.SetColor(Color{R: 1, G: 1, B: 1, A: 1}) // Set default white
 
 	// Create new face
@@ -173,34 +215,7 @@ func LoadTrueTypeFont(program uint32, r io.Reader, scale int32, low, high rune,
 		f.fontChar = append(f.fontChar, char)
 	}
 
-	// Generate texture
-	gl.GenTextures(1, &f.textureID)
-	gl.BindTexture(gl.TEXTURE_2D, f.textureID)
-	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Dx()), int32(rgba.Rect.Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-
-	// Configure VAO/VBO for texture quads
-	genVertexArrays(1, &f.vao)
-	gl.GenBuffers(1, &f.vbo)
-	bindVertexArray(f.vao)
-	gl.BindBuffer(gl.ARRAY_BUFFER, f.vbo)
-
-	vertAttrib := uint32(gl.GetAttribLocation(f.program, gl.Str("vert\x00")))
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointerWithOffset(vertAttrib, 2, gl.FLOAT, false, 4*4, 0)
-
-	texCoordAttrib := uint32(gl.GetAttribLocation(f.program, gl.Str("vertTexCoord\x00")))
-	gl.EnableVertexAttribArray(texCoordAttrib)
-	gl.VertexAttribPointerWithOffset(texCoordAttrib, 2, gl.FLOAT, false, 4*4, 2*4)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	bindVertexArray(0)
+	f.atlas = rgba
 
 	return f, nil
 }
@@ -236,6 +251,9 @@ func (f *Font) SetColor(color Color) {
 
 // UpdateResolution passes the new framebuffer size to the font shader
 func (f *Font) UpdateResolution(windowWidth int, windowHeight int) {
+	if f.sw != nil {
+		return
+	}
 	gl.UseProgram(f.program)
 	resUniform := gl.GetUniformLocation(f.program, gl.Str("resolution\x00"))
 	gl.Uniform2f(resUniform, float32(windowWidth), float32(windowHeight))
@@ -252,15 +270,6 @@ func (f *Font) Print(x, y float32, scale float32, text string) error {
 
 	lowChar := rune(32)
 
-	// Setup blending mode
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-
-	// Activate corresponding render state
-	gl.UseProgram(f.program)
-	// Set text color
-	gl.Uniform4f(gl.GetUniformLocation(f.program, gl.Str("textColor\x00")), f.color.R, f.color.G, f.color.B, f.color.A)
-
 	var coords []point
 
 	// Iterate through all characters in string
@@ -301,6 +310,20 @@ func (f *Font) Print(x, y float32, scale float32, text string) error {
 		x += float32((ch.advance >> 6)) * scale // Bitshift by 6 to get value in pixels (2^6 = 64 (divide amount of 1/64th pixels by 64 to get amount of pixels))
 	}
 
+	if f.sw != nil {
+		f.sw.drawGlyphs(f, coords)
+		return nil
+	}
+
+	// Setup blending mode
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	// Activate corresponding render state
+	gl.UseProgram(f.program)
+	// Set text color
+	gl.Uniform4f(gl.GetUniformLocation(f.program, gl.Str("textColor\x00")), f.color.R, f.color.G, f.color.B, f.color.A)
+
 	bindVertexArray(f.vao)
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_2D, f.textureID)
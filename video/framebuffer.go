@@ -0,0 +1,71 @@
+package video
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/libretro/ludo/libretro"
+)
+
+// toRGBA converts a frame in one of the libretro pixel formats to an image
+func toRGBA(data []byte, width, height, pitch int32, format uint32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	for y := int32(0); y < height; y++ {
+		row := data[y*pitch:]
+		for x := int32(0); x < width; x++ {
+			var r, g, b byte
+			switch format {
+			case libretro.PixelFormatXRGB8888:
+				p := binary.LittleEndian.Uint32(row[x*4:])
+				r, g, b = byte(p>>16), byte(p>>8), byte(p)
+			case libretro.PixelFormatRGB565:
+				p := binary.LittleEndian.Uint16(row[x*2:])
+				r, g, b = byte(p>>11&0x1F), byte(p>>5&0x3F), byte(p&0x1F)
+				r, g, b = r<<3|r>>2, g<<2|g>>4, b<<3|b>>2
+			default: // 0RGB1555
+				p := binary.LittleEndian.Uint16(row[x*2:])
+				r, g, b = byte(p>>10&0x1F), byte(p>>5&0x1F), byte(p&0x1F)
+				r, g, b = r<<3|r>>2, g<<3|g>>2, b<<3|b>>2
+			}
+			i := img.PixOffset(int(x), int(y))
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = r, g, b, 0xFF
+		}
+	}
+	return img
+}
+
+// Frame returns the last frame of the core, or nil if there is none
+func (video *Video) Frame() *image.RGBA {
+	if video.hw != nil {
+		return video.hwFrame()
+	}
+	if video.data == nil || video.pitch == 0 {
+		return nil
+	}
+	return toRGBA(video.frame, video.width, video.height, video.pitch, video.format)
+}
+
+// SaveFrame writes the last frame of the core to a PNG file
+func (video *Video) SaveFrame(path string) error {
+	return saveFrame(video.Frame(), path)
+}
+
+func saveFrame(img *image.RGBA, path string) error {
+	if img == nil {
+		return errors.New("no frame to save")
+	}
+	return writePNG(img, path)
+}
+
+// writePNG encodes an image to a PNG file
+func writePNG(img image.Image, path string) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	return png.Encode(fd, img)
+}
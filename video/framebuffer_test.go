@@ -0,0 +1,55 @@
+package video
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/libretro/ludo/libretro"
+)
+
+func Test_toRGBA(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		pitch  int32
+		format uint32
+		want   color.RGBA
+	}{
+		{
+			name:   "XRGB8888",
+			data:   []byte{0x30, 0x20, 0x10, 0x00},
+			pitch:  4,
+			format: libretro.PixelFormatXRGB8888,
+			want:   color.RGBA{0x10, 0x20, 0x30, 0xFF},
+		},
+		{
+			name:   "RGB565 white",
+			data:   []byte{0xFF, 0xFF},
+			pitch:  2,
+			format: libretro.PixelFormatRGB565,
+			want:   color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+		},
+		{
+			name:   "0RGB1555 red",
+			data:   []byte{0x00, 0x7C},
+			pitch:  2,
+			format: libretro.PixelFormat0RGB1555,
+			want:   color.RGBA{0xFF, 0x00, 0x00, 0xFF},
+		},
+		{
+			name:   "Skips the padding of the rows",
+			data:   []byte{0x30, 0x20, 0x10, 0x00, 0xAA, 0xAA, 0xAA, 0xAA},
+			pitch:  8,
+			format: libretro.PixelFormatXRGB8888,
+			want:   color.RGBA{0x10, 0x20, 0x30, 0xFF},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := toRGBA(tt.data, 1, 1, tt.pitch, tt.format)
+			if got := img.RGBAAt(0, 0); got != tt.want {
+				t.Errorf("toRGBA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
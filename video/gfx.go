@@ -222,23 +222,36 @@ func textureLoad(nrgba *image.NRGBA) uint32 {
 	return texture
 }
 
-// NewImage opens an image file, upload it the the GPU and returns the texture id
-func NewImage(file string) uint32 {
+// loadImage opens and decodes an image file, or returns nil on error
+func loadImage(file string) *image.NRGBA {
 	imgFile, err := os.Open(file)
 	if err != nil {
 		log.Printf("failed to open image file %s: %v", file, err)
-		return 0
+		return nil
 	}
 	defer imgFile.Close()
 
 	img, _, err := image.Decode(imgFile)
 	if err != nil {
 		log.Printf("failed to decode image file %s: %v", file, err)
-		return 0
+		return nil
 	}
 
 	nrgba := image.NewNRGBA(img.Bounds())
 	draw.Draw(nrgba, nrgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+	return nrgba
+}
 
+// NewImage opens an image file, upload it the the GPU and returns the texture id
+func (video *Video) NewImage(file string) uint32 {
+	nrgba := loadImage(file)
+	if nrgba == nil {
+		return 0
+	}
 	return textureLoad(nrgba)
 }
+
+// deleteImage frees the texture of an image created by NewImage
+func (video *Video) deleteImage(image uint32) {
+	gl.DeleteTextures(1, &image)
+}
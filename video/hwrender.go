@@ -0,0 +1,281 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"unsafe"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/glfw/v3.4/glfw"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/state"
+)
+
+// hwRender holds the context and the framebuffer of a core rendering with
+// OpenGL. The cores run in their own thread, so the context belongs to a
+// hidden window sharing its objects with the main window, and is current in
+// the thread of the core. The texture of the framebuffer being shared, the main
+// context presents it like the frames of the other cores.
+type hwRender struct {
+	window        *glfw.Window
+	fbo           uint32 // framebuffer the core renders to
+	tex           uint32 // color attachment of the framebuffer
+	rbo           uint32 // depth and stencil attachment of the framebuffer
+	width, height int32  // size of the framebuffer
+	bottomLeft    bool   // the core renders with the origin at the bottom left
+	depth         bool
+	stencil       bool
+	ready         bool // a frame has been rendered
+	filtered      bool // the filter has been applied to the texture
+}
+
+// InitHWRender creates the OpenGL context requested by a core. It must be
+// called from the main thread.
+func (video *Video) InitHWRender(cb *libretro.HWRenderCallback) error {
+	if video.Window == nil {
+		return errors.New("hardware rendering needs a window")
+	}
+
+	switch cb.ContextType {
+	case libretro.HWContextOpenGL:
+	case libretro.HWContextOpenGLCore:
+		major, minor := int(cb.VersionMajor), int(cb.VersionMinor)
+		// Core profiles start with OpenGL 3.2
+		if major < 3 || (major == 3 && minor < 2) {
+			major, minor = 3, 2
+		}
+		glfw.WindowHint(glfw.ContextVersionMajor, major)
+		glfw.WindowHint(glfw.ContextVersionMinor, minor)
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	default:
+		return fmt.Errorf("unsupported hardware context type %d", cb.ContextType)
+	}
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	defer glfw.DefaultWindowHints()
+
+	w, err := glfw.CreateWindow(1, 1, "", nil, video.Window)
+	if err != nil {
+		return err
+	}
+
+	video.hw = &hwRender{
+		window:     w,
+		bottomLeft: cb.BottomLeftOrigin,
+		depth:      cb.Depth,
+		stencil:    cb.Stencil,
+	}
+
+	if state.Verbose {
+		log.Printf("[Video]: Hardware context %d, version %d.%d\n", cb.ContextType, cb.VersionMajor, cb.VersionMinor)
+	}
+	return nil
+}
+
+// CreateHWFramebuffer makes the hardware context current and creates the
+// framebuffer the core renders to. It must run in the thread of the core, see
+// libretro.Core.Do.
+func (video *Video) CreateHWFramebuffer(width, height int) error {
+	if video.hw == nil {
+		return nil
+	}
+	hw := video.hw
+	hw.window.MakeContextCurrent()
+
+	gl.GenTextures(1, &hw.tex)
+	gl.GenFramebuffers(1, &hw.fbo)
+	if hw.depth || hw.stencil {
+		gl.GenRenderbuffers(1, &hw.rbo)
+	}
+	video.ResizeHWFramebuffer(width, height)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, hw.fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, hw.tex, 0)
+	if hw.rbo != 0 {
+		attachment := uint32(gl.DEPTH_ATTACHMENT)
+		if hw.stencil {
+			attachment = gl.DEPTH_STENCIL_ATTACHMENT
+		}
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, attachment, gl.RENDERBUFFER, hw.rbo)
+	}
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("incomplete framebuffer: 0x%x", status)
+	}
+	return nil
+}
+
+// ResizeHWFramebuffer grows the framebuffer if the core needs a bigger one. It
+// must run in the thread of the core.
+func (video *Video) ResizeHWFramebuffer(width, height int) {
+	hw := video.hw
+	if hw == nil || hw.tex == 0 || (int32(width) <= hw.width && int32(height) <= hw.height) {
+		return
+	}
+	hw.width = max32(hw.width, int32(width))
+	hw.height = max32(hw.height, int32(height))
+
+	gl.BindTexture(gl.TEXTURE_2D, hw.tex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, hw.width, hw.height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	if hw.rbo != 0 {
+		format := uint32(gl.DEPTH_COMPONENT24)
+		if hw.stencil {
+			format = gl.DEPTH24_STENCIL8
+		}
+		gl.BindRenderbuffer(gl.RENDERBUFFER, hw.rbo)
+		gl.RenderbufferStorage(gl.RENDERBUFFER, format, hw.width, hw.height)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	}
+}
+
+// DestroyHWFramebuffer deletes the framebuffer and releases the hardware
+// context. It must run in the thread of the core.
+func (video *Video) DestroyHWFramebuffer() {
+	hw := video.hw
+	if hw == nil || hw.tex == 0 {
+		return
+	}
+	gl.DeleteFramebuffers(1, &hw.fbo)
+	gl.DeleteTextures(1, &hw.tex)
+	if hw.rbo != 0 {
+		gl.DeleteRenderbuffers(1, &hw.rbo)
+	}
+	hw.fbo, hw.tex, hw.rbo = 0, 0, 0
+	glfw.DetachCurrentContext()
+}
+
+// DeinitHWRender destroys the hardware context. It must be called from the
+// main thread, after DestroyHWFramebuffer.
+func (video *Video) DeinitHWRender() {
+	if video.hw == nil {
+		return
+	}
+	video.hw.window.Destroy()
+	video.hw = nil
+}
+
+// HWFramebuffer returns the framebuffer the core renders to. It is the
+// get_current_framebuffer callback of the hardware rendering interface.
+func (video *Video) HWFramebuffer() uintptr {
+	if video.hw == nil {
+		return 0
+	}
+	return uintptr(video.hw.fbo)
+}
+
+// HWProcAddress returns the address of an OpenGL function. It is the
+// get_proc_address callback of the hardware rendering interface.
+func (video *Video) HWProcAddress(name string) unsafe.Pointer {
+	return glfw.GetProcAddress(name)
+}
+
+// refreshHW is called instead of uploading a frame when the core rendered in
+// the framebuffer, in the thread of the core
+func (video *Video) refreshHW(width, height int32) {
+	if video.hw == nil {
+		return
+	}
+	// The main context can't present the texture before the rendering ends
+	gl.Finish()
+	video.width = width
+	video.height = height
+	video.hw.ready = true
+}
+
+// presentHW prepares the texture of the framebuffer to be drawn by the main
+// context
+func (video *Video) presentHW() {
+	if !video.hw.filtered {
+		video.UpdateFilter(video.filter)
+		video.hw.filtered = true
+	}
+	gl.UseProgram(video.program)
+	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("TextureSize\x00")), float32(video.hw.width), float32(video.hw.height))
+	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("InputSize\x00")), float32(video.width), float32(video.height))
+}
+
+// currentTexture returns the texture holding the frame of the core
+func (video *Video) currentTexture() uint32 {
+	if video.hw != nil {
+		return video.hw.tex
+	}
+	return video.texID
+}
+
+// hwUV restricts the texture coordinates to the part of the framebuffer the
+// core rendered to
+func (video *Video) hwUV(va []float32) []float32 {
+	if video.hw == nil || video.hw.width == 0 || video.hw.height == 0 {
+		return va
+	}
+	su := float32(video.width) / float32(video.hw.width)
+	sv := float32(video.height) / float32(video.hw.height)
+	return cropUV(va, su, sv, video.hw.bottomLeft)
+}
+
+// cropUV restricts the texture coordinates of a vertex array to the first
+// texels of a texture, in a proportion su, sv. With flip, the rows are
+// reversed, for framebuffers with a bottom left origin.
+func cropUV(va []float32, su, sv float32, flip bool) []float32 {
+	for i := 0; i+3 < len(va); i += 4 {
+		u, v := va[i+2], va[i+3]
+		if flip {
+			v = 1 - v
+		}
+		va[i+2], va[i+3] = u*su, v*sv
+	}
+	return va
+}
+
+// hwFrame reads the last frame rendered by the core back from the GPU
+func (video *Video) hwFrame() *image.RGBA {
+	hw := video.hw
+	if !hw.ready || hw.tex == 0 {
+		return nil
+	}
+	full := image.NewRGBA(image.Rect(0, 0, int(hw.width), int(hw.height)))
+	gl.BindTexture(gl.TEXTURE_2D, hw.tex)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(full.Pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return cropRows(full, int(video.width), int(video.height), hw.bottomLeft)
+}
+
+// cropRows returns the first w x h texels of a texture read with
+// glGetTexImage. With flip, the rows are reversed, for framebuffers with a
+// bottom left origin.
+func cropRows(src *image.RGBA, w, h int, flip bool) *image.RGBA {
+	w = clampInt(w, 0, src.Rect.Dx())
+	h = clampInt(h, 0, src.Rect.Dy())
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy := y
+		if flip {
+			sy = h - 1 - y
+		}
+		copy(dst.Pix[y*dst.Stride:y*dst.Stride+w*4], src.Pix[sy*src.Stride:])
+	}
+	// The alpha channel of the framebuffer is meaningless
+	for i := 3; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = 0xFF
+	}
+	return dst
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
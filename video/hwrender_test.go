@@ -0,0 +1,78 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func Test_cropUV(t *testing.T) {
+	tests := []struct {
+		name string
+		flip bool
+		want []float32
+	}{
+		{
+			name: "Keeps the first texels",
+			want: []float32{0, 0.25, 0, 0, 0.5, 0.25, 0.5, 0},
+		},
+		{
+			name: "Reverses the rows of framebuffers with a bottom left origin",
+			flip: true,
+			want: []float32{0, 0, 0, 0.25, 0.5, 0, 0.5, 0.25},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			va := make([]float32, len(vertices))
+			copy(va, vertices)
+			va = cropUV(va, 0.5, 0.25, tt.flip)
+			var got []float32
+			for i := 0; i < len(va); i += 4 {
+				got = append(got, va[i+2], va[i+3])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cropUV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cropRows(t *testing.T) {
+	// A 2x3 texture with a different red value on each texel
+	src := image.NewRGBA(image.Rect(0, 0, 2, 3))
+	for i := 0; i < 6; i++ {
+		src.Pix[i*4] = byte(i)
+	}
+
+	tests := []struct {
+		name string
+		flip bool
+		want []byte
+	}{
+		{
+			name: "Keeps the first texels",
+			want: []byte{0, 2},
+		},
+		{
+			name: "Reverses the rows",
+			flip: true,
+			want: []byte{2, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := cropRows(src, 1, 2, tt.flip)
+			if got := img.Bounds().Size(); got != image.Pt(1, 2) {
+				t.Fatalf("cropRows() size = %v, want %v", got, image.Pt(1, 2))
+			}
+			for y, r := range tt.want {
+				want := color.RGBA{r, 0, 0, 0xFF}
+				if got := img.RGBAAt(0, y); got != want {
+					t.Errorf("cropRows() row %d = %v, want %v", y, got, want)
+				}
+			}
+		})
+	}
+}
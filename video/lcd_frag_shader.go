@@ -20,8 +20,10 @@ uniform vec2 InputSize;
 uniform sampler2D Texture;
 COMPAT_VARYING vec2 fragTexCoord;
 
-#define BORDERMULT 14.0
-#define GBAGAMMA 1.0
+#pragma parameter BORDERMULT "Border Multiplier" 14.0 -40.0 40.0 1.0
+#pragma parameter GBAGAMMA "GBA Gamma Hack" 1.0 0.0 1.0 1.0
+uniform float BORDERMULT;
+uniform float GBAGAMMA;
 
 void main() {
   vec2 texcoordInPixels = fragTexCoord.xy * TextureSize.xy;
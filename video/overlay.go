@@ -0,0 +1,207 @@
+package video
+
+import (
+	"image"
+	_ "image/png" // to decode the size of the overlays
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// overlayConfig is the file describing an overlay, next to its image with the
+// .toml extension. The rectangle is the screen of the bezel, in pixels of the
+// image, where the game is displayed.
+type overlayConfig struct {
+	X      int  `toml:"x"`
+	Y      int  `toml:"y"`
+	Width  int  `toml:"width"`
+	Height int  `toml:"height"`
+	Over   bool `toml:"over"` // draw the overlay over the game, through a transparent screen
+}
+
+// overlay is a bezel image drawn around the game
+type overlay struct {
+	image         uint32 // id returned by NewImage
+	width, height int    // size of the image
+	config        overlayConfig
+}
+
+// overlayKey is what the overlay of the current game depends on
+type overlayKey struct {
+	enabled bool
+	dir     string
+	game    string
+}
+
+// overlayLayer picks and holds the overlay of the current game
+type overlayLayer struct {
+	overlay *overlay
+	key     overlayKey
+}
+
+// update picks the overlay again when the game or the settings changed. The
+// images are loaded and freed with the functions of the renderer.
+func (l *overlayLayer) update(newImage func(string) uint32, deleteImage func(uint32)) {
+	key := overlayKey{
+		enabled: settings.Current.VideoOverlay && state.CoreRunning,
+		dir:     settings.Current.OverlaysDirectory,
+		game:    state.GamePath,
+	}
+	if key == l.key {
+		return
+	}
+	l.key = key
+
+	if l.overlay != nil {
+		deleteImage(l.overlay.image)
+		l.overlay = nil
+	}
+	if !key.enabled {
+		return
+	}
+
+	path := findOverlay(key.dir, overlayNames())
+	if path == "" {
+		return
+	}
+	o, err := loadOverlay(path)
+	if err != nil {
+		log.Println("[Video]:", err)
+		return
+	}
+	o.image = newImage(path)
+	if o.image == 0 {
+		return
+	}
+	l.overlay = o
+	if state.Verbose {
+		log.Println("[Video]: Overlay", path)
+	}
+}
+
+// overlayNames returns the names the overlay of the current game can have,
+// from the most specific: the game, its system and the core
+func overlayNames() []string {
+	var names []string
+	if state.GamePath != "" {
+		names = append(names, utils.FileName(state.GamePath))
+	}
+	if system := gameSystem(); system != "" {
+		names = append(names, system)
+	}
+	if state.CorePath != "" {
+		names = append(names, utils.FileName(state.CorePath))
+	}
+	return names
+}
+
+// gameSystem returns the system of the current game, from the playlist listing
+// it, or else from the database entry matching its file name
+func gameSystem() string {
+	if state.GamePath == "" {
+		return ""
+	}
+	path := filepath.Clean(state.GamePath)
+	for csv, playlist := range playlists.Playlists {
+		for _, game := range playlist {
+			if game.Path == path {
+				return utils.FileName(csv)
+			}
+		}
+	}
+	name := filepath.Base(path)
+	for system, dat := range state.DB {
+		for _, game := range dat.Games {
+			if game.Name == utils.FileName(path) {
+				return system
+			}
+			for _, rom := range game.ROMs {
+				if rom.Name == name {
+					return system
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// findOverlay returns the path of the first image of the overlays directory
+// matching a name, or an empty string
+func findOverlay(dir string, names []string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name+".png")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadOverlay reads the size of an overlay image and its config. Without
+// config, the game is displayed in the whole image.
+func loadOverlay(path string) (*overlay, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cfg, _, err := image.DecodeConfig(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &overlay{
+		width:  cfg.Width,
+		height: cfg.Height,
+		config: overlayConfig{Width: cfg.Width, Height: cfg.Height},
+	}
+	b, err := os.ReadFile(strings.TrimSuffix(path, filepath.Ext(path)) + ".toml")
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := toml.Unmarshal(b, &o.config); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// rect returns the rectangle of the framebuffer where the overlay is drawn, as
+// big as possible while keeping its aspect ratio
+func (o *overlay) rect(fbWidth, fbHeight int) (x, y, w, h float32) {
+	return fitViewport(float32(o.width)/float32(o.height), fbWidth, fbHeight)
+}
+
+// screen returns the rectangle of the framebuffer covered by the screen of the
+// overlay
+func (o *overlay) screen(fbWidth, fbHeight int) (x, y, w, h float32) {
+	ox, oy, ow, oh := o.rect(fbWidth, fbHeight)
+	sx := ow / float32(o.width)
+	sy := oh / float32(o.height)
+	c := o.config
+	return ox + float32(c.X)*sx, oy + float32(c.Y)*sy, float32(c.Width) * sx, float32(c.Height) * sy
+}
+
+// placeGame returns the rectangle of the framebuffer displaying the game. With
+// an overlay, the game fits in its screen, unless the viewport is custom.
+func placeGame(geom libretro.GameGeometry, vp settings.Viewport, o *overlay, fbWidth, fbHeight int) (x, y, w, h float32) {
+	if o == nil || vp.AspectRatio == "Custom" || o.config.Width <= 0 || o.config.Height <= 0 {
+		return gameViewport(geom, vp, fbWidth, fbHeight)
+	}
+	sx, sy, sw, sh := o.screen(fbWidth, fbHeight)
+	x, y, w, h = gameViewport(geom, vp, int(sw+0.5), int(sh+0.5))
+	return sx + x, sy + y, w, h
+}
@@ -0,0 +1,157 @@
+package video
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+func writeImage(t *testing.T, path string, w, h int) {
+	t.Helper()
+	if err := writePNG(image.NewNRGBA(image.Rect(0, 0, w, h)), path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_loadOverlay(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, filepath.Join(dir, "Nintendo - SNES.png"), 192, 108)
+	writeFile(t, filepath.Join(dir, "Nintendo - SNES.toml"), "x = 48\ny = 6\nwidth = 96\nheight = 72\nover = true\n")
+	writeImage(t, filepath.Join(dir, "snes9x_libretro.png"), 160, 90)
+
+	t.Run("Finds the most specific overlay", func(t *testing.T) {
+		got := findOverlay(dir, []string{"Super Mario World", "Nintendo - SNES", "snes9x_libretro"})
+		want := filepath.Join(dir, "Nintendo - SNES.png")
+		if got != want {
+			t.Errorf("findOverlay() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Finds nothing without matching image", func(t *testing.T) {
+		if got := findOverlay(dir, []string{"Sega - Mega Drive"}); got != "" {
+			t.Errorf("findOverlay() = %v, want %v", got, "")
+		}
+	})
+
+	t.Run("Reads the screen from the config", func(t *testing.T) {
+		o, err := loadOverlay(filepath.Join(dir, "Nintendo - SNES.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := overlayConfig{X: 48, Y: 6, Width: 96, Height: 72, Over: true}
+		if o.width != 192 || o.height != 108 || o.config != want {
+			t.Errorf("loadOverlay() = %v, want %v", *o, want)
+		}
+	})
+
+	t.Run("Uses the whole image without config", func(t *testing.T) {
+		o, err := loadOverlay(filepath.Join(dir, "snes9x_libretro.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := overlayConfig{Width: 160, Height: 90}
+		if o.config != want {
+			t.Errorf("loadOverlay() = %v, want %v", o.config, want)
+		}
+	})
+}
+
+func Test_gameSystem(t *testing.T) {
+	defer func(path string, p map[string]playlists.Playlist, db dat.DB) {
+		state.GamePath, playlists.Playlists, state.DB = path, p, db
+	}(state.GamePath, playlists.Playlists, state.DB)
+
+	playlists.Playlists = map[string]playlists.Playlist{
+		"/playlists/Nintendo - SNES.csv": {{Path: "/roms/Super Mario World (USA).sfc"}},
+	}
+	state.DB = dat.DB{
+		"Sega - Mega Drive": {Games: []dat.Game{{
+			Name: "Sonic The Hedgehog (USA, Europe)",
+			ROMs: []dat.ROM{{Name: "Sonic The Hedgehog (USA, Europe).md"}},
+		}}},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"Takes the system of the playlist", "/roms/../roms/Super Mario World (USA).sfc", "Nintendo - SNES"},
+		{"Takes the system of the database entry", "/downloads/Sonic The Hedgehog (USA, Europe).md", "Sega - Mega Drive"},
+		{"Matches archives with the game name", "/downloads/Sonic The Hedgehog (USA, Europe).zip", "Sega - Mega Drive"},
+		{"Finds nothing for unknown games", "/downloads/homebrew.sfc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state.GamePath = tt.path
+			if got := gameSystem(); got != tt.want {
+				t.Errorf("gameSystem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_placeGame(t *testing.T) {
+	snes := libretro.GameGeometry{BaseWidth: 256, BaseHeight: 224, AspectRatio: 4.0 / 3.0}
+	// A 16:9 bezel with a 4:3 screen in the middle
+	bezel := &overlay{width: 1920, height: 1080, config: overlayConfig{X: 480, Y: 60, Width: 960, Height: 720}}
+
+	type rect struct{ x, y, w, h float32 }
+	tests := []struct {
+		name     string
+		vp       settings.Viewport
+		o        *overlay
+		fbw, fbh int
+		want     rect
+	}{
+		{
+			name: "Fills the framebuffer without overlay",
+			vp:   settings.Viewport{AspectRatio: "Core"},
+			fbw:  1920, fbh: 1080,
+			want: rect{240, 0, 1440, 1080},
+		},
+		{
+			name: "Fits the game in the screen of the overlay",
+			vp:   settings.Viewport{AspectRatio: "Core"},
+			o:    bezel,
+			fbw:  1920, fbh: 1080,
+			want: rect{480, 60, 960, 720},
+		},
+		{
+			name: "Follows the overlay when it is scaled down",
+			vp:   settings.Viewport{AspectRatio: "Core"},
+			o:    bezel,
+			fbw:  960, fbh: 540,
+			want: rect{240, 30, 480, 360},
+		},
+		{
+			name: "Keeps the forced aspect ratio inside the screen",
+			vp:   settings.Viewport{AspectRatio: "16:9"},
+			o:    bezel,
+			fbw:  1920, fbh: 1080,
+			want: rect{480, 150, 960, 540},
+		},
+		{
+			name: "Ignores the overlay for a custom viewport",
+			vp:   settings.Viewport{AspectRatio: "Custom", CustomX: 10, CustomY: 20, CustomWidth: 100, CustomHeight: 50},
+			o:    bezel,
+			fbw:  1920, fbh: 1080,
+			want: rect{10, 20, 100, 50},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, w, h := placeGame(snes, tt.vp, tt.o, tt.fbw, tt.fbh)
+			got := rect{x, y, w, h}
+			if !approx(got.x, tt.want.x) || !approx(got.y, tt.want.y) || !approx(got.w, tt.want.w) || !approx(got.h, tt.want.h) {
+				t.Errorf("placeGame() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package video
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// ShaderParameter is a parameter declared in a shader with #pragma parameter.
+// The shaders read its value from a uniform, so it can change at runtime.
+type ShaderParameter struct {
+	ID      string
+	Desc    string
+	Initial float32
+	Min     float32
+	Max     float32
+	Step    float32
+}
+
+// builtinShaders maps the filters to the fragment shaders declaring parameters
+var builtinShaders = map[string]string{
+	"CRT": zfastCRTFragmentShader,
+	"LCD": zfastLCDFragmentShader,
+}
+
+// ShaderParameters returns the parameters of the active shader
+func (video *Video) ShaderParameters() []ShaderParameter {
+	if video.chain != nil {
+		return video.chain.params
+	}
+	return video.params
+}
+
+// applyParameters sets the uniforms of the parameters of the filter
+func (video *Video) applyParameters() {
+	values := savedShaderParameters()
+	for _, p := range video.params {
+		loc := gl.GetUniformLocation(video.program, gl.Str(p.ID+"\x00"))
+		gl.Uniform1f(loc, parameterValue(values, p))
+	}
+}
+
+func findParameter(params []ShaderParameter, id string) (ShaderParameter, bool) {
+	for _, p := range params {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return ShaderParameter{}, false
+}
+
+// parametersKeys returns the keys of the parameters saved for the current core
+// and for the current game
+func parametersKeys() (core, game string) {
+	core = utils.FileName(state.CorePath)
+	return core, core + "/" + utils.FileName(state.GamePath)
+}
+
+// savedShaderParameters returns the parameters saved for the current game, or
+// else for the current core
+func savedShaderParameters() map[string]float32 {
+	core, game := parametersKeys()
+	if values, ok := settings.Current.ShaderParameters[game]; ok {
+		return values
+	}
+	return settings.Current.ShaderParameters[core]
+}
+
+func parameterValue(values map[string]float32, p ShaderParameter) float32 {
+	if v, ok := values[p.ID]; ok {
+		return v
+	}
+	return p.Initial
+}
+
+// stepParameter rounds a value to the steps of a parameter and keeps it in its
+// range
+func stepParameter(p ShaderParameter, v float32) float32 {
+	if p.Step > 0 {
+		v = p.Min + float32(math.Round(float64((v-p.Min)/p.Step)))*p.Step
+	}
+	if v < p.Min {
+		v = p.Min
+	}
+	if v > p.Max {
+		v = p.Max
+	}
+	return v
+}
+
+// ShaderParameterValue returns the current value of a parameter
+func ShaderParameterValue(p ShaderParameter) float32 {
+	return parameterValue(savedShaderParameters(), p)
+}
+
+// IncrShaderParameter changes a parameter by a step in the given direction.
+// The value is saved for the current game if it has its own parameters, or
+// else for the current core. The shaders pick it up on the next frame.
+func IncrShaderParameter(p ShaderParameter, direction int) {
+	step := p.Step
+	if step == 0 {
+		step = (p.Max - p.Min) / 100
+	}
+	v := stepParameter(p, ShaderParameterValue(p)+float32(direction)*step)
+
+	core, game := parametersKeys()
+	key := core
+	if GameShaderParameters() {
+		key = game
+	}
+	if settings.Current.ShaderParameters == nil {
+		settings.Current.ShaderParameters = map[string]map[string]float32{}
+	}
+	if settings.Current.ShaderParameters[key] == nil {
+		settings.Current.ShaderParameters[key] = map[string]float32{}
+	}
+	settings.Current.ShaderParameters[key][p.ID] = v
+	settings.Save()
+}
+
+// ResetShaderParameters forgets the values saved for the current game if it
+// has its own parameters, or else for the current core
+func ResetShaderParameters() {
+	core, game := parametersKeys()
+	if GameShaderParameters() {
+		settings.Current.ShaderParameters[game] = map[string]float32{}
+	} else {
+		delete(settings.Current.ShaderParameters, core)
+	}
+	settings.Save()
+}
+
+// GameShaderParameters returns true if the current game has its own parameters
+func GameShaderParameters() bool {
+	_, game := parametersKeys()
+	_, ok := settings.Current.ShaderParameters[game]
+	return ok
+}
+
+// SetGameShaderParameters gives the current game its own parameters, starting
+// from the ones of the core, or goes back to the parameters of the core
+func SetGameShaderParameters(enable bool) {
+	core, game := parametersKeys()
+	if !enable {
+		delete(settings.Current.ShaderParameters, game)
+		settings.Save()
+		return
+	}
+	values := map[string]float32{}
+	for id, v := range settings.Current.ShaderParameters[core] {
+		values[id] = v
+	}
+	if settings.Current.ShaderParameters == nil {
+		settings.Current.ShaderParameters = map[string]map[string]float32{}
+	}
+	settings.Current.ShaderParameters[game] = values
+	settings.Save()
+}
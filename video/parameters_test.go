@@ -0,0 +1,84 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+func Test_stepParameter(t *testing.T) {
+	p := ShaderParameter{ID: "MASK_DARK", Initial: 0.25, Min: 0, Max: 1, Step: 0.05}
+	tests := []struct {
+		name string
+		p    ShaderParameter
+		v    float32
+		want float32
+	}{
+		{name: "Rounds to the nearest step", p: p, v: 0.31, want: 0.3},
+		{name: "Keeps the minimum", p: p, v: -0.05, want: 0},
+		{name: "Keeps the maximum", p: p, v: 1.05, want: 1},
+		{name: "Doesn't round without step", p: ShaderParameter{Max: 1}, v: 0.31, want: 0.31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stepParameter(tt.p, tt.v); got-tt.want > 1e-6 || tt.want-got > 1e-6 {
+				t.Errorf("stepParameter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ShaderParameterValue(t *testing.T) {
+	state.CorePath = "/cores/snes9x_libretro.so"
+	state.GamePath = "/roms/Super Metroid.sfc"
+	defer func() { state.CorePath, state.GamePath = "", "" }()
+	defer func(saved map[string]map[string]float32) { settings.Current.ShaderParameters = saved }(settings.Current.ShaderParameters)
+	settings.Current.ShaderParameters = map[string]map[string]float32{}
+
+	p := ShaderParameter{ID: "BLURSCALEX", Initial: 0.45}
+
+	t.Run("Defaults to the initial value", func(t *testing.T) {
+		if got := ShaderParameterValue(p); got != 0.45 {
+			t.Errorf("ShaderParameterValue() = %v, want 0.45", got)
+		}
+	})
+
+	settings.Current.ShaderParameters["snes9x_libretro"] = map[string]float32{"BLURSCALEX": 0.2}
+
+	t.Run("Uses the value of the core", func(t *testing.T) {
+		if got := ShaderParameterValue(p); got != 0.2 {
+			t.Errorf("ShaderParameterValue() = %v, want 0.2", got)
+		}
+		if GameShaderParameters() {
+			t.Errorf("GameShaderParameters() = true, want false")
+		}
+	})
+
+	settings.Current.ShaderParameters["snes9x_libretro/Super Metroid"] = map[string]float32{}
+
+	t.Run("Prefers the values of the game", func(t *testing.T) {
+		if got := ShaderParameterValue(p); got != 0.45 {
+			t.Errorf("ShaderParameterValue() = %v, want 0.45", got)
+		}
+		if !GameShaderParameters() {
+			t.Errorf("GameShaderParameters() = false, want true")
+		}
+	})
+}
+
+func Test_builtinShaders(t *testing.T) {
+	for filter, src := range builtinShaders {
+		t.Run("Declares the parameters of "+filter, func(t *testing.T) {
+			params := parseParameters(src)
+			if len(params) == 0 {
+				t.Fatalf("parseParameters() = %v, want parameters", params)
+			}
+			for _, p := range params {
+				if p.Initial < p.Min || p.Initial > p.Max {
+					t.Errorf("%s = %v, want in [%v, %v]", p.ID, p.Initial, p.Min, p.Max)
+				}
+			}
+		})
+	}
+}
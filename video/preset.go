@@ -0,0 +1,339 @@
+package video
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libretro/ludo/settings"
+)
+
+// Scale types of a shader pass, relative to the size of its input, to the size
+// of the viewport, or in pixels
+const (
+	scaleSource   = "source"
+	scaleViewport = "viewport"
+	scaleAbsolute = "absolute"
+)
+
+// shaderPreset is a chain of shader passes described by a libretro .glslp file
+type shaderPreset struct {
+	passes   []shaderPass
+	textures []lutTexture
+	values   map[string]string // every key of the preset, to look up the parameters
+}
+
+// shaderPass is a shader of a preset and the way it renders
+type shaderPass struct {
+	shader        string // path of the .glsl file
+	filter        string // "linear", "nearest" or "" to use the video filter
+	wrapMode      string // wrapping of the input texture
+	scaleTypeX    string // "" when the pass renders to the viewport
+	scaleTypeY    string
+	scaleX        float32
+	scaleY        float32
+	alias         string // name used by the next passes to sample the output
+	frameCountMod uint
+	floatFBO      bool
+	srgbFBO       bool
+	mipmapInput   bool
+}
+
+// lutTexture is a lookup texture loaded from an image
+type lutTexture struct {
+	name     string
+	path     string
+	linear   bool
+	wrapMode string
+	mipmap   bool
+}
+
+// ShaderPresets lists the .glslp files of the shaders directory, relative to
+// it
+func ShaderPresets() []string {
+	dir := settings.Current.ShadersDirectory
+	var presets []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".glslp" {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			presets = append(presets, rel)
+		}
+		return nil
+	})
+	sort.Strings(presets)
+	return presets
+}
+
+// ShaderPresetPath returns the path of a preset of the shaders directory, or
+// an empty string for "None"
+func ShaderPresetPath(name string) string {
+	if name == "" || name == "None" {
+		return ""
+	}
+	return filepath.Join(settings.Current.ShadersDirectory, name)
+}
+
+// readPresetValues reads the keys of a preset. Paths are relative to the file
+// declaring them, so dirs stores the directory of each key. A #reference line
+// includes another preset, the following keys override its values.
+func readPresetValues(path string, values, dirs map[string]string, depth int) error {
+	if depth > 16 {
+		return errors.New("too many nested #reference")
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#reference") {
+			ref := unquote(strings.TrimSpace(strings.TrimPrefix(line, "#reference")))
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(filepath.Dir(path), ref)
+			}
+			if err := readPresetValues(ref, values, dirs, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		values[key] = unquote(strings.TrimSpace(value))
+		dirs[key] = filepath.Dir(path)
+	}
+	return scanner.Err()
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// loadShaderPreset parses a .glslp file
+func loadShaderPreset(path string) (*shaderPreset, error) {
+	values := map[string]string{}
+	dirs := map[string]string{}
+	if err := readPresetValues(path, values, dirs, 0); err != nil {
+		return nil, err
+	}
+
+	resolve := func(key string) string {
+		p := values[key]
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dirs[key], p)
+	}
+	boolean := func(key string) bool {
+		b, _ := strconv.ParseBool(values[key])
+		return b
+	}
+	float := func(key string) (float32, bool) {
+		f, err := strconv.ParseFloat(values[key], 32)
+		return float32(f), err == nil
+	}
+
+	n, err := strconv.Atoi(values["shaders"])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("%s: invalid number of shaders %q", filepath.Base(path), values["shaders"])
+	}
+
+	p := &shaderPreset{values: values}
+	for i := 0; i < n; i++ {
+		pass := shaderPass{
+			shader:   resolve(fmt.Sprintf("shader%d", i)),
+			wrapMode: values[fmt.Sprintf("wrap_mode%d", i)],
+			alias:    values[fmt.Sprintf("alias%d", i)],
+		}
+		if pass.shader == "" {
+			return nil, fmt.Errorf("%s: missing shader%d", filepath.Base(path), i)
+		}
+
+		if v, ok := values[fmt.Sprintf("filter_linear%d", i)]; ok {
+			pass.filter = "nearest"
+			if b, _ := strconv.ParseBool(v); b {
+				pass.filter = "linear"
+			}
+		}
+
+		// A scale without type is relative to the input
+		typ := values[fmt.Sprintf("scale_type%d", i)]
+		pass.scaleTypeX, pass.scaleTypeY = typ, typ
+		if t, ok := values[fmt.Sprintf("scale_type_x%d", i)]; ok {
+			pass.scaleTypeX = t
+		}
+		if t, ok := values[fmt.Sprintf("scale_type_y%d", i)]; ok {
+			pass.scaleTypeY = t
+		}
+		pass.scaleX, pass.scaleY = 1, 1
+		if s, ok := float(fmt.Sprintf("scale%d", i)); ok {
+			pass.scaleX, pass.scaleY = s, s
+			if typ == "" {
+				pass.scaleTypeX, pass.scaleTypeY = scaleSource, scaleSource
+			}
+		}
+		if s, ok := float(fmt.Sprintf("scale_x%d", i)); ok {
+			pass.scaleX = s
+			if pass.scaleTypeX == "" {
+				pass.scaleTypeX = scaleSource
+			}
+		}
+		if s, ok := float(fmt.Sprintf("scale_y%d", i)); ok {
+			pass.scaleY = s
+			if pass.scaleTypeY == "" {
+				pass.scaleTypeY = scaleSource
+			}
+		}
+		// Only one axis set, the other one follows the input
+		if pass.scaleTypeX == "" && pass.scaleTypeY != "" {
+			pass.scaleTypeX, pass.scaleX = scaleSource, 1
+		}
+		if pass.scaleTypeY == "" && pass.scaleTypeX != "" {
+			pass.scaleTypeY, pass.scaleY = scaleSource, 1
+		}
+
+		if m, err := strconv.ParseUint(values[fmt.Sprintf("frame_count_mod%d", i)], 10, 32); err == nil {
+			pass.frameCountMod = uint(m)
+		}
+		pass.floatFBO = boolean(fmt.Sprintf("float_framebuffer%d", i))
+		pass.srgbFBO = boolean(fmt.Sprintf("srgb_framebuffer%d", i))
+		pass.mipmapInput = boolean(fmt.Sprintf("mipmap_input%d", i))
+		// Only the last pass renders to the viewport by default
+		if pass.scaleTypeX == "" && i < n-1 {
+			pass.scaleTypeX, pass.scaleTypeY = scaleSource, scaleSource
+		}
+		p.passes = append(p.passes, pass)
+	}
+
+	for _, name := range strings.Split(values["textures"], ";") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if values[name] == "" {
+			return nil, fmt.Errorf("%s: missing texture %s", filepath.Base(path), name)
+		}
+		p.textures = append(p.textures, lutTexture{
+			name:     name,
+			path:     resolve(name),
+			linear:   boolean(name + "_linear"),
+			wrapMode: values[name+"_wrap_mode"],
+			mipmap:   boolean(name + "_mipmap"),
+		})
+	}
+
+	return p, nil
+}
+
+// parameterValue returns the value of a shader parameter set by the preset, or
+// its initial value
+func (p *shaderPreset) parameterValue(param ShaderParameter) float32 {
+	if f, err := strconv.ParseFloat(p.values[param.ID], 32); err == nil {
+		return float32(f)
+	}
+	return param.Initial
+}
+
+// parseParameters finds the #pragma parameter lines of a shader source, like:
+// #pragma parameter ID "Description" initial minimum maximum step
+func parseParameters(src string) []ShaderParameter {
+	var params []ShaderParameter
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#pragma parameter") {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(line, "#pragma parameter"))
+		id, rest, ok := strings.Cut(rest, " ")
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if len(rest) == 0 || rest[0] != '"' {
+			continue
+		}
+		end := strings.Index(rest[1:], "\"")
+		if end < 0 {
+			continue
+		}
+		param := ShaderParameter{ID: id, Desc: rest[1 : end+1]}
+		nums := strings.Fields(rest[end+2:])
+		if len(nums) < 3 {
+			continue
+		}
+		var f [4]float64
+		var err error
+		for i := 0; i < len(nums) && i < 4 && err == nil; i++ {
+			f[i], err = strconv.ParseFloat(nums[i], 32)
+		}
+		if err != nil {
+			continue
+		}
+		param.Initial, param.Min, param.Max, param.Step = float32(f[0]), float32(f[1]), float32(f[2]), float32(f[3])
+		params = append(params, param)
+	}
+	return params
+}
+
+// shaderStage prepares the source of a libretro .glsl shader, holding both the
+// vertex and the fragment shader, for one of the stages: "VERTEX" or
+// "FRAGMENT". The #version directive has to stay on the first line.
+func shaderStage(src, stage string) string {
+	version := ""
+	var lines []string
+	for _, line := range strings.Split(src, "\n") {
+		if version == "" && strings.HasPrefix(strings.TrimSpace(line), "#version") {
+			version = strings.TrimSpace(line) + "\n"
+			line = ""
+		}
+		lines = append(lines, line)
+	}
+	return version +
+		"#define " + stage + "\n" +
+		"#define PARAMETER_UNIFORM\n" +
+		strings.Join(lines, "\n") + "\x00"
+}
+
+// passSize returns the size of the output of a pass, from the size of its
+// input and the size of the viewport. A pass without scale type renders to the
+// viewport.
+func passSize(pass shaderPass, inW, inH, vpW, vpH int32) (int32, int32) {
+	scale := func(typ string, s float32, in, vp int32) int32 {
+		var v float32
+		switch typ {
+		case scaleAbsolute:
+			v = s
+		case scaleViewport:
+			v = float32(vp) * s
+		default:
+			v = float32(in) * s
+		}
+		if v < 1 {
+			return 1
+		}
+		return int32(v + 0.5)
+	}
+	if pass.scaleTypeX == "" {
+		return vpW, vpH
+	}
+	return scale(pass.scaleTypeX, pass.scaleX, inW, vpW), scale(pass.scaleTypeY, pass.scaleY, inH, vpH)
+}
@@ -0,0 +1,219 @@
+package video
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/libretro/ludo/settings"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_loadShaderPreset(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "crt", "base.glslp"), `
+shaders = 2
+shader0 = "shaders/blur.glsl"
+shader1 = shaders/crt.glsl
+`)
+	path := filepath.Join(dir, "crt.glslp")
+	writeFile(t, path, `#reference "crt/base.glslp"
+# The passes
+filter_linear0 = true
+scale_type0 = source
+scale0 = 2.0
+alias0 = Blurred
+float_framebuffer0 = true
+frame_count_mod0 = 100
+filter_linear1 = false
+wrap_mode1 = repeat
+textures = "mask;noise"
+mask = "masks/mask.png"
+mask_linear = true
+noise = noise.png
+noise_wrap_mode = mirrored_repeat
+CURVATURE = 0.5
+`)
+
+	p, err := loadShaderPreset(path)
+	if err != nil {
+		t.Fatalf("loadShaderPreset() = %v, want nil", err)
+	}
+
+	t.Run("Resolves the paths from the file declaring them", func(t *testing.T) {
+		want := []string{
+			filepath.Join(dir, "crt", "shaders", "blur.glsl"),
+			filepath.Join(dir, "crt", "shaders", "crt.glsl"),
+		}
+		got := []string{p.passes[0].shader, p.passes[1].shader}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("loadShaderPreset() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Reads the settings of the passes", func(t *testing.T) {
+		want := []shaderPass{
+			{
+				shader:        p.passes[0].shader,
+				filter:        "linear",
+				scaleTypeX:    scaleSource,
+				scaleTypeY:    scaleSource,
+				scaleX:        2,
+				scaleY:        2,
+				alias:         "Blurred",
+				frameCountMod: 100,
+				floatFBO:      true,
+			},
+			{
+				shader:   p.passes[1].shader,
+				filter:   "nearest",
+				wrapMode: "repeat",
+				scaleX:   1,
+				scaleY:   1,
+			},
+		}
+		if !reflect.DeepEqual(p.passes, want) {
+			t.Errorf("loadShaderPreset() = %+v, want %+v", p.passes, want)
+		}
+	})
+
+	t.Run("Reads the textures", func(t *testing.T) {
+		want := []lutTexture{
+			{name: "mask", path: filepath.Join(dir, "masks", "mask.png"), linear: true},
+			{name: "noise", path: filepath.Join(dir, "noise.png"), wrapMode: "mirrored_repeat"},
+		}
+		if !reflect.DeepEqual(p.textures, want) {
+			t.Errorf("loadShaderPreset() = %+v, want %+v", p.textures, want)
+		}
+	})
+
+	t.Run("Overrides the parameters of the shaders", func(t *testing.T) {
+		if got := p.parameterValue(ShaderParameter{ID: "CURVATURE", Initial: 1}); got != 0.5 {
+			t.Errorf("parameterValue() = %v, want 0.5", got)
+		}
+		if got := p.parameterValue(ShaderParameter{ID: "SCANLINES", Initial: 1}); got != 1 {
+			t.Errorf("parameterValue() = %v, want 1", got)
+		}
+	})
+
+	t.Run("Fails without shaders", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty.glslp")
+		writeFile(t, empty, "shaders = 0\n")
+		if _, err := loadShaderPreset(empty); err == nil {
+			t.Errorf("loadShaderPreset() = nil, want an error")
+		}
+	})
+
+	t.Run("Fails with a missing pass", func(t *testing.T) {
+		missing := filepath.Join(dir, "missing.glslp")
+		writeFile(t, missing, "shaders = 2\nshader0 = a.glsl\n")
+		if _, err := loadShaderPreset(missing); err == nil {
+			t.Errorf("loadShaderPreset() = nil, want an error")
+		}
+	})
+}
+
+func Test_passSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		pass  shaderPass
+		wantW int32
+		wantH int32
+	}{
+		{
+			name:  "Renders to the viewport without scale type",
+			pass:  shaderPass{},
+			wantW: 1280, wantH: 960,
+		},
+		{
+			name:  "Scales the input",
+			pass:  shaderPass{scaleTypeX: scaleSource, scaleTypeY: scaleSource, scaleX: 2, scaleY: 3},
+			wantW: 640, wantH: 720,
+		},
+		{
+			name:  "Scales the viewport",
+			pass:  shaderPass{scaleTypeX: scaleViewport, scaleTypeY: scaleViewport, scaleX: 0.5, scaleY: 0.5},
+			wantW: 640, wantH: 480,
+		},
+		{
+			name:  "Mixes absolute and source scales",
+			pass:  shaderPass{scaleTypeX: scaleAbsolute, scaleTypeY: scaleSource, scaleX: 400, scaleY: 1},
+			wantW: 400, wantH: 240,
+		},
+		{
+			name:  "Keeps at least a pixel",
+			pass:  shaderPass{scaleTypeX: scaleSource, scaleTypeY: scaleSource, scaleX: 0, scaleY: 0},
+			wantW: 1, wantH: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := passSize(tt.pass, 320, 240, 1280, 960)
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("passSize() = %d, %d, want %d, %d", w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func Test_parseParameters(t *testing.T) {
+	src := `#version 130
+#pragma parameter CURVATURE "Curvature" 0.5 0.0 1.0 0.05
+  #pragma parameter MASK "Mask Type" 1 0 3 1
+#pragma parameter BROKEN "Broken"
+#pragma parameter NOSTEP "No Step" 2.0 1.0 4.0
+`
+	want := []ShaderParameter{
+		{ID: "CURVATURE", Desc: "Curvature", Initial: 0.5, Min: 0, Max: 1, Step: 0.05},
+		{ID: "MASK", Desc: "Mask Type", Initial: 1, Min: 0, Max: 3, Step: 1},
+		{ID: "NOSTEP", Desc: "No Step", Initial: 2, Min: 1, Max: 4},
+	}
+	if got := parseParameters(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseParameters() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_shaderStage(t *testing.T) {
+	t.Run("Keeps the version on the first line", func(t *testing.T) {
+		got := shaderStage("// comment\n#version 130\nvoid main() {}\n", "VERTEX")
+		if !strings.HasPrefix(got, "#version 130\n#define VERTEX\n#define PARAMETER_UNIFORM\n") {
+			t.Errorf("shaderStage() = %q", got)
+		}
+		if strings.Count(got, "#version") != 1 {
+			t.Errorf("shaderStage() = %q, want a single #version", got)
+		}
+	})
+
+	t.Run("Terminates the source", func(t *testing.T) {
+		got := shaderStage("void main() {}", "FRAGMENT")
+		if !strings.HasPrefix(got, "#define FRAGMENT\n") || !strings.HasSuffix(got, "\x00") {
+			t.Errorf("shaderStage() = %q", got)
+		}
+	})
+}
+
+func Test_ShaderPresets(t *testing.T) {
+	settings.Current.ShadersDirectory = t.TempDir()
+	writeFile(t, filepath.Join(settings.Current.ShadersDirectory, "crt", "crt-easymode.glslp"), "")
+	writeFile(t, filepath.Join(settings.Current.ShadersDirectory, "crt", "crt-easymode.glsl"), "")
+	writeFile(t, filepath.Join(settings.Current.ShadersDirectory, "bilinear.glslp"), "")
+
+	want := []string{"bilinear.glslp", filepath.Join("crt", "crt-easymode.glslp")}
+	if got := ShaderPresets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ShaderPresets() = %v, want %v", got, want)
+	}
+	if got := ShaderPresetPath("None"); got != "" {
+		t.Errorf("ShaderPresetPath() = %q, want \"\"", got)
+	}
+}
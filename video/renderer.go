@@ -0,0 +1,51 @@
+package video
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/libretro/ludo/libretro"
+)
+
+// Renderer is a video driver. It receives the frames of the core and draws
+// them along with the menu. Video renders with OpenGL in a window, Software
+// rasterizes in memory without a GPU.
+type Renderer interface {
+	// Callbacks of the core
+	Refresh(data unsafe.Pointer, width int32, height int32, pitch int32)
+	SetPixelFormat(format uint32) bool
+	SetRotation(rot uint) bool
+	SetGeometry(geom libretro.GameGeometry)
+	ResetPitch()
+	ResetRot()
+
+	// Window
+	GetFramebufferSize() (int, int)
+	SetTitle(title string)
+	SetShouldClose(b bool)
+	Reconfigure(fullscreen bool)
+	UpdateFilter(filter string)
+	LoadShader(path string) error
+	ShaderParameters() []ShaderParameter
+
+	// Drawing
+	Render()
+	DrawRect(x, y, w, h, r float32, c Color)
+	DrawBorder(x, y, w, h, borderWidth float32, c Color)
+	DrawCircle(x, y, r float32, c Color)
+	DrawImage(image uint32, x, y, w, h, scale, r float32, c Color)
+	ScissorStart(x, y, w, h int32)
+	ScissorEnd()
+	NewImage(file string) uint32
+	Font() *Font
+
+	// Captures
+	Frame() *image.RGBA
+	SaveFrame(path string) error
+	TakeScreenshot(name string) error
+}
+
+var (
+	_ Renderer = (*Video)(nil)
+	_ Renderer = (*Software)(nil)
+)
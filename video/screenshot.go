@@ -2,7 +2,6 @@ package video
 
 import (
 	"image"
-	"image/png"
 	"os"
 	"path/filepath"
 
@@ -18,12 +17,13 @@ import (
 // taking care of this.
 func (video *Video) renderScreenshot() {
 	va := video.vertexArray(0, 0, float32(video.Geom.BaseWidth), float32(video.Geom.BaseHeight), 1.0)
+	va = video.hwUV(va)
 	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
 	gl.BufferData(gl.ARRAY_BUFFER, len(va)*4, gl.Ptr(va), gl.STATIC_DRAW)
 
 	bindVertexArray(video.vao)
 
-	gl.BindTexture(gl.TEXTURE_2D, video.texID)
+	gl.BindTexture(gl.TEXTURE_2D, video.currentTexture())
 	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
 
 	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
@@ -49,18 +49,16 @@ func (video *Video) TakeScreenshot(name string) error {
 
 	gl.UseProgram(video.program)
 
+	return saveScreenshot(imaging.FlipV(img), name)
+}
+
+// saveScreenshot writes an image to a PNG file in the screenshots directory
+func saveScreenshot(img image.Image, name string) error {
 	err := os.MkdirAll(settings.Current.ScreenshotsDirectory, os.ModePerm)
 	if err != nil {
 		return err
 	}
 
 	path := filepath.Join(settings.Current.ScreenshotsDirectory, name+".png")
-	fd, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-
-	flipped := imaging.FlipV(img)
-
-	return png.Encode(fd, flipped)
+	return writePNG(img, path)
 }
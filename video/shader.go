@@ -0,0 +1,448 @@
+package video
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/state"
+)
+
+// renderTarget is a texture and the framebuffer rendering to it
+type renderTarget struct {
+	fbo, tex      uint32
+	width, height int32
+	format        int32 // internal format of the texture
+}
+
+// resize allocates the texture, if its size or format changed
+func (t *renderTarget) resize(width, height, format int32) {
+	if t.tex == 0 {
+		gl.GenTextures(1, &t.tex)
+		gl.GenFramebuffers(1, &t.fbo)
+	}
+	if t.width == width && t.height == height && t.format == format {
+		return
+	}
+	t.width, t.height, t.format = width, height, format
+
+	typ := uint32(gl.UNSIGNED_BYTE)
+	if format == gl.RGBA32F_ARB {
+		typ = gl.FLOAT
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.tex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, format, width, height, 0, gl.RGBA, typ, nil)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, t.tex, 0)
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+func (t *renderTarget) delete() {
+	if t == nil || t.tex == 0 {
+		return
+	}
+	gl.DeleteFramebuffers(1, &t.fbo)
+	gl.DeleteTextures(1, &t.tex)
+	t.fbo, t.tex = 0, 0
+}
+
+// chainPass is a compiled pass of a shader chain
+type chainPass struct {
+	shaderPass
+	program  uint32
+	vao      uint32
+	uniforms map[string]int32
+	color    int32         // location of the COLOR attribute
+	out      *renderTarget // output of the pass, nil when rendering to the viewport
+	prev     *renderTarget // output of the previous frame, for the feedback
+}
+
+// uniform returns the location of a uniform, -1 if the shader doesn't use it
+func (p *chainPass) uniform(name string) int32 {
+	if loc, ok := p.uniforms[name]; ok {
+		return loc
+	}
+	loc := gl.GetUniformLocation(p.program, gl.Str(name+"\x00"))
+	p.uniforms[name] = loc
+	return loc
+}
+
+// bindTarget binds the texture of a render target to the next texture unit
+// if the shader samples it, and sets its sizes
+func (p *chainPass) bindTarget(unit *int32, prefix string, t *renderTarget) {
+	if t == nil {
+		return
+	}
+	if loc := p.uniform(prefix + "Texture"); loc >= 0 {
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(*unit))
+		gl.BindTexture(gl.TEXTURE_2D, t.tex)
+		gl.Uniform1i(loc, *unit)
+		*unit++
+	}
+	gl.Uniform2f(p.uniform(prefix+"TextureSize"), float32(t.width), float32(t.height))
+	gl.Uniform2f(p.uniform(prefix+"InputSize"), float32(t.width), float32(t.height))
+}
+
+// lut is a lookup texture uploaded to the GPU
+type lut struct {
+	name string
+	tex  uint32
+}
+
+// shaderChain renders the game through the passes of a shader preset. The
+// frame of the core is first copied upright in the orig target, so every
+// texture of the chain shares the same orientation and texture coordinates.
+type shaderChain struct {
+	passes     []*chainPass
+	luts       []lut
+	params     []ShaderParameter // with the values of the preset as initial values
+	orig       *renderTarget
+	history    []*renderTarget // previous frames, Prev, Prev1 to Prev6
+	vbo        uint32
+	frameCount uint
+}
+
+// Texture coordinates used by the libretro shaders, they all share the same
+// attribute data
+var texCoordAttribs = []string{"TexCoord", "OrigTexCoord", "LUTTexCoord",
+	"PrevTexCoord", "Prev1TexCoord", "Prev2TexCoord", "Prev3TexCoord",
+	"Prev4TexCoord", "Prev5TexCoord", "Prev6TexCoord"}
+
+var prevRegexp = regexp.MustCompile(`\bPrev([1-6]?)Texture\b`)
+
+// Quad covering a render target, for the passes
+var passVertices = []float32{
+	//  X, Y, U, V
+	0, 0, 0, 0, // left-bottom
+	0, 1, 0, 1, // left-top
+	1, 0, 1, 0, // right-bottom
+	1, 1, 1, 1, // right-top
+}
+
+// Quad covering the viewport for a texture of the chain, upright unlike the
+// frames of the cores
+var blitVertices = []float32{
+	//  X, Y, U, V
+	-1, -1, 0, 0, // left-bottom
+	-1, 1, 0, 1, // left-top
+	1, -1, 1, 0, // right-bottom
+	1, 1, 1, 1, // right-top
+}
+
+// Maps the quad of the passes to the clip space
+var mvpMatrix = []float32{
+	2, 0, 0, 0,
+	0, 2, 0, 0,
+	0, 0, 1, 0,
+	-1, -1, 0, 1,
+}
+
+// newShaderChain compiles the shaders of a preset and loads its textures
+func newShaderChain(path string) (*shaderChain, error) {
+	preset, err := loadShaderPreset(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &shaderChain{orig: &renderTarget{}}
+	gl.GenBuffers(1, &c.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, c.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(passVertices)*4, gl.Ptr(passVertices), gl.STATIC_DRAW)
+
+	history, feedback := 0, false
+	for i, sp := range preset.passes {
+		b, err := os.ReadFile(sp.shader)
+		if err != nil {
+			c.delete()
+			return nil, err
+		}
+		src := string(b)
+
+		program, err := newProgram(shaderStage(src, "VERTEX"), shaderStage(src, "FRAGMENT"))
+		if err != nil {
+			log.Println("[Video]:", err)
+			c.delete()
+			return nil, fmt.Errorf("failed to compile %s", filepath.Base(sp.shader))
+		}
+		pass := &chainPass{shaderPass: sp, program: program, uniforms: map[string]int32{}}
+		c.passes = append(c.passes, pass)
+		if sp.scaleTypeX != "" {
+			pass.out = &renderTarget{}
+		}
+
+		for _, param := range parseParameters(src) {
+			if _, ok := findParameter(c.params, param.ID); ok {
+				continue
+			}
+			param.Initial = preset.parameterValue(param)
+			c.params = append(c.params, param)
+		}
+		for _, m := range prevRegexp.FindAllStringSubmatch(src, -1) {
+			n, _ := strconv.Atoi(m[1])
+			if n+1 > history {
+				history = n + 1
+			}
+		}
+		feedback = feedback || strings.Contains(src, "Feedback")
+
+		genVertexArrays(1, &pass.vao)
+		bindVertexArray(pass.vao)
+		gl.BindBuffer(gl.ARRAY_BUFFER, c.vbo)
+		if loc := gl.GetAttribLocation(program, gl.Str("VertexCoord\x00")); loc >= 0 {
+			gl.EnableVertexAttribArray(uint32(loc))
+			gl.VertexAttribPointerWithOffset(uint32(loc), 2, gl.FLOAT, false, 4*4, 0)
+		}
+		for _, name := range texCoordAttribs {
+			if loc := gl.GetAttribLocation(program, gl.Str(name+"\x00")); loc >= 0 {
+				gl.EnableVertexAttribArray(uint32(loc))
+				gl.VertexAttribPointerWithOffset(uint32(loc), 2, gl.FLOAT, false, 4*4, 2*4)
+			}
+		}
+		pass.color = gl.GetAttribLocation(program, gl.Str("COLOR\x00"))
+		bindVertexArray(0)
+
+		if state.Verbose {
+			log.Printf("[Video]: Shader pass %d: %s\n", i, filepath.Base(sp.shader))
+		}
+	}
+
+	for i := 0; i < history; i++ {
+		c.history = append(c.history, &renderTarget{})
+	}
+	if feedback {
+		for _, pass := range c.passes {
+			if pass.out != nil {
+				pass.prev = &renderTarget{}
+			}
+		}
+	}
+
+	for _, t := range preset.textures {
+		img := loadImage(t.path)
+		if img == nil {
+			c.delete()
+			return nil, fmt.Errorf("failed to load texture %s", filepath.Base(t.path))
+		}
+		tex := textureLoad(img)
+		setSampling(tex, t.linear, t.mipmap, t.wrapMode)
+		c.luts = append(c.luts, lut{name: t.name, tex: tex})
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	return c, nil
+}
+
+// delete frees the objects of the chain
+func (c *shaderChain) delete() {
+	for _, pass := range c.passes {
+		gl.DeleteProgram(pass.program)
+		pass.out.delete()
+		pass.prev.delete()
+	}
+	for _, l := range c.luts {
+		gl.DeleteTextures(1, &l.tex)
+	}
+	for _, t := range c.history {
+		t.delete()
+	}
+	c.orig.delete()
+	gl.DeleteBuffers(1, &c.vbo)
+}
+
+// setSampling sets the filter and the wrap mode of a texture
+func setSampling(tex uint32, linear, mipmap bool, wrap string) {
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	filter := int32(gl.NEAREST)
+	minFilter := int32(gl.NEAREST)
+	if linear {
+		filter, minFilter = gl.LINEAR, gl.LINEAR
+	}
+	if mipmap {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+		minFilter = gl.NEAREST_MIPMAP_NEAREST
+		if linear {
+			minFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrapMode(wrap))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrapMode(wrap))
+}
+
+func wrapMode(mode string) int32 {
+	switch mode {
+	case "clamp_to_edge":
+		return gl.CLAMP_TO_EDGE
+	case "repeat":
+		return gl.REPEAT
+	case "mirrored_repeat":
+		return gl.MIRRORED_REPEAT
+	default:
+		return gl.CLAMP_TO_BORDER
+	}
+}
+
+// renderChain draws the frame of the core through the shader chain, in the
+// rectangle x, y, w, h of the window
+func (video *Video) renderChain(x, y, w, h float32, fbw, fbh int) {
+	c := video.chain
+	vpW, vpH := int32(w+0.5), int32(h+0.5)
+
+	// Copy the frame upright, rotated and cropped, to the orig target
+	vp := CurrentViewport()
+	u0, v0, u1, v1 := overscanUV(video.Geom, vp)
+	ow := int32(float32(video.width)*(u1-u0) + 0.5)
+	oh := int32(float32(video.height)*(v1-v0) + 0.5)
+	if video.rot%2 == 1 {
+		ow, oh = oh, ow
+	}
+	c.orig.resize(ow, oh, gl.RGBA8)
+	va := make([]float32, len(vertices))
+	copy(va, vertices)
+	va = rotateUV(va, video.rot)
+	va = cropUVs(va, video.Geom, vp)
+	va = video.hwUV(va)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, c.orig.fbo)
+	gl.Viewport(0, 0, ow, oh)
+	gl.UseProgram(video.defaultProgram)
+	gl.Uniform4f(gl.GetUniformLocation(video.defaultProgram, gl.Str("color\x00")), 1, 1, 1, 1)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, video.currentTexture())
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	bindVertexArray(video.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(va)*4, gl.Ptr(va), gl.STATIC_DRAW)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+	values := savedShaderParameters()
+	input := c.orig
+	for i, pass := range c.passes {
+		// Sampling of the input
+		linear := pass.filter == "linear" || (pass.filter == "" && video.filter == "Smooth")
+		setSampling(input.tex, linear, pass.mipmapInput, pass.wrapMode)
+
+		if pass.out != nil {
+			format := int32(gl.RGBA8)
+			if pass.floatFBO {
+				format = gl.RGBA32F_ARB
+			} else if pass.srgbFBO {
+				format = gl.SRGB8_ALPHA8
+			}
+			pw, ph := passSize(pass.shaderPass, input.width, input.height, vpW, vpH)
+			pass.out.resize(pw, ph, format)
+			gl.BindFramebuffer(gl.FRAMEBUFFER, pass.out.fbo)
+			gl.Viewport(0, 0, pw, ph)
+		} else {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+			gl.Viewport(int32(x), int32(fbh)-int32(y)-vpH, vpW, vpH)
+		}
+		if pass.srgbFBO {
+			gl.Enable(gl.FRAMEBUFFER_SRGB)
+		}
+
+		gl.UseProgram(pass.program)
+		outW, outH := vpW, vpH
+		if pass.out != nil {
+			outW, outH = pass.out.width, pass.out.height
+		}
+		gl.Uniform2f(pass.uniform("OutputSize"), float32(outW), float32(outH))
+		gl.UniformMatrix4fv(pass.uniform("MVPMatrix"), 1, false, &mvpMatrix[0])
+		frameCount := c.frameCount
+		if pass.frameCountMod > 0 {
+			frameCount %= pass.frameCountMod
+		}
+		gl.Uniform1i(pass.uniform("FrameCount"), int32(frameCount))
+		direction := int32(1)
+		if state.Rewinding {
+			direction = -1
+		}
+		gl.Uniform1i(pass.uniform("FrameDirection"), direction)
+		for _, param := range c.params {
+			gl.Uniform1f(pass.uniform(param.ID), parameterValue(values, param))
+		}
+
+		unit := int32(0)
+		pass.bindTarget(&unit, "", input)
+		pass.bindTarget(&unit, "Orig", c.orig)
+		for j, t := range c.history {
+			name := "Prev"
+			if j > 0 {
+				name += strconv.Itoa(j)
+			}
+			pass.bindTarget(&unit, name, t)
+		}
+		for j := 0; j < i; j++ {
+			prev := c.passes[j]
+			pass.bindTarget(&unit, fmt.Sprintf("Pass%d", j+1), prev.out)
+			pass.bindTarget(&unit, fmt.Sprintf("PassPrev%d", i-j), prev.out)
+			if prev.alias != "" {
+				pass.bindTarget(&unit, prev.alias, prev.out)
+			}
+		}
+		for j, p := range c.passes {
+			pass.bindTarget(&unit, fmt.Sprintf("PassFeedback%d", j), p.prev)
+			if p.alias != "" {
+				pass.bindTarget(&unit, p.alias+"Feedback", p.prev)
+			}
+		}
+		for _, l := range c.luts {
+			if loc := pass.uniform(l.name); loc >= 0 {
+				gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+				gl.BindTexture(gl.TEXTURE_2D, l.tex)
+				gl.Uniform1i(loc, unit)
+				unit++
+			}
+		}
+		gl.ActiveTexture(gl.TEXTURE0)
+
+		bindVertexArray(pass.vao)
+		if pass.color >= 0 {
+			gl.VertexAttrib4f(uint32(pass.color), 1, 1, 1, 1)
+		}
+		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+		gl.Disable(gl.FRAMEBUFFER_SRGB)
+
+		if pass.out != nil {
+			input = pass.out
+		}
+	}
+
+	// The last pass has its own scale, stretch its output to the viewport
+	if last := c.passes[len(c.passes)-1]; last.out != nil {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.Viewport(int32(x), int32(fbh)-int32(y)-vpH, vpW, vpH)
+		gl.UseProgram(video.defaultProgram)
+		setSampling(last.out.tex, video.filter == "Smooth", false, "clamp_to_edge")
+		bindVertexArray(video.vao)
+		gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
+		gl.BufferData(gl.ARRAY_BUFFER, len(blitVertices)*4, gl.Ptr(blitVertices), gl.STATIC_DRAW)
+		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	}
+
+	gl.Viewport(0, 0, int32(fbw), int32(fbh))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.UseProgram(0)
+
+	// Keep the frame and the outputs for the next one
+	if n := len(c.history); n > 0 {
+		oldest := c.history[n-1]
+		copy(c.history[1:], c.history[:n-1])
+		c.history[0] = c.orig
+		c.orig = oldest
+	}
+	for _, pass := range c.passes {
+		if pass.prev != nil {
+			pass.out, pass.prev = pass.prev, pass.out
+		}
+	}
+	c.frameCount++
+}
@@ -0,0 +1,494 @@
+package video
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// Software is a renderer rasterizing the game and the menu in an image with
+// the CPU. It needs neither a GPU nor a window, so the menu and the games can
+// be rendered and snapshot-tested anywhere. The shaders of the filters are not
+// emulated, only their nearest or bilinear sampling.
+type Software struct {
+	Geom libretro.GameGeometry
+
+	canvas *image.RGBA
+	clip   image.Rectangle // scissor box, in canvas coordinates
+	font   *Font
+	images []*image.NRGBA // images created by NewImage, the id is the index + 1
+	smooth bool           // bilinear sampling of the game frame
+
+	format        uint32 // libretro pixel format set by the environment callback
+	pitch         int32  // pitch set by the refresh callback
+	width, height int32  // dimensions set by the refresh callback
+	rot           uint   // rotation index
+	frame         []byte // copy of the last frame
+
+	overlays overlayLayer // bezel around the game
+}
+
+// NewSoftware creates a software renderer drawing in a canvas of the given
+// size. It uses the font of the assets, or the Go font if it can't be read.
+func NewSoftware(width, height int) *Software {
+	sw := &Software{
+		canvas: image.NewRGBA(image.Rect(0, 0, width, height)),
+		format: libretro.PixelFormat0RGB1555,
+	}
+	sw.clip = sw.canvas.Bounds()
+
+	data, err := os.ReadFile(filepath.Join(settings.Current.AssetsDirectory, "font.ttf"))
+	if err != nil {
+		data = goregular.TTF
+	}
+	sw.font, err = newAtlas(bytes.NewReader(data), int32(36*2), 32, 256)
+	if err != nil {
+		panic(err)
+	}
+	sw.font.sw = sw
+
+	sw.UpdateFilter(settings.Current.VideoFilter)
+	return sw
+}
+
+// Canvas returns the image the renderer draws in
+func (sw *Software) Canvas() *image.RGBA {
+	return sw.canvas
+}
+
+// SaveCanvas writes the image drawn by the last Render to a PNG file
+func (sw *Software) SaveCanvas(path string) error {
+	return writePNG(sw.canvas, path)
+}
+
+// Refresh copies the frame of the core. A nil frame is a dupe of the last one,
+// which is kept.
+func (sw *Software) Refresh(data unsafe.Pointer, width int32, height int32, pitch int32) {
+	if state.SkipVideo || data == nil {
+		return
+	}
+	sw.width = width
+	sw.height = height
+	sw.pitch = pitch
+	if pitch*height <= 0 {
+		sw.frame = sw.frame[:0]
+		return
+	}
+	sw.frame = append(sw.frame[:0], unsafe.Slice((*byte)(data), pitch*height)...)
+}
+
+// SetPixelFormat sets the pixel format of the frames of the core
+func (sw *Software) SetPixelFormat(format uint32) bool {
+	switch format {
+	case libretro.PixelFormat0RGB1555, libretro.PixelFormatXRGB8888, libretro.PixelFormatRGB565:
+		sw.format = format
+		return true
+	default:
+		log.Printf("Unknown pixel type %v", format)
+	}
+	return false
+}
+
+// SetRotation rotates the game image as requested by the core
+func (sw *Software) SetRotation(rot uint) bool {
+	sw.rot = rot % 4
+	return true
+}
+
+// SetGeometry sets the geometry of the game, as reported by the core
+func (sw *Software) SetGeometry(geom libretro.GameGeometry) {
+	sw.Geom = geom
+}
+
+// ResetPitch should be called when unloading a game so that the next game won't
+// be rendered with the wrong pitch
+func (sw *Software) ResetPitch() {
+	sw.pitch = 0
+}
+
+// ResetRot should be called when unloading a game so that the next game won't
+// be rendered with the wrong rotation
+func (sw *Software) ResetRot() {
+	sw.rot = 0
+}
+
+// GetFramebufferSize returns the size of the canvas
+func (sw *Software) GetFramebufferSize() (int, int) {
+	return sw.canvas.Rect.Dx(), sw.canvas.Rect.Dy()
+}
+
+// SetTitle does nothing, there is no window
+func (sw *Software) SetTitle(title string) {}
+
+// SetShouldClose does nothing, there is no window
+func (sw *Software) SetShouldClose(b bool) {}
+
+// Reconfigure does nothing, there is no window
+func (sw *Software) Reconfigure(fullscreen bool) {}
+
+// UpdateFilter picks the sampling of the game frame for a filter
+func (sw *Software) UpdateFilter(filter string) {
+	switch filter {
+	case "Smooth", "Pixel Perfect", "CRT", "LCD":
+		sw.smooth = true
+	default:
+		sw.smooth = false
+	}
+}
+
+// LoadShader fails for presets, shaders need the OpenGL renderer
+func (sw *Software) LoadShader(path string) error {
+	if path == "" {
+		return nil
+	}
+	return errors.New("shaders need the OpenGL renderer")
+}
+
+// ShaderParameters returns nil, the filters have no shader
+func (sw *Software) ShaderParameters() []ShaderParameter {
+	return nil
+}
+
+// Frame returns the last frame of the core, or nil if there is none
+func (sw *Software) Frame() *image.RGBA {
+	if len(sw.frame) == 0 || sw.pitch == 0 {
+		return nil
+	}
+	return toRGBA(sw.frame, sw.width, sw.height, sw.pitch, sw.format)
+}
+
+// SaveFrame writes the last frame of the core to a PNG file
+func (sw *Software) SaveFrame(path string) error {
+	return saveFrame(sw.Frame(), path)
+}
+
+// TakeScreenshot writes the last frame of the core, at the base resolution of
+// the game, to a file of the screenshots directory
+func (sw *Software) TakeScreenshot(name string) error {
+	frame := sw.Frame()
+	if frame == nil {
+		return errors.New("no frame to capture")
+	}
+	var img image.Image = frame
+	bw, bh := sw.Geom.BaseWidth, sw.Geom.BaseHeight
+	if bw > 0 && bh > 0 && (bw != frame.Rect.Dx() || bh != frame.Rect.Dy()) {
+		filter := imaging.NearestNeighbor
+		if sw.smooth {
+			filter = imaging.Linear
+		}
+		img = imaging.Resize(frame, bw, bh, filter)
+	}
+	return saveScreenshot(img, name)
+}
+
+// Render clears the canvas and draws the current frame
+func (sw *Software) Render() {
+	sw.overlays.update(sw.NewImage, sw.deleteImage)
+	sw.clip = sw.canvas.Bounds()
+	if !state.CoreRunning {
+		sw.clear(Color{R: 1, G: 1, B: 1, A: 1})
+		return
+	}
+	sw.clear(Color{R: 0, G: 0, B: 0, A: 1})
+
+	// A sane pitch must be set by Refresh first
+	frame := sw.Frame()
+	if frame == nil {
+		return
+	}
+
+	fbw, fbh := sw.GetFramebufferSize()
+	ov := sw.overlays.overlay
+	if ov != nil && !ov.config.Over {
+		sw.drawOverlay(ov, fbw, fbh)
+	}
+
+	vp := CurrentViewport()
+	x, y, w, h := placeGame(sw.Geom, vp, ov, fbw, fbh)
+	u0, v0, u1, v1 := overscanUV(sw.Geom, vp)
+	tex := textureOf(frame.Pix, frame.Stride, frame.Rect)
+	rot := sw.rot
+	sw.fill(x, y, w, h, func(u, v float32) Color {
+		u, v = rotate(u, v, rot)
+		u, v = u0+u*(u1-u0), v0+v*(v1-v0)
+		return tex.sample(u, v, sw.smooth)
+	})
+
+	if ov != nil && ov.config.Over {
+		sw.drawOverlay(ov, fbw, fbh)
+	}
+}
+
+// drawOverlay draws the bezel around the game
+func (sw *Software) drawOverlay(ov *overlay, fbw, fbh int) {
+	x, y, w, h := ov.rect(fbw, fbh)
+	sw.DrawImage(ov.image, x, y, w, h, 1, 0, Color{R: 1, G: 1, B: 1, A: 1})
+}
+
+// DrawRect draws a rectangle and supports rounded corners
+func (sw *Software) DrawRect(x, y, w, h, r float32, c Color) {
+	ratio := w / h
+	hx, hy := 0.5*ratio, float32(0.5)
+	c = c.clamp()
+	sw.fill(x, y, w, h, func(u, v float32) Color {
+		b := udRoundBox(u*ratio-hx, v-hy, hx, hy, min32(hx, hy)*r)
+		return c.Alpha(c.A * (1 - smoothstep(0.00002, 0.0001, b)))
+	})
+}
+
+// DrawBorder draws a colored rectangle border
+func (sw *Software) DrawBorder(x, y, w, h, borderWidth float32, c Color) {
+	ratio := w / h
+	minX, maxX := borderWidth/ratio, 1-borderWidth/ratio
+	minY, maxY := borderWidth, 1-borderWidth
+	sw.fill(x, y, w, h, func(u, v float32) Color {
+		if u < maxX && u > minX && v < maxY && v > minY {
+			return Color{}
+		}
+		return c
+	})
+}
+
+// DrawCircle draws a circle
+func (sw *Software) DrawCircle(x, y, r float32, c Color) {
+	sw.fill(x-r, y-r, r*2, r*2, func(u, v float32) Color {
+		du, dv := u-0.5, v-0.5
+		d := (du*du + dv*dv) * 4
+		return c.Alpha(c.A * (1 - smoothstep(0.125*0.95, 0.125*1.05, d)))
+	})
+}
+
+// DrawImage draws an image with x, y, w, h. Unknown images are not drawn.
+func (sw *Software) DrawImage(image uint32, x, y, w, h, scale, r float32, c Color) {
+	if image == 0 || int(image) > len(sw.images) || sw.images[image-1] == nil {
+		return
+	}
+	img := sw.images[image-1]
+	tex := textureOf(img.Pix, img.Stride, img.Rect)
+	sw.fill(x, y, w*scale, h*scale, func(u, v float32) Color {
+		t := tex.sample(u, v, true)
+		return Color{R: t.R * c.R, G: t.G * c.G, B: t.B * c.B, A: t.A * c.A}
+	})
+}
+
+// ScissorStart restricts the drawing to a box, don't forget to call ScissorEnd.
+// Like in OpenGL, y starts from the bottom of the canvas.
+func (sw *Software) ScissorStart(x, y, w, h int32) {
+	fbh := int32(sw.canvas.Rect.Dy())
+	box := image.Rect(int(x), int(fbh-y-h), int(x+w), int(fbh-y))
+	sw.clip = box.Intersect(sw.canvas.Bounds())
+}
+
+// ScissorEnd closes a scissor box
+func (sw *Software) ScissorEnd() {
+	sw.clip = sw.canvas.Bounds()
+}
+
+// NewImage opens an image file, keeps it in memory and returns its id
+func (sw *Software) NewImage(file string) uint32 {
+	nrgba := loadImage(file)
+	if nrgba == nil {
+		return 0
+	}
+	sw.images = append(sw.images, nrgba)
+	return uint32(len(sw.images))
+}
+
+// deleteImage frees an image created by NewImage, its id is not reused
+func (sw *Software) deleteImage(image uint32) {
+	if image > 0 && int(image) <= len(sw.images) {
+		sw.images[image-1] = nil
+	}
+}
+
+// Font returns the font used to print text
+func (sw *Software) Font() *Font {
+	return sw.font
+}
+
+// drawGlyphs draws the quads computed by Font.Print, two triangles per glyph
+func (sw *Software) drawGlyphs(f *Font, coords []point) {
+	tex := textureOf(f.atlas.Pix, f.atlas.Stride, f.atlas.Rect)
+	c := f.color.clamp()
+	for i := 0; i+5 < len(coords); i += 6 {
+		p1, p2 := coords[i], coords[i+5]
+		sw.fill(p1[0], p1[1], p2[0]-p1[0], p2[1]-p1[1], func(u, v float32) Color {
+			t := tex.sample(p1[2]+(p2[2]-p1[2])*u, p1[3]+(p2[3]-p1[3])*v, true)
+			return c.Alpha(c.A * t.R)
+		})
+	}
+}
+
+// clear fills the whole canvas with an opaque color
+func (sw *Software) clear(c Color) {
+	r, g, b := to8(c.R), to8(c.G), to8(c.B)
+	pix := sw.canvas.Pix
+	for i := 0; i+3 < len(pix); i += 4 {
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = r, g, b, 0xFF
+	}
+}
+
+// fill blends the pixels whose center is inside a rectangle, like the
+// rasterization of a quad by OpenGL. The shade function gets the coordinates of
+// the pixel in the rectangle, from 0 to 1, and returns a non premultiplied
+// color.
+func (sw *Software) fill(x, y, w, h float32, shade func(u, v float32) Color) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	x0 := int(math.Ceil(float64(x - 0.5)))
+	y0 := int(math.Ceil(float64(y - 0.5)))
+	x1 := int(math.Ceil(float64(x + w - 0.5)))
+	y1 := int(math.Ceil(float64(y + h - 0.5)))
+	box := image.Rect(x0, y0, x1, y1).Intersect(sw.clip)
+	for py := box.Min.Y; py < box.Max.Y; py++ {
+		v := (float32(py) + 0.5 - y) / h
+		for px := box.Min.X; px < box.Max.X; px++ {
+			u := (float32(px) + 0.5 - x) / w
+			sw.blend(sw.canvas.PixOffset(px, py), shade(u, v))
+		}
+	}
+}
+
+// blend composites a color over a pixel of the canvas, like
+// glBlendFunc(GL_SRC_ALPHA, GL_ONE_MINUS_SRC_ALPHA)
+func (sw *Software) blend(i int, c Color) {
+	a := clamp01(c.A)
+	if a == 0 {
+		return
+	}
+	pix := sw.canvas.Pix
+	mix := func(src float32, dst uint8) uint8 {
+		return to8(clamp01(src)*a + float32(dst)/255*(1-a))
+	}
+	pix[i] = mix(c.R, pix[i])
+	pix[i+1] = mix(c.G, pix[i+1])
+	pix[i+2] = mix(c.B, pix[i+2])
+	pix[i+3] = to8(a + float32(pix[i+3])/255*(1-a))
+}
+
+// texture samples an RGBA or NRGBA image with clamp to edge wrapping
+type texture struct {
+	pix    []uint8
+	stride int
+	rect   image.Rectangle
+}
+
+func textureOf(pix []uint8, stride int, rect image.Rectangle) texture {
+	return texture{pix: pix, stride: stride, rect: rect}
+}
+
+func (t texture) texel(x, y int) Color {
+	w, h := t.rect.Dx(), t.rect.Dy()
+	x = clampInt(x, 0, w-1)
+	y = clampInt(y, 0, h-1)
+	i := y*t.stride + x*4
+	return Color{
+		R: float32(t.pix[i]) / 255,
+		G: float32(t.pix[i+1]) / 255,
+		B: float32(t.pix[i+2]) / 255,
+		A: float32(t.pix[i+3]) / 255,
+	}
+}
+
+// sample returns the color at u, v, from 0 to 1, with nearest or bilinear
+// filtering
+func (t texture) sample(u, v float32, linear bool) Color {
+	w, h := t.rect.Dx(), t.rect.Dy()
+	if w == 0 || h == 0 {
+		return Color{}
+	}
+	if !linear {
+		return t.texel(int(u*float32(w)), int(v*float32(h)))
+	}
+	tx := u*float32(w) - 0.5
+	ty := v*float32(h) - 0.5
+	x0 := int(math.Floor(float64(tx)))
+	y0 := int(math.Floor(float64(ty)))
+	fx := tx - float32(x0)
+	fy := ty - float32(y0)
+	c00, c10 := t.texel(x0, y0), t.texel(x0+1, y0)
+	c01, c11 := t.texel(x0, y0+1), t.texel(x0+1, y0+1)
+	lerp := func(a, b, c, d float32) float32 {
+		return (a*(1-fx)+b*fx)*(1-fy) + (c*(1-fx)+d*fx)*fy
+	}
+	return Color{
+		R: lerp(c00.R, c10.R, c01.R, c11.R),
+		G: lerp(c00.G, c10.G, c01.G, c11.G),
+		B: lerp(c00.B, c10.B, c01.B, c11.B),
+		A: lerp(c00.A, c10.A, c01.A, c11.A),
+	}
+}
+
+// rotate maps a point of the screen to the texture, as rotateUV does for the
+// vertices
+func rotate(u, v float32, rot uint) (float32, float32) {
+	switch rot {
+	case 1: // 90 degrees
+		return 1 - v, u
+	case 2: // 180 degrees
+		return 1 - u, 1 - v
+	case 3: // 270 degrees
+		return v, 1 - u
+	}
+	return u, v
+}
+
+// udRoundBox is the distance from a point to a rounded box of half size bx, by
+func udRoundBox(px, py, bx, by, r float32) float32 {
+	dx := max(float32(math.Abs(float64(px)))-bx+r, 0)
+	dy := max(float32(math.Abs(float64(py)))-by+r, 0)
+	return float32(math.Hypot(float64(dx), float64(dy))) - r
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := clamp01((x - edge0) / (edge1 - edge0))
+	return t * t * (3 - 2*t)
+}
+
+func (c Color) clamp() Color {
+	return Color{R: clamp01(c.R), G: clamp01(c.G), B: clamp01(c.B), A: clamp01(c.A)}
+}
+
+func clamp01(f float32) float32 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
+
+func clampInt(i, lo, hi int) int {
+	if i < lo {
+		return lo
+	}
+	if i > hi {
+		return hi
+	}
+	return i
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func to8(f float32) uint8 {
+	return uint8(clamp01(f)*255 + 0.5)
+}
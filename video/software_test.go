@@ -0,0 +1,249 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+var (
+	white = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	black = color.RGBA{0x00, 0x00, 0x00, 0xFF}
+	red   = color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+)
+
+func Test_Software_DrawRect(t *testing.T) {
+	state.CoreRunning = false
+	sw := NewSoftware(8, 8)
+	sw.Render()
+
+	t.Run("Clears the canvas in white without a game", func(t *testing.T) {
+		if got := sw.Canvas().RGBAAt(0, 0); got != white {
+			t.Errorf("RGBAAt() = %v, want %v", got, white)
+		}
+	})
+
+	sw.DrawRect(2, 2, 4, 4, 0, Color{R: 1, G: 0, B: 0, A: 1})
+
+	t.Run("Fills the pixels of the rectangle", func(t *testing.T) {
+		for _, p := range []image.Point{{2, 2}, {5, 5}} {
+			if got := sw.Canvas().RGBAAt(p.X, p.Y); got != red {
+				t.Errorf("RGBAAt(%v) = %v, want %v", p, got, red)
+			}
+		}
+	})
+
+	t.Run("Leaves the pixels outside of the rectangle", func(t *testing.T) {
+		for _, p := range []image.Point{{1, 1}, {6, 6}} {
+			if got := sw.Canvas().RGBAAt(p.X, p.Y); got != white {
+				t.Errorf("RGBAAt(%v) = %v, want %v", p, got, white)
+			}
+		}
+	})
+
+	sw.DrawRect(0, 0, 8, 8, 0, Color{R: 0, G: 0, B: 0, A: 0.5})
+
+	t.Run("Blends translucent colors", func(t *testing.T) {
+		want := color.RGBA{0x80, 0x80, 0x80, 0xFF}
+		if got := sw.Canvas().RGBAAt(0, 0); got != want {
+			t.Errorf("RGBAAt() = %v, want %v", got, want)
+		}
+	})
+
+	sw.Render()
+	sw.DrawRect(0, 0, 8, 8, 1, Color{R: 1, G: 0, B: 0, A: 1})
+
+	t.Run("Rounds the corners", func(t *testing.T) {
+		if got := sw.Canvas().RGBAAt(0, 0); got != white {
+			t.Errorf("RGBAAt() = %v, want %v", got, white)
+		}
+		if got := sw.Canvas().RGBAAt(4, 4); got != red {
+			t.Errorf("RGBAAt() = %v, want %v", got, red)
+		}
+	})
+}
+
+func Test_Software_Scissor(t *testing.T) {
+	state.CoreRunning = false
+	sw := NewSoftware(8, 8)
+	sw.Render()
+
+	// The scissor box starts from the bottom, like in OpenGL
+	sw.ScissorStart(0, 0, 8, 2)
+	sw.DrawRect(0, 0, 8, 8, 0, Color{R: 1, G: 0, B: 0, A: 1})
+	sw.ScissorEnd()
+
+	t.Run("Clips the drawing to the box", func(t *testing.T) {
+		if got := sw.Canvas().RGBAAt(0, 5); got != white {
+			t.Errorf("RGBAAt() = %v, want %v", got, white)
+		}
+		if got := sw.Canvas().RGBAAt(0, 6); got != red {
+			t.Errorf("RGBAAt() = %v, want %v", got, red)
+		}
+	})
+}
+
+func Test_Software_Render(t *testing.T) {
+	state.CoreRunning = true
+	defer func() { state.CoreRunning = false }()
+
+	// A 2x2 frame with a different color in each corner
+	frame := []byte{
+		0x00, 0x00, 0xFF, 0x00, 0x00, 0xFF, 0x00, 0x00,
+		0xFF, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0x00,
+	}
+	topLeft := red
+	topRight := color.RGBA{0x00, 0xFF, 0x00, 0xFF}
+	bottomLeft := color.RGBA{0x00, 0x00, 0xFF, 0xFF}
+	bottomRight := white
+
+	sw := NewSoftware(8, 4)
+	sw.UpdateFilter("Raw")
+	sw.SetPixelFormat(libretro.PixelFormatXRGB8888)
+	sw.SetGeometry(libretro.GameGeometry{BaseWidth: 2, BaseHeight: 2, AspectRatio: 1})
+	sw.Refresh(unsafe.Pointer(&frame[0]), 2, 2, 8)
+
+	tests := []struct {
+		name string
+		rot  uint
+		want [4]color.RGBA
+	}{
+		{
+			name: "Draws the frame in the middle",
+			want: [4]color.RGBA{topLeft, topRight, bottomLeft, bottomRight},
+		},
+		{
+			name: "Rotates the frame by 90 degrees",
+			rot:  1,
+			want: [4]color.RGBA{topRight, bottomRight, topLeft, bottomLeft},
+		},
+		{
+			name: "Rotates the frame by 180 degrees",
+			rot:  2,
+			want: [4]color.RGBA{bottomRight, bottomLeft, topRight, topLeft},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw.SetRotation(tt.rot)
+			sw.Render()
+			c := sw.Canvas()
+			got := [4]color.RGBA{c.RGBAAt(2, 0), c.RGBAAt(5, 0), c.RGBAAt(2, 3), c.RGBAAt(5, 3)}
+			if got != tt.want {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+			if got := c.RGBAAt(1, 1); got != black {
+				t.Errorf("Render() border = %v, want %v", got, black)
+			}
+		})
+	}
+
+	t.Run("Takes screenshots at the base resolution", func(t *testing.T) {
+		settings.Current.ScreenshotsDirectory = t.TempDir()
+		sw.SetGeometry(libretro.GameGeometry{BaseWidth: 4, BaseHeight: 4, AspectRatio: 1})
+		if err := sw.TakeScreenshot("shot"); err != nil {
+			t.Fatalf("TakeScreenshot() = %v, want nil", err)
+		}
+		fd, err := os.Open(filepath.Join(settings.Current.ScreenshotsDirectory, "shot.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		img, err := png.Decode(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.Bounds().Size(); got != image.Pt(4, 4) {
+			t.Errorf("TakeScreenshot() size = %v, want %v", got, image.Pt(4, 4))
+		}
+	})
+	t.Run("Keeps the last frame on dupes", func(t *testing.T) {
+		want := sw.Frame()
+		sw.Refresh(nil, 0, 0, 0)
+		got := sw.Frame()
+		if got == nil {
+			t.Fatalf("Frame() = nil, want the last frame")
+		}
+		if !reflect.DeepEqual(got.Pix, want.Pix) || got.Bounds() != want.Bounds() {
+			t.Errorf("Frame() = %v, want %v", got.Pix, want.Pix)
+		}
+	})
+}
+
+func Test_Software_DrawImage(t *testing.T) {
+	state.CoreRunning = false
+	sw := NewSoftware(8, 8)
+	sw.Render()
+
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := range src.Pix {
+		src.Pix[i] = 0xFF
+	}
+	path := filepath.Join(t.TempDir(), "image.png")
+	if err := writePNG(src, path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Returns 0 for missing files", func(t *testing.T) {
+		if got := sw.NewImage(filepath.Join(t.TempDir(), "missing.png")); got != 0 {
+			t.Errorf("NewImage() = %v, want 0", got)
+		}
+	})
+
+	id := sw.NewImage(path)
+	sw.DrawImage(id, 0, 0, 2, 2, 2, 0, Color{R: 1, G: 0, B: 0, A: 1})
+
+	t.Run("Draws the scaled and tinted image", func(t *testing.T) {
+		if got := sw.Canvas().RGBAAt(3, 3); got != red {
+			t.Errorf("RGBAAt() = %v, want %v", got, red)
+		}
+		if got := sw.Canvas().RGBAAt(4, 4); got != white {
+			t.Errorf("RGBAAt() = %v, want %v", got, white)
+		}
+	})
+}
+
+func Test_Software_Print(t *testing.T) {
+	state.CoreRunning = false
+	sw := NewSoftware(200, 60)
+	sw.Render()
+
+	sw.Font().SetColor(Color{R: 0, G: 0, B: 0, A: 1})
+	sw.Font().Print(10, 40, 0.5, "Ludo")
+
+	t.Run("Prints the text in the canvas", func(t *testing.T) {
+		dark := 0
+		c := sw.Canvas()
+		for y := 0; y < 60; y++ {
+			for x := 0; x < 200; x++ {
+				if c.RGBAAt(x, y).R < 0x80 {
+					dark++
+				}
+			}
+		}
+		if dark == 0 {
+			t.Errorf("Print() drew no pixels")
+		}
+	})
+
+	t.Run("Doesn't print outside of the text box", func(t *testing.T) {
+		w := int(sw.Font().Width(0.5, "Ludo"))
+		c := sw.Canvas()
+		for y := 0; y < 60; y++ {
+			for x := 10 + w + 4; x < 200; x++ {
+				if got := c.RGBAAt(x, y); got != white {
+					t.Fatalf("RGBAAt(%d, %d) = %v, want %v", x, y, got, white)
+				}
+			}
+		}
+	})
+}
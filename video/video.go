@@ -19,7 +19,8 @@ import (
 type Video struct {
 	Window *glfw.Window
 	Geom   libretro.GameGeometry
-	Font   *Font
+
+	font *Font
 
 	program              uint32 // current program used for the game quad
 	defaultProgram       uint32 // default program used for the game quad
@@ -33,15 +34,24 @@ type Video struct {
 	vbo                  uint32 // vertex buffer object
 	texID                uint32 // texture id
 
-	pitch         int32  // pitch set by the refresh callback
-	pixFmt        uint32 // format set by the environment callback
-	pixType       uint32 // pixel type for the core framebuffer
-	bpp           int32  // bit per pixel for the core framebuffer
-	width, height int32  // dimensions set by the refresh callback
-	rot           uint   // rotation index
+	pitch         int32             // pitch set by the refresh callback
+	format        uint32            // libretro pixel format set by the environment callback
+	pixFmt        uint32            // format set by the environment callback
+	pixType       uint32            // pixel type for the core framebuffer
+	bpp           int32             // bit per pixel for the core framebuffer
+	width, height int32             // dimensions set by the refresh callback
+	rot           uint              // rotation index
+	filter        string            // filter set by UpdateFilter
+	params        []ShaderParameter // parameters of the shader of the filter
 
 	needUpload bool // true when the texture needs to be uploaded to the GPU
 	data       unsafe.Pointer
+	frame      []byte // copy of the last frame, as the core can reuse its buffer before the upload
+
+	hw    *hwRender    // set when the core renders with OpenGL
+	chain *shaderChain // set when a shader preset replaces the filter
+
+	overlays overlayLayer // bezel around the game
 }
 
 // Init instanciates the video package
@@ -56,6 +66,9 @@ func (video *Video) Reconfigure(fullscreen bool) {
 	if video.Window != nil {
 		video.Window.Destroy()
 	}
+	// The objects of the shader chain and the overlay are gone with the context
+	video.chain = nil
+	video.overlays = overlayLayer{}
 	video.Configure(fullscreen)
 }
 
@@ -106,8 +119,14 @@ func (video *Video) Configure(fullscreen bool) {
 		height = 240 * 2
 	}
 
+	// The new window must share the objects of the hardware context, if any
+	var share *glfw.Window
+	if video.hw != nil {
+		share = video.hw.window
+	}
+
 	var err error
-	video.Window, err = glfw.CreateWindow(width, height, "Ludo", m, nil)
+	video.Window, err = glfw.CreateWindow(width, height, "Ludo", m, share)
 	if err != nil {
 		panic("Window creation failed:" + err.Error())
 	}
@@ -128,7 +147,7 @@ func (video *Video) Configure(fullscreen bool) {
 
 	// LoadFont (fontfile, font scale, window width, window height)
 	fontPath := filepath.Join(settings.Current.AssetsDirectory, "font.ttf")
-	video.Font, err = LoadFont(fontPath, int32(36*2), fbw, fbh)
+	video.font, err = LoadFont(fontPath, int32(36*2), fbw, fbh)
 	if err != nil {
 		panic(err)
 	}
@@ -183,6 +202,10 @@ func (video *Video) Configure(fullscreen bool) {
 
 	video.coreRatioViewport(fbw, fbh)
 
+	if err := video.LoadShader(ShaderPresetPath(settings.Current.VideoShader)); err != nil {
+		log.Println("[Video]:", err)
+	}
+
 	if e := gl.GetError(); e != gl.NO_ERROR {
 		log.Printf("[Video] OpenGL error: %d\n", e)
 	}
@@ -196,7 +219,8 @@ func (video *Video) Configure(fullscreen bool) {
 // CRT: zfast-crt
 // LCD: zfast-lcd
 func (video *Video) UpdateFilter(filter string) {
-	gl.BindTexture(gl.TEXTURE_2D, video.texID)
+	video.filter = filter
+	gl.BindTexture(gl.TEXTURE_2D, video.currentTexture())
 	switch filter {
 	case "Smooth":
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
@@ -223,11 +247,31 @@ func (video *Video) UpdateFilter(filter string) {
 	}
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	video.params = parseParameters(builtinShaders[filter])
 	gl.UseProgram(video.program)
 	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("TextureSize\x00")), float32(video.width), float32(video.height))
 	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("InputSize\x00")), float32(video.width), float32(video.height))
 }
 
+// LoadShader replaces the filter of the game by the shader preset at path. An
+// empty path goes back to the filter.
+func (video *Video) LoadShader(path string) error {
+	if video.chain != nil {
+		video.chain.delete()
+		video.chain = nil
+	}
+	if path == "" {
+		video.UpdateFilter(video.filter)
+		return nil
+	}
+	chain, err := newShaderChain(path)
+	if err != nil {
+		return err
+	}
+	video.chain = chain
+	return nil
+}
+
 // SetPixelFormat is a callback passed to the libretro implementation.
 // It allows the core or the game to tell us which pixel format should be used for the display.
 func (video *Video) SetPixelFormat(format uint32) bool {
@@ -238,6 +282,7 @@ func (video *Video) SetPixelFormat(format uint32) bool {
 	// PixelStorei also needs to be updated whenever bpp changes
 	defer func() { video.needUpload = true }()
 
+	video.format = format
 	switch format {
 	case libretro.PixelFormat0RGB1555:
 		video.pixFmt = gl.UNSIGNED_SHORT_5_5_5_1
@@ -273,32 +318,26 @@ func (video *Video) ResetRot() {
 	video.rot = 0
 }
 
-// coreRatioViewport configures the vertex array to display the game at the center of the window
-// while preserving the original ascpect ratio of the game or core
-func (video *Video) coreRatioViewport(fbWidth int, fbHeight int) (x, y, w, h float32) {
-	// Scale the content to fit in the viewport.
-	fbw := float32(fbWidth)
-	fbh := float32(fbHeight)
-
-	// NXEngine workaround
-	aspectRatio := float32(video.Geom.AspectRatio)
-	if aspectRatio == 0 {
-		aspectRatio = float32(video.Geom.BaseWidth) / float32(video.Geom.BaseHeight)
-	}
+// Font returns the font used to print text
+func (video *Video) Font() *Font {
+	return video.font
+}
 
-	h = fbh
-	w = fbh * aspectRatio
-	if w > fbw {
-		h = fbw / aspectRatio
-		w = fbw
-	}
+// SetGeometry sets the geometry of the game, as reported by the core
+func (video *Video) SetGeometry(geom libretro.GameGeometry) {
+	video.Geom = geom
+}
 
-	// Place the content in the middle of the window.
-	x = (fbw - w) / 2
-	y = (fbh - h) / 2
+// coreRatioViewport configures the vertex array to display the game in the
+// window, at the aspect ratio and the scale of the viewport settings
+func (video *Video) coreRatioViewport(fbWidth int, fbHeight int) (x, y, w, h float32) {
+	vp := CurrentViewport()
+	x, y, w, h = placeGame(video.Geom, vp, video.overlays.overlay, fbWidth, fbHeight)
 
 	va := video.vertexArray(x, y, w, h, 1.0)
 	va = rotateUV(va, video.rot)
+	va = cropUVs(va, video.Geom, vp)
+	va = video.hwUV(va)
 	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
 	gl.BufferData(gl.ARRAY_BUFFER, len(va)*4, gl.Ptr(va), gl.STATIC_DRAW)
 
@@ -313,6 +352,7 @@ func (video *Video) ResizeViewport() {
 
 // Render the current frame
 func (video *Video) Render() {
+	video.overlays.update(video.NewImage, video.deleteImage)
 	if !state.CoreRunning {
 		gl.ClearColor(1, 1, 1, 1)
 		gl.Clear(gl.COLOR_BUFFER_BIT)
@@ -321,35 +361,76 @@ func (video *Video) Render() {
 	gl.ClearColor(0, 0, 0, 1)
 	gl.Clear(gl.COLOR_BUFFER_BIT)
 
-	// Early return to not render the first frame of a newly loaded game with the
-	// previous game pitch. A sane pitch must be set by video.Refresh first.
-	if video.pitch == 0 {
-		return
-	}
+	if video.hw != nil {
+		// Nothing to present before the first frame of the core
+		if !video.hw.ready {
+			return
+		}
+		video.presentHW()
+	} else {
+		// Early return to not render the first frame of a newly loaded game with the
+		// previous game pitch. A sane pitch must be set by video.Refresh first.
+		if video.pitch == 0 {
+			return
+		}
 
-	video.uploadTexture()
+		video.uploadTexture()
+	}
 
 	fbw, fbh := video.Window.GetFramebufferSize()
-	_, _, w, h := video.coreRatioViewport(fbw, fbh)
+	ov := video.overlays.overlay
+	if ov != nil && !ov.config.Over {
+		video.drawOverlay(ov, fbw, fbh)
+	}
 
-	gl.UseProgram(video.program)
-	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("OutputSize\x00")), w, h)
+	x, y, w, h := video.coreRatioViewport(fbw, fbh)
 
-	bindVertexArray(video.vao)
+	if video.chain != nil {
+		video.renderChain(x, y, w, h, fbw, fbh)
+	} else {
+		gl.UseProgram(video.program)
+		gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("OutputSize\x00")), w, h)
+		video.applyParameters()
 
-	gl.BindTexture(gl.TEXTURE_2D, video.texID)
-	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
+		bindVertexArray(video.vao)
 
-	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+		gl.BindTexture(gl.TEXTURE_2D, video.currentTexture())
+		gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
+
+		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	}
+
+	if ov != nil && ov.config.Over {
+		video.drawOverlay(ov, fbw, fbh)
+	}
 }
 
-// Refresh the texture framebuffer
+// drawOverlay draws the bezel around the game
+func (video *Video) drawOverlay(ov *overlay, fbw, fbh int) {
+	x, y, w, h := ov.rect(fbw, fbh)
+	video.DrawImage(ov.image, x, y, w, h, 1, 0, Color{R: 1, G: 1, B: 1, A: 1})
+}
+
+// Refresh the texture framebuffer. A nil frame is a dupe of the last one,
+// which is kept.
 func (video *Video) Refresh(data unsafe.Pointer, width int32, height int32, pitch int32) {
+	if state.SkipVideo || data == nil {
+		return
+	}
+	if libretro.IsHWFrame(data) {
+		video.refreshHW(width, height)
+		return
+	}
 	video.needUpload = true
 	video.width = width
 	video.height = height
 	video.pitch = pitch
-	video.data = data // maybe need a full copy
+	if pitch*height <= 0 {
+		video.data = nil
+		return
+	}
+	video.frame = append(video.frame[:0], unsafe.Slice((*byte)(data), pitch*height)...)
+	video.data = unsafe.Pointer(&video.frame[0])
 }
 
 func (video *Video) uploadTexture() {
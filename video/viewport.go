@@ -0,0 +1,164 @@
+package video
+
+import (
+	"math"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// AspectRatios lists the aspect ratios of the viewport settings
+var AspectRatios = []string{"Core", "4:3", "16:9", "1:1 PAR", "Custom"}
+
+// CurrentViewport returns the viewport settings of the current core, or the
+// global ones if the core has none
+func CurrentViewport() settings.Viewport {
+	if vp, ok := settings.Current.CoreViewports[utils.FileName(state.CorePath)]; ok {
+		return vp
+	}
+	return settings.Current.Viewport
+}
+
+// CoreViewport returns true if the current core has its own viewport settings
+func CoreViewport() bool {
+	_, ok := settings.Current.CoreViewports[utils.FileName(state.CorePath)]
+	return ok
+}
+
+// SetCoreViewport gives the current core its own viewport settings, starting
+// from the global ones, or goes back to the global settings
+func SetCoreViewport(enable bool) {
+	name := utils.FileName(state.CorePath)
+	if !enable {
+		delete(settings.Current.CoreViewports, name)
+		settings.Save()
+		return
+	}
+	if settings.Current.CoreViewports == nil {
+		settings.Current.CoreViewports = map[string]settings.Viewport{}
+	}
+	settings.Current.CoreViewports[name] = settings.Current.Viewport
+	settings.Save()
+}
+
+// UpdateViewport changes the viewport settings of the current core if it has
+// its own, or else the global ones
+func UpdateViewport(update func(vp *settings.Viewport)) {
+	vp := CurrentViewport()
+	update(&vp)
+	if CoreViewport() {
+		settings.Current.CoreViewports[utils.FileName(state.CorePath)] = vp
+	} else {
+		settings.Current.Viewport = vp
+	}
+	settings.Save()
+}
+
+// overscanUV returns the texture coordinates of the part of the frame left
+// once the overscan is cropped. Crops bigger than the frame are ignored.
+func overscanUV(geom libretro.GameGeometry, vp settings.Viewport) (u0, v0, u1, v1 float32) {
+	bw, bh := float32(geom.BaseWidth), float32(geom.BaseHeight)
+	if bw <= 0 || bh <= 0 {
+		return 0, 0, 1, 1
+	}
+	u0 = float32(vp.CropLeft) / bw
+	u1 = 1 - float32(vp.CropRight)/bw
+	v0 = float32(vp.CropTop) / bh
+	v1 = 1 - float32(vp.CropBottom)/bh
+	if u1 <= u0 || v1 <= v0 {
+		return 0, 0, 1, 1
+	}
+	return
+}
+
+// cropUVs restricts the texture coordinates of a vertex array to the part of
+// the frame left once the overscan is cropped
+func cropUVs(va []float32, geom libretro.GameGeometry, vp settings.Viewport) []float32 {
+	u0, v0, u1, v1 := overscanUV(geom, vp)
+	for i := 0; i+3 < len(va); i += 4 {
+		va[i+2] = u0 + va[i+2]*(u1-u0)
+		va[i+3] = v0 + va[i+3]*(v1-v0)
+	}
+	return va
+}
+
+// viewportAspect returns the aspect ratio of the game once cropped, cw and ch
+// being its size in pixels
+func viewportAspect(geom libretro.GameGeometry, vp settings.Viewport, cw, ch float32) float32 {
+	switch vp.AspectRatio {
+	case "4:3":
+		return 4.0 / 3.0
+	case "16:9":
+		return 16.0 / 9.0
+	case "1:1 PAR":
+		return cw / ch
+	}
+	// NXEngine workaround
+	aspectRatio := float32(geom.AspectRatio)
+	if aspectRatio == 0 {
+		aspectRatio = float32(geom.BaseWidth) / float32(geom.BaseHeight)
+	}
+	// The crop changes the shape of the pixels, not only the size of the image
+	return aspectRatio * (cw / float32(geom.BaseWidth)) / (ch / float32(geom.BaseHeight))
+}
+
+// fitViewport returns the biggest rectangle of an aspect ratio at the center of
+// the framebuffer
+func fitViewport(aspectRatio float32, fbWidth int, fbHeight int) (x, y, w, h float32) {
+	// Scale the content to fit in the viewport.
+	fbw := float32(fbWidth)
+	fbh := float32(fbHeight)
+
+	h = fbh
+	w = fbh * aspectRatio
+	if w > fbw {
+		h = fbw / aspectRatio
+		w = fbw
+	}
+
+	// Place the content in the middle of the window.
+	x = (fbw - w) / 2
+	y = (fbh - h) / 2
+	return
+}
+
+// gameViewport returns the rectangle of the framebuffer displaying the game,
+// for the viewport settings. The custom viewport is used as is, or fills the
+// framebuffer if it has no size. With integer scaling, the height of the game
+// is a multiple of its height in pixels, as long as it fits.
+func gameViewport(geom libretro.GameGeometry, vp settings.Viewport, fbWidth int, fbHeight int) (x, y, w, h float32) {
+	if vp.AspectRatio == "Custom" {
+		if vp.CustomWidth <= 0 || vp.CustomHeight <= 0 {
+			return 0, 0, float32(fbWidth), float32(fbHeight)
+		}
+		return float32(vp.CustomX), float32(vp.CustomY), float32(vp.CustomWidth), float32(vp.CustomHeight)
+	}
+	if geom.BaseWidth <= 0 || geom.BaseHeight <= 0 {
+		return fitViewport(float32(geom.AspectRatio), fbWidth, fbHeight)
+	}
+
+	u0, v0, u1, v1 := overscanUV(geom, vp)
+	cw := float32(geom.BaseWidth) * (u1 - u0)
+	ch := float32(geom.BaseHeight) * (v1 - v0)
+	aspectRatio := viewportAspect(geom, vp, cw, ch)
+
+	if vp.IntegerScale {
+		fbw, fbh := float32(fbWidth), float32(fbHeight)
+		n := float32(math.Floor(float64(min32(fbh/ch, fbw/(ch*aspectRatio)))))
+		if n >= 1 {
+			h = n * ch
+			w = h * aspectRatio
+			if vp.AspectRatio == "1:1 PAR" {
+				w = n * cw
+			}
+			// Keep the pixels aligned on the pixels of the framebuffer
+			x = float32(math.Floor(float64(fbw-w) / 2))
+			y = float32(math.Floor(float64(fbh-h) / 2))
+			return
+		}
+	}
+
+	return fitViewport(aspectRatio, fbWidth, fbHeight)
+}
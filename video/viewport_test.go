@@ -0,0 +1,144 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/settings"
+)
+
+func Test_gameViewport(t *testing.T) {
+	// A SNES game displayed at 4:3
+	snes := libretro.GameGeometry{BaseWidth: 256, BaseHeight: 224, AspectRatio: 4.0 / 3.0}
+
+	type rect struct{ x, y, w, h float32 }
+	tests := []struct {
+		name string
+		geom libretro.GameGeometry
+		vp   settings.Viewport
+		fbw  int
+		fbh  int
+		want rect
+	}{
+		{
+			name: "Fits the aspect ratio of the core",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "Core"},
+			fbw:  1920, fbh: 1080,
+			want: rect{240, 0, 1440, 1080},
+		},
+		{
+			name: "Uses the base size without aspect ratio",
+			geom: libretro.GameGeometry{BaseWidth: 320, BaseHeight: 240},
+			vp:   settings.Viewport{AspectRatio: "Core"},
+			fbw:  640, fbh: 640,
+			want: rect{0, 80, 640, 480},
+		},
+		{
+			name: "Forces 16:9",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "16:9"},
+			fbw:  1920, fbh: 1200,
+			want: rect{0, 60, 1920, 1080},
+		},
+		{
+			name: "Forces square pixels",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "1:1 PAR"},
+			fbw:  1120, fbh: 896,
+			want: rect{48, 0, 1024, 896},
+		},
+		{
+			name: "Scales the height by an integer",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "Core", IntegerScale: true},
+			fbw:  1920, fbh: 1080,
+			want: rect{362, 92, 1194.6667, 896},
+		},
+		{
+			name: "Scales square pixels by an integer",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "1:1 PAR", IntegerScale: true},
+			fbw:  1920, fbh: 1080,
+			want: rect{448, 92, 1024, 896},
+		},
+		{
+			name: "Fits when the window is too small for integer scaling",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "1:1 PAR", IntegerScale: true},
+			fbw:  200, fbh: 175,
+			want: rect{0, 0, 200, 175},
+		},
+		{
+			name: "Crops the overscan",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "1:1 PAR", CropTop: 8, CropBottom: 8},
+			fbw:  1024, fbh: 1024,
+			want: rect{0, 96, 1024, 832},
+		},
+		{
+			name: "Keeps the shape of the pixels of the core when cropping",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "Core", CropLeft: 128},
+			fbw:  800, fbh: 600,
+			want: rect{200, 0, 400, 600},
+		},
+		{
+			name: "Ignores crops bigger than the frame",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "Core", CropLeft: 200, CropRight: 200},
+			fbw:  800, fbh: 600,
+			want: rect{0, 0, 800, 600},
+		},
+		{
+			name: "Uses the custom viewport",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "Custom", CustomX: 10, CustomY: 20, CustomWidth: 300, CustomHeight: 200},
+			fbw:  800, fbh: 600,
+			want: rect{10, 20, 300, 200},
+		},
+		{
+			name: "Fills the window with an empty custom viewport",
+			geom: snes,
+			vp:   settings.Viewport{AspectRatio: "Custom"},
+			fbw:  800, fbh: 600,
+			want: rect{0, 0, 800, 600},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, w, h := gameViewport(tt.geom, tt.vp, tt.fbw, tt.fbh)
+			got := rect{x, y, w, h}
+			if !approx(got.x, tt.want.x) || !approx(got.y, tt.want.y) || !approx(got.w, tt.want.w) || !approx(got.h, tt.want.h) {
+				t.Errorf("gameViewport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cropUVs(t *testing.T) {
+	geom := libretro.GameGeometry{BaseWidth: 100, BaseHeight: 50}
+	vp := settings.Viewport{CropLeft: 10, CropRight: 20, CropTop: 5}
+	va := []float32{
+		-1, -1, 0, 1,
+		-1, 1, 0, 0,
+		1, -1, 1, 1,
+		1, 1, 1, 0,
+	}
+	want := []float32{
+		-1, -1, 0.1, 1,
+		-1, 1, 0.1, 0.1,
+		1, -1, 0.8, 1,
+		1, 1, 0.8, 0.1,
+	}
+	got := cropUVs(va, geom, vp)
+	for i := range want {
+		if !approx(got[i], want[i]) {
+			t.Fatalf("cropUVs() = %v, want %v", got, want)
+		}
+	}
+}
+
+func approx(a, b float32) bool {
+	return a-b < 1e-3 && b-a < 1e-3
+}